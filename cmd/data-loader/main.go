@@ -2,19 +2,34 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/tim8842/tender-data-loader/internal/agreement"
 	"github.com/tim8842/tender-data-loader/internal/config"
 	"github.com/tim8842/tender-data-loader/internal/customer"
 	"github.com/tim8842/tender-data-loader/internal/fiber"
+	"github.com/tim8842/tender-data-loader/internal/fillrate"
+	"github.com/tim8842/tender-data-loader/internal/health"
+	"github.com/tim8842/tender-data-loader/internal/notice"
+	"github.com/tim8842/tender-data-loader/internal/opendata"
+	"github.com/tim8842/tender-data-loader/internal/shard"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
 	"github.com/tim8842/tender-data-loader/internal/task"
+	"github.com/tim8842/tender-data-loader/internal/uagent"
 	"github.com/tim8842/tender-data-loader/internal/variable"
+	"github.com/tim8842/tender-data-loader/internal/webhook"
+	"github.com/tim8842/tender-data-loader/pkg/archive"
 	"github.com/tim8842/tender-data-loader/pkg/db/mongo"
 	"github.com/tim8842/tender-data-loader/pkg/logger"
 	"github.com/tim8842/tender-data-loader/pkg/repository"
+	"github.com/tim8842/tender-data-loader/pkg/request"
 	"go.uber.org/zap"
 )
 
@@ -25,26 +40,35 @@ import (
 // @BasePath /
 
 func main() {
+	// SIGINT/SIGTERM запускают остановку, сами задачи работают в mainCtx до конца остановки
+	sigCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 	mainCtx, cancel := context.WithCancel(context.Background())
 	//закрываю родителя, должны закрыться и дети
 	ctxTimeout, cancelTimeout := context.WithTimeout(mainCtx, 10*time.Second)
 	defer cancel()
 	defer cancelTimeout()
-	// Загрузка логера
-	lgr, _, err := logger.InitLogger("./logs", 100, 7, 30, true)
+	// Загрузка конфига: yaml с секциями или .env
+	configFile := flag.String("config", getenv("CONFIG_FILE", "configs/.env"), "файл конфигурации (.yaml или .env)")
+	flag.Parse()
+	configPath, err := filepath.Abs(*configFile)
 	if err != nil {
-		log.Fatalf("Ошибка инициализации логгера: %v", err)
+		log.Fatal(err)
 	}
-	defer lgr.Sync()
-	lgr.Info("Логгер инициализирован")
-	// Загрузка конфига
-	envPath, err := filepath.Abs("configs/.env")
+	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
-		panic(err)
+		log.Fatalf("Ошибка загрузки конфигурации: %v", err)
 	}
-	cfg, err := config.LoadConfig(envPath)
+	// Загрузка логера
+	lgr, _, err := logger.InitLogger(cfg.LogDir, cfg.LogMaxSize, cfg.LogMaxBackups, cfg.LogMaxAge, cfg.LogCompress)
 	if err != nil {
-		log.Fatalf("Ошибка загрузки конфигурации: %v", err)
+		log.Fatalf("Ошибка инициализации логгера: %v", err)
+	}
+	defer lgr.Sync()
+	lgr.Info("Логгер инициализирован", zap.String("profile", cfg.Profile), zap.String("config", configPath))
+	request.SetRateLimit(cfg.RateLimitRPS, cfg.RateLimitBurst)
+	if err := agreement.LoadRules(cfg.ParserRulesFile); err != nil {
+		log.Fatalf("Ошибка загрузки правил разбора: %v", err)
 	}
 	client, dbConn, err := mongo.SetupMongo(
 		ctxTimeout,
@@ -58,19 +82,107 @@ func main() {
 	if err != nil {
 		log.Fatal("Ошибка подключеник к монго", zap.Error(err))
 	}
-	defer client.Disconnect(mainCtx)
 	genAgreeRepo := repository.NewGenericRepository[*agreement.Agreement](dbConn.Collection("agreements"), lgr)
-	agreementRepo := &agreement.AgreementRepo{GenericRepository: genAgreeRepo}
+	genVersionRepo := repository.NewGenericRepository[*agreement.AgreementVersion](dbConn.Collection("agreement_versions"), lgr)
+	versionRepo := &agreement.AgreementVersionRepo{GenericRepository: genVersionRepo}
+	agreementRepo := &agreement.AgreementRepo{GenericRepository: genAgreeRepo, Versions: versionRepo}
 	genVarRepo := repository.NewGenericRepository[*variable.Variable](dbConn.Collection("variables"), lgr)
 	variableRepo := &variable.VariableRepo{GenericRepository: genVarRepo}
 	genCustomerRepo := repository.NewGenericRepository[*customer.Customer](dbConn.Collection("customers"), lgr)
 	customerRepo := &customer.CustomerRepo{GenericRepository: genCustomerRepo}
+	genSupplierRepo := repository.NewGenericRepository[*supplier.Supplier](dbConn.Collection("suppliers"), lgr)
+	supplierRepo := &supplier.SupplierRepo{GenericRepository: genSupplierRepo}
+	genNoticeRepo := repository.NewGenericRepository[*notice.Notice](dbConn.Collection("notices"), lgr)
+	noticeRepo := &notice.NoticeRepo{GenericRepository: genNoticeRepo}
+	shardRepo := &shard.ShardRepo{GenericRepository: repository.NewGenericRepository[*shard.Shard](dbConn.Collection("variables"), lgr)}
+	archiveRepo := &opendata.ArchiveRepo{GenericRepository: repository.NewGenericRepository[*opendata.ImportedArchive](dbConn.Collection("opendata_archives"), lgr)}
+	stagingRepo := &opendata.StagingRepo{GenericRepository: repository.NewGenericRepository[*opendata.StagedAgreement](dbConn.Collection("opendata_agreements"), lgr)}
+	if err := agreementRepo.EnsureIndexes(ctxTimeout); err != nil {
+		lgr.Warn("Не удалось создать индексы договоров", zap.Error(err))
+	}
+	if err := versionRepo.EnsureIndexes(ctxTimeout); err != nil {
+		lgr.Warn("Не удалось создать индексы версий договоров", zap.Error(err))
+	}
 	variable.CreateBaseVariables(ctxTimeout, lgr, variableRepo)
-	go task.StartTasks(mainCtx, lgr, cfg, agreementRepo, variableRepo, customerRepo)
-	app := fiber.SetupFiberApp(lgr, agreementRepo)
+	// Страницы качаем напрямую или через архив
+	var requester request.IRequester = &request.Requester{}
+	archiveMode, _ := archive.ParseMode(cfg.ArchiveMode)
+	if archiveMode != archive.ModeOff {
+		store, err := archive.NewStore(cfg.ArchiveBackend, cfg.ArchiveDir, dbConn)
+		if err != nil {
+			lgr.Fatal("Ошибка инициализации архива страниц", zap.Error(err))
+		}
+		requester = archive.NewRequester(requester, store, archiveMode)
+		lgr.Info("Архив страниц включен", zap.String("mode", cfg.ArchiveMode), zap.String("backend", cfg.ArchiveBackend))
+	}
+	var proxies *uagent.ProxyPool
+	if cfg.ProxyEnabled && archiveMode != archive.ModeReplay {
+		proxies = uagent.NewProxyPool(cfg.UrlGetProxy, &request.Requester{}, cfg.ProxyPoolSize, cfg.ProxyMaxFailures)
+		if err := proxies.Fill(ctxTimeout, lgr); err != nil {
+			lgr.Warn("Не удалось заполнить пул прокси", zap.Error(err))
+		}
+	}
+	subscriptionRepo := &webhook.SubscriptionRepo{
+		GenericRepository: repository.NewGenericRepository[*webhook.Subscription](dbConn.Collection("webhook_subscriptions"), lgr),
+	}
+	deadLetterRepo := &webhook.DeadLetterRepo{
+		GenericRepository: repository.NewGenericRepository[*webhook.DeadLetter](dbConn.Collection("webhook_dead_letters"), lgr),
+	}
+	dispatcher := webhook.NewDispatcher(
+		subscriptionRepo, deadLetterRepo, &http.Client{Timeout: cfg.WebhookTimeout},
+		webhook.DefaultPolicy(cfg.WebhookMaxRetries), webhook.DefaultQueueSize,
+	)
+	dispatcherDone := make(chan struct{})
+	go func() {
+		dispatcher.Run(mainCtx, lgr)
+		close(dispatcherDone)
+	}()
+	monitor := fillrate.NewMonitor(lgr, cfg.FillRateThreshold, cfg.FillRateMinBatch, cfg.FillRateHistory)
+	runner, err := task.SetupTasks(mainCtx, lgr, cfg, agreementRepo, variableRepo, customerRepo, supplierRepo, noticeRepo, shardRepo, archiveRepo, stagingRepo, proxies, requester, dispatcher, monitor)
+	if err != nil {
+		lgr.Fatal("Ошибка настройки задач", zap.Error(err))
+	}
+	tasksDone := make(chan error, 1)
+	go func() { tasksDone <- task.StartTasks(sigCtx, runner, cfg.ShutdownTimeout) }()
+	checker := health.NewChecker(cfg.HealthCheckTimeout,
+		health.Check{Name: "mongo", Fn: health.Ping(mongo.NewPingFuncInWrapp(client))},
+		health.Check{Name: "task_workers", Fn: health.Workers(runner)},
+		health.Check{Name: task.BackToNowAgreementTaskName, Fn: health.Progress(runner, task.BackToNowAgreementTaskName, cfg.HealthMaxBatchAge)},
+	)
+	app := fiber.SetupFiberApp(lgr, agreementRepo, versionRepo, customerRepo, supplierRepo, noticeRepo, subscriptionRepo, deadLetterRepo, runner, proxies, monitor, shardRepo, checker)
 
 	lgr.Info("Сервер запущен на :" + cfg.Port)
-	if err := app.Listen(":" + cfg.Port); err != nil {
-		lgr.Fatal("Ошибка при запуске сервера", zap.Error(err))
+	listenErr := make(chan error, 1)
+	go func() { listenErr <- app.Listen(":" + cfg.Port) }()
+	select {
+	case err := <-listenErr:
+		lgr.Error("Ошибка при запуске сервера", zap.Error(err))
+		stop()
+	case <-sigCtx.Done():
+		lgr.Info("Получен сигнал остановки")
+	}
+
+	// Порядок остановки: новые запросы, задачи с их текущей пачкой, вебхуки, затем монго
+	if err := app.ShutdownWithTimeout(cfg.ShutdownTimeout); err != nil {
+		lgr.Warn("Ошибка остановки сервера", zap.Error(err))
+	}
+	if err := <-tasksDone; err != nil {
+		lgr.Warn("Задачи остановлены принудительно, недокачанная пачка отброшена", zap.Error(err))
+	}
+	cancel()
+	// Недоставленные вебхуки пишутся в dead letter, монго для этого еще нужна
+	<-dispatcherDone
+	disconnectCtx, cancelDisconnect := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelDisconnect()
+	if err := client.Disconnect(disconnectCtx); err != nil {
+		lgr.Warn("Ошибка отключения от монго", zap.Error(err))
+	}
+	lgr.Info("Сервис остановлен")
+}
+
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
 	}
+	return fallback
 }
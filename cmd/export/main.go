@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/config"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/export"
+	"github.com/tim8842/tender-data-loader/pkg/db/mongo"
+	"github.com/tim8842/tender-data-loader/pkg/logger"
+	"github.com/tim8842/tender-data-loader/pkg/repository"
+	"go.uber.org/zap"
+)
+
+// Выгрузка договоров в файл, то же, что GET /export/agreements:
+//
+//	export -format xlsx -signed-from 2025-01-01 -signed-to 2025-03-31 -out q1.xlsx
+//	export -format jsonl -customer 492275 > customer.jsonl
+func main() {
+	formatFlag := flag.String("format", "csv", "формат: csv, jsonl, xlsx")
+	out := flag.String("out", "", "файл выгрузки, по умолчанию stdout")
+	signedFrom := flag.String("signed-from", "", "дата заключения с (YYYY-MM-DD)")
+	signedTo := flag.String("signed-to", "", "дата заключения по (YYYY-MM-DD)")
+	customerId := flag.String("customer", "", "ID заказчика")
+	supplierId := flag.String("supplier", "", "ID поставщика")
+	okpd2 := flag.String("okpd2", "", "префикс кода ОКПД2")
+	law := flag.String("law", "", "закон: 223 или 44")
+	batch := flag.Int("batch", export.DefaultBatchSize, "сколько договоров читать из курсора за раз")
+	configFile := flag.String("config", "configs/.env", "файл конфигурации (.yaml или .env)")
+	flag.Parse()
+
+	format, err := export.ParseFormat(*formatFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *law != "" && *law != agreement.LawFZ223 && *law != agreement.LawFZ44 {
+		log.Fatal(agreement.ErrBadLaw)
+	}
+	search := &agreement.AgreementSearch{CustomerId: *customerId, SupplierId: *supplierId, OKPD2: *okpd2, Law: *law}
+	if search.SignedFrom, err = parseDate(*signedFrom); err != nil {
+		log.Fatalf("signed-from: %v", err)
+	}
+	if search.SignedTo, err = parseDate(*signedTo); err != nil {
+		log.Fatalf("signed-to: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	envPath, err := filepath.Abs(*configFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(envPath)
+	if err != nil {
+		log.Fatalf("Ошибка загрузки конфигурации: %v", err)
+	}
+	lgr, _, err := logger.InitLogger(cfg.LogDir, cfg.LogMaxSize, cfg.LogMaxBackups, cfg.LogMaxAge, cfg.LogCompress)
+	if err != nil {
+		log.Fatalf("Ошибка инициализации логгера: %v", err)
+	}
+	defer lgr.Sync()
+
+	ctxTimeout, cancelTimeout := context.WithTimeout(ctx, 10*time.Second)
+	defer cancelTimeout()
+	client, dbConn, err := mongo.SetupMongo(
+		ctxTimeout,
+		lgr,
+		&mongo.MongoConfig{
+			User: cfg.MongoUser, Password: cfg.MongoPassword,
+			Host: cfg.MongoHost, Port: cfg.MongoPort,
+			DBName: cfg.MongoDB,
+		},
+	)
+	if err != nil {
+		log.Fatalf("Ошибка подключения к монго: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+	agreementRepo := &agreement.AgreementRepo{
+		GenericRepository: repository.NewGenericRepository[*agreement.Agreement](dbConn.Collection("agreements"), lgr),
+	}
+	customerRepo := &customer.CustomerRepo{
+		GenericRepository: repository.NewGenericRepository[*customer.Customer](dbConn.Collection("customers"), lgr),
+	}
+
+	dst := os.Stdout
+	if *out != "" {
+		if dst, err = os.Create(*out); err != nil {
+			log.Fatalf("Ошибка создания файла: %v", err)
+		}
+	}
+	w := bufio.NewWriter(dst)
+	rows, err := export.NewExporter(agreementRepo, customerRepo, *batch).Export(ctx, w, format, search.Filter())
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil && dst != os.Stdout {
+		err = dst.Close()
+	}
+	if err != nil {
+		lgr.Error("Выгрузка прервана", zap.Int("rows", rows), zap.Error(err))
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "rows: %d\n", rows)
+}
+
+func parseDate(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/config"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/reparse"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	"github.com/tim8842/tender-data-loader/pkg/db/mongo"
+	"github.com/tim8842/tender-data-loader/pkg/logger"
+	"github.com/tim8842/tender-data-loader/pkg/repository"
+	"go.uber.org/zap"
+)
+
+// Перепарсинг сохраненных страниц текущими парсерами без обращения к zakupki:
+//
+//	reparse -input ./archive -dry-run
+//	reparse -input pages.tar.gz
+func main() {
+	input := flag.String("input", "", "каталог архива страниц или tar (.tar, .tar.gz)")
+	dryRun := flag.Bool("dry-run", false, "не сохранять, только показать, какие поля изменятся")
+	batch := flag.Int("batch", reparse.DefaultBatchSize, "размер пачки для BulkMergeMany")
+	configFile := flag.String("config", "configs/.env", "файл конфигурации (.yaml или .env)")
+	flag.Parse()
+	if *input == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	// os.Exit не выполняет defer, поэтому вся работа в run: временный каталог
+	// из tar удаляется, а соединение с монго закрывается и при ошибке
+	if err := run(*input, *configFile, *batch, *dryRun); err != nil {
+		log.Printf("Перепарсинг прерван: %v", err)
+		os.Exit(1)
+	}
+}
+
+func run(input, configFile string, batch int, dryRun bool) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	configPath, err := filepath.Abs(configFile)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки конфигурации: %w", err)
+	}
+	lgr, _, err := logger.InitLogger(cfg.LogDir, cfg.LogMaxSize, cfg.LogMaxBackups, cfg.LogMaxAge, cfg.LogCompress)
+	if err != nil {
+		return fmt.Errorf("ошибка инициализации логгера: %w", err)
+	}
+	defer lgr.Sync()
+	if err := agreement.LoadRules(cfg.ParserRulesFile); err != nil {
+		return fmt.Errorf("ошибка загрузки правил разбора: %w", err)
+	}
+
+	store, cleanup, err := reparse.OpenStore(input)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия архива: %w", err)
+	}
+	defer cleanup()
+
+	ctxTimeout, cancelTimeout := context.WithTimeout(ctx, 10*time.Second)
+	defer cancelTimeout()
+	client, dbConn, err := mongo.SetupMongo(
+		ctxTimeout,
+		lgr,
+		&mongo.MongoConfig{
+			User: cfg.MongoUser, Password: cfg.MongoPassword,
+			Host: cfg.MongoHost, Port: cfg.MongoPort,
+			DBName: cfg.MongoDB,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к монго: %w", err)
+	}
+	defer client.Disconnect(context.Background())
+	versionRepo := &agreement.AgreementVersionRepo{
+		GenericRepository: repository.NewGenericRepository[*agreement.AgreementVersion](dbConn.Collection("agreement_versions"), lgr),
+	}
+	if err := versionRepo.EnsureIndexes(ctx); err != nil {
+		lgr.Warn("Не удалось создать индексы версий договоров", zap.Error(err))
+	}
+	agreementRepo := &agreement.AgreementRepo{
+		GenericRepository: repository.NewGenericRepository[*agreement.Agreement](dbConn.Collection("agreements"), lgr),
+		Versions:          versionRepo,
+	}
+	customerRepo := &customer.CustomerRepo{
+		GenericRepository: repository.NewGenericRepository[*customer.Customer](dbConn.Collection("customers"), lgr),
+	}
+
+	supplierRepo := &supplier.SupplierRepo{
+		GenericRepository: repository.NewGenericRepository[*supplier.Supplier](dbConn.Collection("suppliers"), lgr),
+	}
+
+	summary, err := reparse.NewReparser(cfg, store, agreementRepo, customerRepo, supplierRepo, batch, dryRun).Run(ctx, lgr)
+	fmt.Print(summary)
+	if err != nil {
+		lgr.Error("Перепарсинг прерван", zap.Error(err))
+		return err
+	}
+	return nil
+}
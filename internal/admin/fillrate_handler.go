@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tim8842/tender-data-loader/internal/fillrate"
+	"go.uber.org/zap"
+)
+
+// defaultFillRateBatches сколько последних пачек показывать по умолчанию
+const defaultFillRateBatches = 20
+
+type IFillRateMonitor interface {
+	Report(task string, last int) fillrate.Report
+}
+
+// FillRateHandler показывает заполненность полей по последним пачкам загрузки
+type FillRateHandler struct {
+	logger  *zap.Logger
+	monitor IFillRateMonitor
+}
+
+// NewFillRateHandler создает новый handler
+func NewFillRateHandler(logger *zap.Logger, monitor IFillRateMonitor) *FillRateHandler {
+	return &FillRateHandler{logger: logger, monitor: monitor}
+}
+
+// GetFillRates godoc
+// @Summary Заполненность полей
+// @Description Возвращает долю договоров с заполненными полями по последним пачкам каждой задачи. Падение доли обычно значит, что изменилась разметка страниц
+// @Tags admin
+// @Produce json
+// @Param task query string false "Имя задачи"
+// @Param last query int false "Сколько последних пачек учитывать" default(20)
+// @Success 200 {object} fillrate.Report
+// @Failure 400 {object} map[string]string
+// @Router /admin/fill-rates [get]
+func (h *FillRateHandler) GetFillRates(c *fiber.Ctx) error {
+	last := defaultFillRateBatches
+	if v := c.Query("last"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "last must be a positive integer"})
+		}
+		last = n
+	}
+	return c.JSON(h.monitor.Report(c.Query("task"), last))
+}
@@ -0,0 +1,61 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/internal/admin"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/fillrate"
+	"go.uber.org/zap"
+)
+
+func TestFillRateHandler_GetFillRates(t *testing.T) {
+	monitor := fillrate.NewMonitor(zap.NewNop(), 0.5, 10, 100)
+	data := []*agreement.AgreementParesedData{
+		{Number: "1", Price: 10, SignedAt: time.Now(), Customer: &customer.Customer{INN: "1"}},
+		{Number: "2"},
+	}
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, monitor.Observe("recent_agreement", data))
+	}
+	assert.NoError(t, monitor.Observe("back_to_now_agreement", data))
+	app := fiber.New()
+	app.Get("/admin/fill-rates", admin.NewFillRateHandler(zap.NewNop(), monitor).GetFillRates)
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedTasks  int
+		expectedLast   int
+	}{
+		{name: "all tasks", url: "/admin/fill-rates", expectedStatus: fiber.StatusOK, expectedTasks: 2, expectedLast: 1},
+		{name: "one task", url: "/admin/fill-rates?task=recent_agreement&last=2", expectedStatus: fiber.StatusOK, expectedTasks: 1, expectedLast: 2},
+		{name: "unknown task", url: "/admin/fill-rates?task=unknown", expectedStatus: fiber.StatusOK},
+		{name: "bad last", url: "/admin/fill-rates?last=0", expectedStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.url, nil))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.expectedStatus != fiber.StatusOK {
+				return
+			}
+			var report fillrate.Report
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+			assert.Len(t, report.Tasks, tt.expectedTasks)
+			if tt.expectedTasks > 0 {
+				assert.Len(t, report.Tasks[0].Last, tt.expectedLast)
+				assert.Equal(t, 0.5, report.Tasks[0].Rates["price"])
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package admin
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/tim8842/tender-data-loader/internal/uagent"
+	"go.uber.org/zap"
+)
+
+type IProxyPool interface {
+	Stats() uagent.PoolStats
+}
+
+// ProxyHandler показывает состояние пула прокси
+type ProxyHandler struct {
+	logger *zap.Logger
+	pool   IProxyPool
+}
+
+// NewProxyHandler создает новый handler
+func NewProxyHandler(logger *zap.Logger, pool IProxyPool) *ProxyHandler {
+	return &ProxyHandler{logger: logger, pool: pool}
+}
+
+// GetProxies godoc
+// @Summary Состояние пула прокси
+// @Description Возвращает прокси в пуле с их статистикой, отсортированные по оценке
+// @Tags admin
+// @Produce json
+// @Success 200 {object} uagent.PoolStats
+// @Router /admin/proxies [get]
+func (h *ProxyHandler) GetProxies(c *fiber.Ctx) error {
+	return c.JSON(h.pool.Stats())
+}
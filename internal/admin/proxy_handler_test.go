@@ -0,0 +1,27 @@
+package admin_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/internal/admin"
+	"github.com/tim8842/tender-data-loader/internal/uagent"
+	"go.uber.org/zap"
+)
+
+func TestProxyHandler_GetProxies(t *testing.T) {
+	// пул выключен — отдаем пустое состояние
+	var pool *uagent.ProxyPool
+	app := fiber.New()
+	app.Get("/admin/proxies", admin.NewProxyHandler(zap.NewNop(), pool).GetProxies)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/proxies", nil))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"enabled":false,"size":0,"target":0,"evicted":0,"proxies":[]}`, string(body))
+}
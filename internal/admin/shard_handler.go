@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tim8842/tender-data-loader/internal/shard"
+	"go.uber.org/zap"
+)
+
+type IShardLister interface {
+	List(ctx context.Context, task string) ([]*shard.Shard, error)
+}
+
+// ShardHandler показывает прогресс догрузки по шардам
+type ShardHandler struct {
+	logger      *zap.Logger
+	shards      IShardLister
+	defaultTask string
+}
+
+// NewShardHandler создает новый handler, defaultTask показывается, если задача не указана
+func NewShardHandler(logger *zap.Logger, shards IShardLister, defaultTask string) *ShardHandler {
+	return &ShardHandler{logger: logger, shards: shards, defaultTask: defaultTask}
+}
+
+// GetShards godoc
+// @Summary Шарды догрузки
+// @Description Возвращает шарды догрузки задачи с курсором, арендой и долей загруженных дней. Пустой список значит, что догрузка идет без шардов
+// @Tags admin
+// @Produce json
+// @Param task query string false "Имя задачи" default(back_to_now_agreement)
+// @Success 200 {object} shard.Report
+// @Failure 500 {object} map[string]string
+// @Router /admin/shards [get]
+func (h *ShardHandler) GetShards(c *fiber.Ctx) error {
+	task := c.Query("task", h.defaultTask)
+	shards, err := h.shards.List(c.Context(), task)
+	if err != nil {
+		h.logger.Error("Ошибка получения шардов", zap.String("task", task), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+	return c.JSON(shard.NewReport(task, shards, time.Now()))
+}
@@ -0,0 +1,60 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/internal/admin"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/tim8842/tender-data-loader/internal/shard"
+	"go.uber.org/zap"
+)
+
+func TestShardHandler_GetShards(t *testing.T) {
+	shards := shard.Plan("back_to_now_agreement", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name           string
+		url            string
+		task           string
+		shards         []*shard.Shard
+		err            error
+		expectedStatus int
+		expectedTotal  int
+		expectedDone   int
+	}{
+		{name: "default task", url: "/admin/shards", task: "back_to_now_agreement", shards: shards,
+			expectedStatus: fiber.StatusOK, expectedTotal: 2, expectedDone: 1},
+		{name: "no shards", url: "/admin/shards?task=other", task: "other", expectedStatus: fiber.StatusOK},
+		{name: "repo error", url: "/admin/shards", task: "back_to_now_agreement", err: errors.New("boom"),
+			expectedStatus: fiber.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(inmock.MockShardRepo)
+			repo.On("List", mock.Anything, tt.task).Return(tt.shards, tt.err)
+			app := fiber.New()
+			app.Get("/admin/shards", admin.NewShardHandler(zap.NewNop(), repo, "back_to_now_agreement").GetShards)
+
+			resp, err := app.Test(httptest.NewRequest("GET", tt.url, nil))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.expectedStatus != fiber.StatusOK {
+				return
+			}
+			var report shard.Report
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+			assert.Equal(t, tt.task, report.Task)
+			assert.Equal(t, tt.expectedTotal, report.Total)
+			assert.Equal(t, tt.expectedDone, report.Done)
+			assert.Len(t, report.Shards, tt.expectedTotal)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package admin
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tim8842/tender-data-loader/pkg"
+	"go.uber.org/zap"
+)
+
+type ITaskRunner interface {
+	Statuses() []pkg.TaskStatus
+	Status(taskName string) (pkg.TaskStatus, error)
+	Trigger(taskName string) error
+	Pause(taskName string) error
+	Resume(taskName string) error
+	Cancel(taskName string) error
+}
+
+// TaskHandler обрабатывает запросы управления задачами
+type TaskHandler struct {
+	logger *zap.Logger
+	runner ITaskRunner
+}
+
+// NewTaskHandler создает новый handler
+func NewTaskHandler(logger *zap.Logger, runner ITaskRunner) *TaskHandler {
+	return &TaskHandler{logger: logger, runner: runner}
+}
+
+// ListTasks godoc
+// @Summary Список задач
+// @Description Возвращает зарегистрированные задачи и их состояние
+// @Tags admin
+// @Produce json
+// @Success 200 {array} pkg.TaskStatus
+// @Router /admin/tasks [get]
+func (h *TaskHandler) ListTasks(c *fiber.Ctx) error {
+	return c.JSON(h.runner.Statuses())
+}
+
+// GetTask godoc
+// @Summary Состояние задачи
+// @Description Возвращает состояние задачи по имени
+// @Tags admin
+// @Produce json
+// @Param name path string true "Имя задачи"
+// @Success 200 {object} pkg.TaskStatus
+// @Failure 404 {object} map[string]string
+// @Router /admin/tasks/{name} [get]
+func (h *TaskHandler) GetTask(c *fiber.Ctx) error {
+	st, err := h.runner.Status(c.Params("name"))
+	if err != nil {
+		return h.error(c, err)
+	}
+	return c.JSON(st)
+}
+
+// TriggerTask godoc
+// @Summary Запустить задачу
+// @Description Ставит задачу в очередь вне расписания
+// @Tags admin
+// @Produce json
+// @Param name path string true "Имя задачи"
+// @Success 202 {object} pkg.TaskStatus
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /admin/tasks/{name}/trigger [post]
+func (h *TaskHandler) TriggerTask(c *fiber.Ctx) error {
+	return h.control(c, h.runner.Trigger, fiber.StatusAccepted)
+}
+
+// PauseTask godoc
+// @Summary Поставить задачу на паузу
+// @Description Запрещает новые запуски задачи, текущий запуск продолжается
+// @Tags admin
+// @Produce json
+// @Param name path string true "Имя задачи"
+// @Success 200 {object} pkg.TaskStatus
+// @Failure 404 {object} map[string]string
+// @Router /admin/tasks/{name}/pause [post]
+func (h *TaskHandler) PauseTask(c *fiber.Ctx) error {
+	return h.control(c, h.runner.Pause, fiber.StatusOK)
+}
+
+// ResumeTask godoc
+// @Summary Снять задачу с паузы
+// @Description Разрешает новые запуски задачи
+// @Tags admin
+// @Produce json
+// @Param name path string true "Имя задачи"
+// @Success 200 {object} pkg.TaskStatus
+// @Failure 404 {object} map[string]string
+// @Router /admin/tasks/{name}/resume [post]
+func (h *TaskHandler) ResumeTask(c *fiber.Ctx) error {
+	return h.control(c, h.runner.Resume, fiber.StatusOK)
+}
+
+// CancelTask godoc
+// @Summary Отменить выполнение задачи
+// @Description Отменяет контекст выполняющегося запуска задачи
+// @Tags admin
+// @Produce json
+// @Param name path string true "Имя задачи"
+// @Success 202 {object} pkg.TaskStatus
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/tasks/{name}/cancel [post]
+func (h *TaskHandler) CancelTask(c *fiber.Ctx) error {
+	return h.control(c, h.runner.Cancel, fiber.StatusAccepted)
+}
+
+func (h *TaskHandler) control(c *fiber.Ctx, action func(string) error, status int) error {
+	name := c.Params("name")
+	if err := action(name); err != nil {
+		return h.error(c, err)
+	}
+	h.logger.Info("Управление задачей", zap.String("task", name), zap.String("path", c.Path()))
+	st, err := h.runner.Status(name)
+	if err != nil {
+		return h.error(c, err)
+	}
+	return c.Status(status).JSON(st)
+}
+
+func (h *TaskHandler) error(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, pkg.ErrTaskNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, pkg.ErrTaskPaused), errors.Is(err, pkg.ErrTaskBusy), errors.Is(err, pkg.ErrTaskNotRunning):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, pkg.ErrRunnerStopped):
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": err.Error()})
+	}
+	h.logger.Error("Ошибка управления задачей", zap.Error(err))
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+}
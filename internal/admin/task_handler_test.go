@@ -0,0 +1,138 @@
+package admin_test
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/internal/admin"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/tim8842/tender-data-loader/pkg"
+	"go.uber.org/zap"
+)
+
+func setupTaskApp(runner admin.ITaskRunner) *fiber.App {
+	app := fiber.New()
+	h := admin.NewTaskHandler(zap.NewNop(), runner)
+	app.Get("/admin/tasks", h.ListTasks)
+	app.Get("/admin/tasks/:name", h.GetTask)
+	app.Post("/admin/tasks/:name/trigger", h.TriggerTask)
+	app.Post("/admin/tasks/:name/pause", h.PauseTask)
+	app.Post("/admin/tasks/:name/resume", h.ResumeTask)
+	app.Post("/admin/tasks/:name/cancel", h.CancelTask)
+	return app
+}
+
+func TestTaskHandler(t *testing.T) {
+	idle := pkg.TaskStatus{Name: "task", State: pkg.TaskStateIdle}
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		setupMock      func(m *inmock.MockTaskRunner)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "list",
+			method: "GET",
+			path:   "/admin/tasks",
+			setupMock: func(m *inmock.MockTaskRunner) {
+				m.On("Statuses").Return([]pkg.TaskStatus{idle})
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `[{"name":"task","state":"idle","paused":false,"scheduled":false,"runs":0,"failures":0,"cancels":0}]`,
+		},
+		{
+			name:   "get unknown",
+			method: "GET",
+			path:   "/admin/tasks/unknown",
+			setupMock: func(m *inmock.MockTaskRunner) {
+				m.On("Status", "unknown").Return(pkg.TaskStatus{}, pkg.ErrTaskNotFound)
+			},
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody:   `{"error":"task not found"}`,
+		},
+		{
+			name:   "trigger",
+			method: "POST",
+			path:   "/admin/tasks/task/trigger",
+			setupMock: func(m *inmock.MockTaskRunner) {
+				m.On("Trigger", "task").Return(nil)
+				m.On("Status", "task").Return(pkg.TaskStatus{Name: "task", State: pkg.TaskStateQueued}, nil)
+			},
+			expectedStatus: fiber.StatusAccepted,
+			expectedBody:   `{"name":"task","state":"queued","paused":false,"scheduled":false,"runs":0,"failures":0,"cancels":0}`,
+		},
+		{
+			name:   "trigger busy",
+			method: "POST",
+			path:   "/admin/tasks/task/trigger",
+			setupMock: func(m *inmock.MockTaskRunner) {
+				m.On("Trigger", "task").Return(pkg.ErrTaskBusy)
+			},
+			expectedStatus: fiber.StatusConflict,
+			expectedBody:   `{"error":"task already queued or running"}`,
+		},
+		{
+			name:   "pause",
+			method: "POST",
+			path:   "/admin/tasks/task/pause",
+			setupMock: func(m *inmock.MockTaskRunner) {
+				m.On("Pause", "task").Return(nil)
+				m.On("Status", "task").Return(pkg.TaskStatus{Name: "task", State: pkg.TaskStatePaused, Paused: true}, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `{"name":"task","state":"paused","paused":true,"scheduled":false,"runs":0,"failures":0,"cancels":0}`,
+		},
+		{
+			name:   "resume",
+			method: "POST",
+			path:   "/admin/tasks/task/resume",
+			setupMock: func(m *inmock.MockTaskRunner) {
+				m.On("Resume", "task").Return(nil)
+				m.On("Status", "task").Return(idle, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `{"name":"task","state":"idle","paused":false,"scheduled":false,"runs":0,"failures":0,"cancels":0}`,
+		},
+		{
+			name:   "cancel not running",
+			method: "POST",
+			path:   "/admin/tasks/task/cancel",
+			setupMock: func(m *inmock.MockTaskRunner) {
+				m.On("Cancel", "task").Return(pkg.ErrTaskNotRunning)
+			},
+			expectedStatus: fiber.StatusConflict,
+			expectedBody:   `{"error":"task not running"}`,
+		},
+		{
+			name:   "cancel unexpected error",
+			method: "POST",
+			path:   "/admin/tasks/task/cancel",
+			setupMock: func(m *inmock.MockTaskRunner) {
+				m.On("Cancel", "task").Return(errors.New("boom"))
+			},
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(inmock.MockTaskRunner)
+			tt.setupMock(m)
+			app := setupTaskApp(m)
+			resp, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			assert.JSONEq(t, tt.expectedBody, string(body))
+			m.AssertExpectations(t)
+		})
+	}
+}
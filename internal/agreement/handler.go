@@ -2,27 +2,35 @@ package agreement
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
 // AgreementHandler обрабатывает запросы к договорам
 type AgreementHandler struct {
-	logger    *zap.Logger
-	agreeRepo IAgreementRepo
+	logger      *zap.Logger
+	agreeRepo   IAgreementRepo
+	versionRepo IAgreementVersionRepo
 }
 
 // NewAgreementHandler создает новый handler
 func NewAgreementHandler(
 	logger *zap.Logger,
 	agreeRepo IAgreementRepo,
+	versionRepo IAgreementVersionRepo,
 ) *AgreementHandler {
 	return &AgreementHandler{
-		logger:    logger,
-		agreeRepo: agreeRepo,
+		logger:      logger,
+		agreeRepo:   agreeRepo,
+		versionRepo: versionRepo,
 	}
 }
 
@@ -63,3 +71,281 @@ func (h *AgreementHandler) GetAgreementByID(c *fiber.Ctx) error {
 
 	return c.JSON(agreement)
 }
+
+// AgreementHistory текущее состояние договора и прежние версии от старых к новым
+type AgreementHistory struct {
+	AgreementId string              `json:"agreement_id"`
+	Current     *Agreement          `json:"current"`
+	Versions    []*AgreementVersion `json:"versions"`
+}
+
+// GetAgreementHistory godoc
+// @Summary История изменений договора
+// @Description Возвращает текущее состояние договора и его прежние версии с измененными полями
+// @Tags agreements
+// @Accept json
+// @Produce json
+// @Param id path string true "ID договора"
+// @Success 200 {object} model.AgreementHistory
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /agreements/{id}/history [get]
+func (h *AgreementHandler) GetAgreementHistory(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	current, err := h.agreeRepo.GetByID(c.Context(), id)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) && !strings.Contains(err.Error(), "not found") {
+		h.logger.Error("Ошибка при получении договора", zap.String("id", id), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "internal server error",
+		})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: 1}, {Key: "replaced_at", Value: 1}})
+	versions, err := h.versionRepo.List(c.Context(), bson.M{"agreement_id": id}, opts)
+	if err != nil {
+		h.logger.Error("Ошибка при получении истории договора", zap.String("id", id), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "internal server error",
+		})
+	}
+	if current == nil && len(versions) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "agreement not found",
+		})
+	}
+	if versions == nil {
+		versions = []*AgreementVersion{}
+	}
+	return c.JSON(&AgreementHistory{
+		AgreementId: id,
+		Current:     current,
+		Versions:    versions,
+	})
+}
+
+// SearchAgreements godoc
+// @Summary Поиск договоров
+// @Description Возвращает договоры по фильтрам с пагинацией по курсору
+// @Tags agreements
+// @Accept json
+// @Produce json
+// @Param signed_from query string false "Дата заключения с (YYYY-MM-DD)"
+// @Param signed_to query string false "Дата заключения по (YYYY-MM-DD)"
+// @Param price_min query number false "Минимальная цена"
+// @Param price_max query number false "Максимальная цена"
+// @Param status query string false "Статус"
+// @Param purchase_method query string false "Способ закупки"
+// @Param law query string false "Закон: 223 или 44"
+// @Param customer_id query string false "ID заказчика"
+// @Param supplier_id query string false "ID поставщика"
+// @Param okpd2 query string false "Префикс кода ОКПД2"
+// @Param subject query string false "Подстрока предмета договора"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param limit query int false "Размер страницы (по умолчанию 50, максимум 500)"
+// @Param sort query string false "Поле сортировки: signed_at, published_at, updated_at, price"
+// @Param order query string false "Порядок сортировки: asc, desc (по умолчанию desc)"
+// @Success 200 {object} model.AgreementPage
+// @Failure 400 {object} map[string]string
+// @Router /agreements [get]
+func (h *AgreementHandler) SearchAgreements(c *fiber.Ctx) error {
+	search, err := ParseAgreementSearch(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	page, err := SearchAgreements(c.Context(), h.agreeRepo, search)
+	if err != nil {
+		if errors.Is(err, ErrBadCursor) || errors.Is(err, ErrBadSort) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		h.logger.Error("Ошибка при поиске договоров", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "internal server error",
+		})
+	}
+	return c.JSON(page)
+}
+
+// ParseAgreementSearch разбирает параметры запроса поиска договоров
+func ParseAgreementSearch(c *fiber.Ctx) (*AgreementSearch, error) {
+	s := &AgreementSearch{
+		Status:         c.Query("status"),
+		PurchaseMethod: c.Query("purchase_method"),
+		Law:            c.Query("law"),
+		CustomerId:     c.Query("customer_id"),
+		SupplierId:     c.Query("supplier_id"),
+		OKPD2:          c.Query("okpd2"),
+		Subject:        c.Query("subject"),
+		Cursor:         c.Query("cursor"),
+		SortBy:         c.Query("sort"),
+		Limit:          c.QueryInt("limit", DefaultSearchLimit),
+	}
+	if s.Law != "" && s.Law != LawFZ223 && s.Law != LawFZ44 {
+		return nil, ErrBadLaw
+	}
+	var err error
+	if s.SignedFrom, err = queryDate(c, "signed_from"); err != nil {
+		return nil, err
+	}
+	if s.SignedTo, err = queryDate(c, "signed_to"); err != nil {
+		return nil, err
+	}
+	if s.PriceMin, err = queryFloat(c, "price_min"); err != nil {
+		return nil, err
+	}
+	if s.PriceMax, err = queryFloat(c, "price_max"); err != nil {
+		return nil, err
+	}
+	switch c.Query("order", "desc") {
+	case "desc":
+		s.Desc = true
+	case "asc":
+		s.Desc = false
+	default:
+		return nil, errors.New("order must be asc or desc")
+	}
+	return s, nil
+}
+
+func queryDate(c *fiber.Ctx, key string) (*time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be in YYYY-MM-DD format", key)
+	}
+	return &t, nil
+}
+
+func queryFloat(c *fiber.Ctx, key string) (*float64, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", key)
+	}
+	return &f, nil
+}
+
+// CustomerAgreementsPage договоры заказчика с итогами
+type CustomerAgreementsPage struct {
+	CustomerId string `json:"customer_id"`
+	AgreementTotals
+	AgreementPage
+}
+
+// GetCustomerAgreements godoc
+// @Summary Договоры заказчика
+// @Description Возвращает договоры заказчика с итогами (количество, сумма цен)
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param id path string true "ID заказчика"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param limit query int false "Размер страницы (по умолчанию 50, максимум 500)"
+// @Param sort query string false "Поле сортировки: signed_at, published_at, updated_at, price"
+// @Param order query string false "Порядок сортировки: asc, desc (по умолчанию desc)"
+// @Success 200 {object} model.CustomerAgreementsPage
+// @Failure 400 {object} map[string]string
+// @Router /customers/{id}/agreements [get]
+func (h *AgreementHandler) GetCustomerAgreements(c *fiber.Ctx) error {
+	id := c.Params("id")
+	search, err := ParseAgreementSearch(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	search.CustomerId = id
+
+	page, err := SearchAgreements(c.Context(), h.agreeRepo, search)
+	if err != nil {
+		if errors.Is(err, ErrBadCursor) || errors.Is(err, ErrBadSort) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		h.logger.Error("Ошибка при получении договоров заказчика", zap.String("id", id), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "internal server error",
+		})
+	}
+	totals, err := CountTotals(c.Context(), h.agreeRepo, search.Filter())
+	if err != nil {
+		h.logger.Error("Ошибка при подсчете итогов заказчика", zap.String("id", id), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "internal server error",
+		})
+	}
+
+	return c.JSON(&CustomerAgreementsPage{
+		CustomerId:      id,
+		AgreementTotals: *totals,
+		AgreementPage:   *page,
+	})
+}
+
+// SupplierAgreementsPage договоры поставщика с итогами
+type SupplierAgreementsPage struct {
+	SupplierId string `json:"supplier_id"`
+	AgreementTotals
+	AgreementPage
+}
+
+// GetSupplierAgreements godoc
+// @Summary Договоры поставщика
+// @Description Возвращает договоры поставщика с итогами (количество, сумма цен)
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param id path string true "ID поставщика"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param limit query int false "Размер страницы (по умолчанию 50, максимум 500)"
+// @Param sort query string false "Поле сортировки: signed_at, published_at, updated_at, price"
+// @Param order query string false "Порядок сортировки: asc, desc (по умолчанию desc)"
+// @Success 200 {object} model.SupplierAgreementsPage
+// @Failure 400 {object} map[string]string
+// @Router /suppliers/{id}/agreements [get]
+func (h *AgreementHandler) GetSupplierAgreements(c *fiber.Ctx) error {
+	id := c.Params("id")
+	search, err := ParseAgreementSearch(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	search.SupplierId = id
+
+	page, err := SearchAgreements(c.Context(), h.agreeRepo, search)
+	if err != nil {
+		if errors.Is(err, ErrBadCursor) || errors.Is(err, ErrBadSort) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		h.logger.Error("Ошибка при получении договоров поставщика", zap.String("id", id), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "internal server error",
+		})
+	}
+	totals, err := CountTotals(c.Context(), h.agreeRepo, search.Filter())
+	if err != nil {
+		h.logger.Error("Ошибка при подсчете итогов поставщика", zap.String("id", id), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "internal server error",
+		})
+	}
+
+	return c.JSON(&SupplierAgreementsPage{
+		SupplierId:      id,
+		AgreementTotals: *totals,
+		AgreementPage:   *page,
+	})
+}
@@ -1,9 +1,12 @@
 package agreement_test
 
 import (
+	"encoding/json"
 	"errors"
+	"io"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
@@ -11,6 +14,7 @@ import (
 	"github.com/tim8842/tender-data-loader/internal/agreement"
 	inmock "github.com/tim8842/tender-data-loader/internal/mock"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
 )
@@ -34,7 +38,7 @@ func TestAgreementHandler_GetAgreementByID(t *testing.T) {
 			},
 			mockError:      nil,
 			expectedStatus: fiber.StatusOK,
-			expectedBody:   `{"customer_id":"", "execution_end":"0001-01-01T00:00:00Z", "execution_start":"0001-01-01T00:00:00Z", "id":"123", "notice_id":"", "number":"Test Agreement", "pdif":"", "price":0, "published_at":"0001-01-01T00:00:00Z", "purchase_method":"", "services": [], "signed_at":"0001-01-01T00:00:00Z", "status":"", "subject":"", "updated_at":"0001-01-01T00:00:00Z"}`,
+			expectedBody:   `{"customer_id":"", "execution_end":"0001-01-01T00:00:00Z", "execution_start":"0001-01-01T00:00:00Z", "id":"123", "law":"", "notice_id":"", "number":"Test Agreement", "pdif":"", "price":0, "published_at":"0001-01-01T00:00:00Z", "purchase_method":"", "services": [], "signed_at":"0001-01-01T00:00:00Z", "status":"", "subject":"", "supplier_ids":null, "updated_at":"0001-01-01T00:00:00Z"}`,
 		},
 		{
 			name:           "not found",
@@ -61,7 +65,7 @@ func TestAgreementHandler_GetAgreementByID(t *testing.T) {
 			defer logger.Sync()
 			mockRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
 			mockRepo.On("GetByID", mock.Anything, tt.id).Return(tt.mockReturn, tt.mockError)
-			h := agreement.NewAgreementHandler(logger, mockRepo)
+			h := agreement.NewAgreementHandler(logger, mockRepo, nil)
 			app.Get("/agreements/:id", h.GetAgreementByID)
 			req := httptest.NewRequest("GET", "/agreements/"+tt.id, nil)
 			resp, err := app.Test(req)
@@ -75,3 +79,246 @@ func TestAgreementHandler_GetAgreementByID(t *testing.T) {
 		})
 	}
 }
+
+func TestAgreementHandler_SearchAgreements(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockReturn     []*agreement.Agreement
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "successful search",
+			query:          "?signed_from=2025-01-01&price_min=100&okpd2=28.24&sort=price&order=asc",
+			mockReturn:     []*agreement.Agreement{{ID: "1"}},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:           "bad date",
+			query:          "?signed_from=01.01.2025",
+			expectedStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:           "bad order",
+			query:          "?order=up",
+			expectedStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:           "bad sort",
+			query:          "?sort=number",
+			expectedStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:           "bad law",
+			query:          "?law=94",
+			expectedStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:           "internal error",
+			query:          "",
+			mockError:      errors.New("internal error"),
+			expectedStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			logger := zap.NewNop()
+			mockRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+			mockRepo.On("CountDocuments", mock.Anything, mock.Anything).Return(int64(len(tt.mockReturn)), nil)
+			mockRepo.On("List", mock.Anything, mock.Anything, mock.Anything).Return(tt.mockReturn, tt.mockError)
+			h := agreement.NewAgreementHandler(logger, mockRepo, nil)
+			app.Get("/agreements", h.SearchAgreements)
+			req := httptest.NewRequest("GET", "/agreements"+tt.query, nil)
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestAgreementHandler_GetCustomerAgreements(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		listReturn     []*agreement.Agreement
+		aggReturn      []bson.M
+		aggError       error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "with totals",
+			id:             "492275",
+			listReturn:     []*agreement.Agreement{{ID: "1", CustomerId: "492275", Price: 100}, {ID: "2", CustomerId: "492275", Price: 50.5}},
+			aggReturn:      []bson.M{{"_id": nil, "count": int32(2), "total_price": 150.5}},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:           "no agreements",
+			id:             "1",
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `{"customer_id":"1","count":0,"total_price":0,"items":[],"total":0}`,
+		},
+		{
+			name:           "aggregate error",
+			id:             "2",
+			aggError:       errors.New("db error"),
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			mockRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+			mockRepo.On("CountDocuments", mock.Anything, bson.M{"customer_id": tt.id}).Return(int64(len(tt.listReturn)), nil)
+			mockRepo.On("List", mock.Anything, bson.M{"customer_id": tt.id}, mock.Anything).Return(tt.listReturn, nil)
+			mockRepo.On("Aggregate", mock.Anything, mock.Anything).Return(tt.aggReturn, tt.aggError)
+			h := agreement.NewAgreementHandler(zap.NewNop(), mockRepo, nil)
+			app.Get("/customers/:id/agreements", h.GetCustomerAgreements)
+			resp, err := app.Test(httptest.NewRequest("GET", "/customers/"+tt.id+"/agreements", nil))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, string(body))
+			} else {
+				var page agreement.CustomerAgreementsPage
+				assert.NoError(t, json.Unmarshal(body, &page))
+				assert.Equal(t, int64(2), page.Count)
+				assert.InDelta(t, 150.5, page.TotalPrice, 0.001)
+				assert.Len(t, page.Items, 2)
+			}
+		})
+	}
+}
+
+func TestAgreementHandler_GetSupplierAgreements(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		listReturn     []*agreement.Agreement
+		aggReturn      []bson.M
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "with totals",
+			id:             "5110001111_511001001",
+			listReturn:     []*agreement.Agreement{{ID: "1", SupplierIds: []string{"5110001111_511001001"}, Price: 100}},
+			aggReturn:      []bson.M{{"_id": nil, "count": int32(1), "total_price": 100.0}},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:           "bad order",
+			id:             "1",
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   `{"error":"order must be asc or desc"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			mockRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+			mockRepo.On("CountDocuments", mock.Anything, bson.M{"supplier_ids": tt.id}).Return(int64(len(tt.listReturn)), nil)
+			mockRepo.On("List", mock.Anything, bson.M{"supplier_ids": tt.id}, mock.Anything).Return(tt.listReturn, nil)
+			mockRepo.On("Aggregate", mock.Anything, mock.Anything).Return(tt.aggReturn, nil)
+			h := agreement.NewAgreementHandler(zap.NewNop(), mockRepo, nil)
+			app.Get("/suppliers/:id/agreements", h.GetSupplierAgreements)
+			url := "/suppliers/" + tt.id + "/agreements"
+			if tt.expectedStatus == fiber.StatusBadRequest {
+				url += "?order=up"
+			}
+			resp, err := app.Test(httptest.NewRequest("GET", url, nil))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, string(body))
+			} else {
+				var page agreement.SupplierAgreementsPage
+				assert.NoError(t, json.Unmarshal(body, &page))
+				assert.Equal(t, tt.id, page.SupplierId)
+				assert.Equal(t, int64(1), page.Count)
+				assert.Len(t, page.Items, 1)
+			}
+		})
+	}
+}
+
+func TestAgreementHandler_GetAgreementHistory(t *testing.T) {
+	date := time.Date(2025, 6, 11, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		id             string
+		current        *agreement.Agreement
+		currentErr     error
+		versions       []*agreement.AgreementVersion
+		versionsErr    error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "current with versions",
+			id:      "1",
+			current: &agreement.Agreement{ID: "1", Status: "Исполнение завершено", Price: 120},
+			versions: []*agreement.AgreementVersion{{
+				ID: "1_1749600000000", AgreementId: "1", UpdatedAt: date,
+				Changed:  []string{"status", "price"},
+				Snapshot: &agreement.Agreement{ID: "1", Status: "Исполнение", Price: 100},
+			}},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:           "not found",
+			id:             "2",
+			current:        nil,
+			currentErr:     mongo.ErrNoDocuments,
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody:   `{"error":"agreement not found"}`,
+		},
+		{
+			name:           "versions error",
+			id:             "3",
+			current:        &agreement.Agreement{ID: "3"},
+			versionsErr:    errors.New("db error"),
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			mockRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+			mockVersions := new(inmock.MockGenericRepository[*agreement.AgreementVersion])
+			mockRepo.On("GetByID", mock.Anything, tt.id).Return(tt.current, tt.currentErr)
+			mockVersions.On("List", mock.Anything, bson.M{"agreement_id": tt.id}, mock.Anything).Return(tt.versions, tt.versionsErr)
+			h := agreement.NewAgreementHandler(zap.NewNop(), mockRepo, mockVersions)
+			app.Get("/agreements/:id/history", h.GetAgreementHistory)
+			resp, err := app.Test(httptest.NewRequest("GET", "/agreements/"+tt.id+"/history", nil))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, string(body))
+				return
+			}
+			var history agreement.AgreementHistory
+			assert.NoError(t, json.Unmarshal(body, &history))
+			assert.Equal(t, 120.0, history.Current.Price)
+			assert.Len(t, history.Versions, 1)
+			assert.Equal(t, "Исполнение", history.Versions[0].Snapshot.Status)
+			assert.Equal(t, []string{"status", "price"}, history.Versions[0].Changed)
+		})
+	}
+}
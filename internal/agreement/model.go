@@ -4,6 +4,13 @@ import (
 	"time"
 
 	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+)
+
+// Закон, по которому заключен договор
+const (
+	LawFZ223 = "223"
+	LawFZ44  = "44"
 )
 
 type AgreementService struct {
@@ -23,6 +30,7 @@ type AgreementService struct {
 
 type AgreementParesedData struct {
 	ID             string    `bson:"_id,omitempty" json:"id"` // номер договора (идентификатор)
+	Law            string    `bson:"law" json:"law"`          // 223 или 44, пусто — 223
 	Number         string    `bson:"number,omitempty" json:"number"`
 	Status         string    `bson:"status,omitempty" json:"status"`
 	Pfid           string    `bson:"pfid,omitempty" json:"pdif"`
@@ -38,12 +46,14 @@ type AgreementParesedData struct {
 	PurchaseMethod string `bson:"purchase_method" json:"purchase_method"` // способ закупки
 	Subject        string `bson:"subject" json:"subject"`                 // предмет договора
 
-	Customer *customer.Customer  `bson:"customer" json:"customer"` // вложенный заказчик
-	Services []*AgreementService `bson:"services" json:"services"` // список услуг
+	Customer  *customer.Customer   `bson:"customer" json:"customer"`   // вложенный заказчик
+	Suppliers []*supplier.Supplier `bson:"suppliers" json:"suppliers"` // поставщики по договору
+	Services  []*AgreementService  `bson:"services" json:"services"`   // список услуг
 }
 
 type Agreement struct {
 	ID             string    `bson:"_id,omitempty" json:"id"` // номер договора (идентификатор)
+	Law            string    `bson:"law" json:"law"`          // 223 или 44, у договоров без поля — 223
 	Number         string    `bson:"number,omitempty" json:"number"`
 	Status         string    `bson:"status,omitempty" json:"status"`
 	Pfid           string    `bson:"pfid,omitempty" json:"pdif"`
@@ -59,17 +69,19 @@ type Agreement struct {
 	PurchaseMethod string `bson:"purchase_method" json:"purchase_method"` // способ закупки
 	Subject        string `bson:"subject" json:"subject"`                 // предмет договора
 
-	CustomerId string              `bson:"customer_id" json:"customer_id"` // вложенный заказчик
-	Services   []*AgreementService `bson:"services" json:"services"`       // список услуг
+	CustomerId  string              `bson:"customer_id" json:"customer_id"`   // вложенный заказчик
+	SupplierIds []string            `bson:"supplier_ids" json:"supplier_ids"` // ID поставщиков
+	Services    []*AgreementService `bson:"services" json:"services"`         // список услуг
 }
 
 func (t Agreement) GetID() any {
 	return t.ID
 }
 
-func ParseAgreementDataToModels(data *AgreementParesedData) (*Agreement, *customer.Customer) {
+func ParseAgreementDataToModels(data *AgreementParesedData) (*Agreement, *customer.Customer, []*supplier.Supplier) {
 	agreement := &Agreement{
 		ID:             data.ID,
+		Law:            data.Law,
 		Number:         data.Number,
 		Status:         data.Status,
 		Pfid:           data.Pfid,
@@ -86,6 +98,15 @@ func ParseAgreementDataToModels(data *AgreementParesedData) (*Agreement, *custom
 		Services:       data.Services,
 	}
 
+	if agreement.Law == "" {
+		agreement.Law = LawFZ223
+	}
+
+	suppliers := supplier.Merge(nil, data.Suppliers...)
+	for _, s := range suppliers {
+		agreement.SupplierIds = append(agreement.SupplierIds, s.ID)
+	}
+
 	customer := &customer.Customer{
 		ID:       data.Customer.ID,
 		Code:     data.Customer.Code,
@@ -96,5 +117,5 @@ func ParseAgreementDataToModels(data *AgreementParesedData) (*Agreement, *custom
 		Location: data.Customer.Location,
 	}
 
-	return agreement, customer
+	return agreement, customer, suppliers
 }
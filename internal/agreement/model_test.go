@@ -7,15 +7,17 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/tim8842/tender-data-loader/internal/agreement"
 	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
 )
 
 func TestParseAgreementDataToModels(t *testing.T) {
 	now := time.Now()
 
 	tests := []struct {
-		name     string
-		input    *agreement.AgreementParesedData
-		expected *agreement.Agreement
+		name              string
+		input             *agreement.AgreementParesedData
+		expected          *agreement.Agreement
+		expectedSuppliers int
 	}{
 		{
 			name: "basic agreement and customer",
@@ -42,6 +44,11 @@ func TestParseAgreementDataToModels(t *testing.T) {
 					MainWork: "Software development",
 					Location: "Moscow",
 				},
+				Suppliers: []*supplier.Supplier{
+					{Name: "ООО Ромашка", INN: "7701000001", KPP: "770101001"},
+					{Name: "ООО Ромашка", INN: "7701000001", KPP: "770101001", OGRN: "1027700000001"},
+					{Name: "ИП Иванов", INN: "770100000002"},
+				},
 				Services: []*agreement.AgreementService{
 					{
 						Name:         "Development",
@@ -54,6 +61,7 @@ func TestParseAgreementDataToModels(t *testing.T) {
 			},
 			expected: &agreement.Agreement{
 				ID:             "agr123",
+				Law:            agreement.LawFZ223,
 				Number:         "A-001",
 				Status:         "active",
 				Pfid:           "pfid123",
@@ -67,6 +75,7 @@ func TestParseAgreementDataToModels(t *testing.T) {
 				PurchaseMethod: "auction",
 				Subject:        "IT services",
 				CustomerId:     "cust001",
+				SupplierIds:    []string{"7701000001_770101001", "770100000002"},
 				Services: []*agreement.AgreementService{
 					{
 						Name:         "Development",
@@ -77,13 +86,15 @@ func TestParseAgreementDataToModels(t *testing.T) {
 					},
 				},
 			},
+			expectedSuppliers: 2,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			agreement, customer := agreement.ParseAgreementDataToModels(tt.input)
+			agreement, customer, suppliers := agreement.ParseAgreementDataToModels(tt.input)
 			assert.Equal(t, tt.expected, agreement)
+			assert.Len(t, suppliers, tt.expectedSuppliers)
 			assert.Equal(t, tt.input.Customer.ID, customer.ID)
 			assert.Equal(t, tt.input.Customer.Name, customer.Name)
 		})
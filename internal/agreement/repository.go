@@ -2,23 +2,132 @@ package agreement
 
 import (
 	"context"
+	"time"
 
 	"github.com/tim8842/tender-data-loader/pkg/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type AgreementRepo struct {
 	*repository.GenericRepository[*Agreement]
+	Versions IAgreementVersionRepo // если задан, BulkMergeMany сохраняет заменяемые версии
 }
 
 func (r *AgreementRepo) GetByID(ctx context.Context, id string) (*Agreement, error) {
 	return r.GenericRepository.GetByID(ctx, id)
 }
 
+// BulkMergeMany заменяет договоры целиком. Прежние состояния изменившихся договоров
+// сначала пишутся в Versions, чтобы при ошибке замены история не потерялась
 func (r *AgreementRepo) BulkMergeMany(ctx context.Context, docs []*Agreement) error {
+	if r.Versions != nil && len(docs) > 0 {
+		if err := r.saveVersions(ctx, docs); err != nil {
+			return err
+		}
+	}
 	return r.BulkCreateOrUpdateMany(ctx, docs)
 }
 
+func (r *AgreementRepo) saveVersions(ctx context.Context, docs []*Agreement) error {
+	ids := make([]string, len(docs))
+	for i, d := range docs {
+		ids[i] = d.ID
+	}
+	stored, err := r.GenericRepository.List(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return err
+	}
+	versions := BuildVersions(stored, docs, time.Now())
+	if len(versions) == 0 {
+		return nil
+	}
+	// Ретрай после ошибки замены снова найдет те же изменения, записанную версию не дублируем
+	changedIds := make([]string, len(versions))
+	for i, v := range versions {
+		changedIds[i] = v.AgreementId
+	}
+	latest, err := r.latestVersions(ctx, changedIds)
+	if err != nil {
+		return err
+	}
+	versions = SkipRecorded(versions, latest)
+	if len(versions) == 0 {
+		return nil
+	}
+	return r.Versions.BulkMergeMany(ctx, versions)
+}
+
+// latestVersions последняя записанная версия каждого договора, только agreement_id и hash
+func (r *AgreementRepo) latestVersions(ctx context.Context, ids []string) ([]*AgreementVersion, error) {
+	rows, err := r.Versions.Aggregate(ctx, bson.A{
+		bson.M{"$match": bson.M{"agreement_id": bson.M{"$in": ids}}},
+		bson.M{"$sort": bson.D{{Key: "agreement_id", Value: 1}, {Key: "replaced_at", Value: -1}}},
+		bson.M{"$group": bson.M{"_id": "$agreement_id", "hash": bson.M{"$first": "$hash"}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	latest := make([]*AgreementVersion, 0, len(rows))
+	for _, row := range rows {
+		id, _ := row["_id"].(string)
+		hash, _ := row["hash"].(string)
+		latest = append(latest, &AgreementVersion{AgreementId: id, Hash: hash})
+	}
+	return latest, nil
+}
+
+// EnsureIndexes создает индексы, на которые опираются задачи: notice_id — курсор
+// загрузки извещений
+func (r *AgreementRepo) EnsureIndexes(ctx context.Context) error {
+	return r.CreateIndexes(ctx, mongo.IndexModel{Keys: bson.D{{Key: "notice_id", Value: 1}}})
+}
+
+func (r *AgreementRepo) List(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*Agreement, error) {
+	return r.GenericRepository.List(ctx, filter, opts...)
+}
+
+func (r *AgreementRepo) CountDocuments(ctx context.Context, filter interface{}) (int64, error) {
+	return r.GenericRepository.CountDocuments(ctx, filter)
+}
+
+func (r *AgreementRepo) Aggregate(ctx context.Context, pipeline interface{}) ([]bson.M, error) {
+	return r.GenericRepository.Aggregate(ctx, pipeline)
+}
+
 type IAgreementRepo interface {
 	GetByID(ctx context.Context, id string) (*Agreement, error)
 	BulkMergeMany(ctx context.Context, docs []*Agreement) error
+	List(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*Agreement, error)
+	CountDocuments(ctx context.Context, filter interface{}) (int64, error)
+	Aggregate(ctx context.Context, pipeline interface{}) ([]bson.M, error)
+	Stream(ctx context.Context, filter interface{}, fn func(*Agreement) error, opts ...*options.FindOptions) error
+}
+
+type AgreementVersionRepo struct {
+	*repository.GenericRepository[*AgreementVersion]
+}
+
+func (r *AgreementVersionRepo) BulkMergeMany(ctx context.Context, docs []*AgreementVersion) error {
+	return r.BulkCreateOrUpdateMany(ctx, docs)
+}
+
+func (r *AgreementVersionRepo) List(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*AgreementVersion, error) {
+	return r.GenericRepository.List(ctx, filter, opts...)
+}
+
+func (r *AgreementVersionRepo) Aggregate(ctx context.Context, pipeline interface{}) ([]bson.M, error) {
+	return r.GenericRepository.Aggregate(ctx, pipeline)
+}
+
+// EnsureIndexes создает индекс для поиска последней версии договора
+func (r *AgreementVersionRepo) EnsureIndexes(ctx context.Context) error {
+	return r.CreateIndexes(ctx, mongo.IndexModel{Keys: bson.D{{Key: "agreement_id", Value: 1}, {Key: "replaced_at", Value: -1}}})
+}
+
+type IAgreementVersionRepo interface {
+	BulkMergeMany(ctx context.Context, docs []*AgreementVersion) error
+	List(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*AgreementVersion, error)
+	Aggregate(ctx context.Context, pipeline interface{}) ([]bson.M, error)
 }
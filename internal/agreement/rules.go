@@ -0,0 +1,62 @@
+package agreement
+
+import (
+	_ "embed"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/tim8842/tender-data-loader/pkg/extract"
+)
+
+// Страницы, которые разбираются по правилам из rules.yaml
+const (
+	PageAgreementMain      = "agreement_main"
+	PageAgreementPrintForm = "agreement_print_form"
+	PageCustomerMain       = "customer_main"
+)
+
+//go:embed rules.yaml
+var defaultRulesData []byte
+
+var rules atomic.Pointer[extract.Rules]
+
+func init() {
+	r, err := extract.Load(defaultRulesData)
+	if err != nil {
+		panic("agreement: bad default parser rules: " + err.Error())
+	}
+	rules.Store(r)
+}
+
+// DefaultRules правила разбора, встроенные в бинарник
+func DefaultRules() *extract.Rules {
+	r, _ := extract.Load(defaultRulesData)
+	return r
+}
+
+// SetRules заменяет правила разбора страниц договора. Должны быть описаны все страницы Page*,
+// и все поля правил должны быть в AgreementParesedData, куда их пишет разбор
+func SetRules(r *extract.Rules) error {
+	for _, page := range []string{PageAgreementMain, PageAgreementPrintForm, PageCustomerMain} {
+		if len(r.Pages[page]) == 0 {
+			return fmt.Errorf("no rules for page %s", page)
+		}
+		if err := r.Check(page, &AgreementParesedData{}); err != nil {
+			return err
+		}
+	}
+	rules.Store(r)
+	return nil
+}
+
+// LoadRules загружает правила разбора из файла, пустой путь — встроенные правила
+func LoadRules(path string) error {
+	if path == "" {
+		return SetRules(DefaultRules())
+	}
+	r, err := extract.LoadFile(path)
+	if err != nil {
+		return err
+	}
+	return SetRules(r)
+}
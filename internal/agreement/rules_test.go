@@ -0,0 +1,72 @@
+package agreement_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/pkg/reader"
+)
+
+func TestDefaultRules_PrintFormVersion(t *testing.T) {
+	dir := "../../assets/test/ParseAgreementFromHtml"
+	tests := []struct {
+		name            string
+		file            string
+		expectedVersion string
+	}{
+		{name: "old form", file: "correctOld2013.html", expectedVersion: "2013"},
+		{name: "new form", file: "correctNew2024.html", expectedVersion: "2024"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(bytes.NewReader(reader.ReadHtmlFile(dir + "/" + tt.file)))
+			require.NoError(t, err)
+			layout, err := agreement.DefaultRules().Layout(agreement.PageAgreementPrintForm, doc)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedVersion, layout.Version)
+		})
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	defer agreement.LoadRules("")
+	dir := t.TempDir()
+	partial := filepath.Join(dir, "partial.yaml")
+	require.NoError(t, os.WriteFile(partial, []byte(`
+pages:
+  agreement_main:
+    - version: "1"
+      fields:
+        - {field: number, selector: .number}
+`), 0o644))
+	typo := filepath.Join(dir, "typo.yaml")
+	require.NoError(t, os.WriteFile(typo, []byte(`
+pages:
+  agreement_main:
+    - version: "1"
+      fields:
+        - {field: number, selector: .number}
+  agreement_print_form:
+    - version: "1"
+      fields:
+        - {field: customer.innn, selector: .inn}
+  customer_main:
+    - version: "1"
+      fields:
+        - {field: customer.location, selector: .location}
+`), 0o644))
+	broken := filepath.Join(dir, "broken.yaml")
+	require.NoError(t, os.WriteFile(broken, []byte("pages: ["), 0o644))
+
+	assert.NoError(t, agreement.LoadRules(""))
+	assert.ErrorContains(t, agreement.LoadRules(partial), "no rules for page agreement_print_form")
+	assert.ErrorContains(t, agreement.LoadRules(typo), "field customer.innn: no field innn")
+	assert.Error(t, agreement.LoadRules(broken))
+	assert.Error(t, agreement.LoadRules(filepath.Join(dir, "missing.yaml")))
+}
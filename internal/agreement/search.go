@@ -0,0 +1,300 @@
+package agreement
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 500
+)
+
+// sortFields поля, по которым разрешена сортировка, и признак того, что поле - дата
+var sortFields = map[string]bool{
+	"signed_at":    true,
+	"published_at": true,
+	"updated_at":   true,
+	"price":        false,
+}
+
+var (
+	ErrBadCursor = errors.New("bad cursor")
+	ErrBadSort   = errors.New("unsupported sort field")
+	ErrBadLaw    = errors.New("law must be 223 or 44")
+)
+
+// AgreementSearch параметры поиска договоров
+type AgreementSearch struct {
+	SignedFrom     *time.Time
+	SignedTo       *time.Time
+	PriceMin       *float64
+	PriceMax       *float64
+	Status         string
+	PurchaseMethod string
+	Law            string // 223 или 44
+	CustomerId     string
+	SupplierId     string
+	OKPD2          string // префикс кода ОКПД2 любой из услуг
+	Subject        string // подстрока предмета договора
+
+	Cursor string
+	Limit  int
+	SortBy string
+	Desc   bool
+}
+
+// AgreementPage страница результатов поиска
+type AgreementPage struct {
+	Items      []*Agreement `json:"items"`
+	Total      int64        `json:"total"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+type searchCursor struct {
+	Value any    `json:"v"`
+	ID    string `json:"id"`
+}
+
+// Normalize проставляет значения по умолчанию и проверяет параметры
+func (s *AgreementSearch) Normalize() error {
+	if s.Limit <= 0 {
+		s.Limit = DefaultSearchLimit
+	}
+	if s.Limit > MaxSearchLimit {
+		s.Limit = MaxSearchLimit
+	}
+	if s.SortBy == "" {
+		s.SortBy = "signed_at"
+	}
+	if _, ok := sortFields[s.SortBy]; !ok {
+		return fmt.Errorf("%w: %s", ErrBadSort, s.SortBy)
+	}
+	return nil
+}
+
+// Filter строит фильтр mongo без учета курсора
+func (s *AgreementSearch) Filter() bson.M {
+	filter := bson.M{}
+	if s.SignedFrom != nil || s.SignedTo != nil {
+		signed := bson.M{}
+		if s.SignedFrom != nil {
+			signed["$gte"] = *s.SignedFrom
+		}
+		if s.SignedTo != nil {
+			signed["$lte"] = *s.SignedTo
+		}
+		filter["signed_at"] = signed
+	}
+	if s.PriceMin != nil || s.PriceMax != nil {
+		price := bson.M{}
+		if s.PriceMin != nil {
+			price["$gte"] = *s.PriceMin
+		}
+		if s.PriceMax != nil {
+			price["$lte"] = *s.PriceMax
+		}
+		filter["price"] = price
+	}
+	if s.Status != "" {
+		filter["status"] = s.Status
+	}
+	if s.PurchaseMethod != "" {
+		filter["purchase_method"] = s.PurchaseMethod
+	}
+	switch s.Law {
+	case LawFZ223:
+		// Договоры, загруженные до появления поля law, — все по 223-ФЗ
+		filter["law"] = bson.M{"$in": bson.A{LawFZ223, nil}}
+	case LawFZ44:
+		filter["law"] = LawFZ44
+	}
+	if s.CustomerId != "" {
+		filter["customer_id"] = s.CustomerId
+	}
+	if s.SupplierId != "" {
+		filter["supplier_ids"] = s.SupplierId
+	}
+	if s.OKPD2 != "" {
+		// В базе код хранится вместе с префиксом "ОКПД2:" и наименованием
+		filter["services.okpd2"] = bson.M{"$regex": `^(ОКПД2:\s*)?` + regexp.QuoteMeta(s.OKPD2)}
+	}
+	if s.Subject != "" {
+		filter["subject"] = bson.M{"$regex": regexp.QuoteMeta(s.Subject), "$options": "i"}
+	}
+	return filter
+}
+
+// cursorFilter добавляет к фильтру условие "после курсора"
+func (s *AgreementSearch) cursorFilter(filter bson.M) (bson.M, error) {
+	if s.Cursor == "" {
+		return filter, nil
+	}
+	c, err := decodeCursor(s.Cursor, sortFields[s.SortBy])
+	if err != nil {
+		return nil, err
+	}
+	op := "$gt"
+	if s.Desc {
+		op = "$lt"
+	}
+	after := bson.M{"$or": bson.A{
+		bson.M{s.SortBy: bson.M{op: c.Value}},
+		bson.M{s.SortBy: c.Value, "_id": bson.M{op: c.ID}},
+	}}
+	if len(filter) == 0 {
+		return after, nil
+	}
+	return bson.M{"$and": bson.A{filter, after}}, nil
+}
+
+func (s *AgreementSearch) findOptions() *options.FindOptions {
+	dir := 1
+	if s.Desc {
+		dir = -1
+	}
+	return options.Find().
+		SetSort(bson.D{{Key: s.SortBy, Value: dir}, {Key: "_id", Value: dir}}).
+		SetLimit(int64(s.Limit + 1))
+}
+
+// SearchAgreements ищет договоры по фильтрам с пагинацией по курсору
+func SearchAgreements(ctx context.Context, repo IAgreementRepo, s *AgreementSearch) (*AgreementPage, error) {
+	if err := s.Normalize(); err != nil {
+		return nil, err
+	}
+	filter := s.Filter()
+	total, err := repo.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	pageFilter, err := s.cursorFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	items, err := repo.List(ctx, pageFilter, s.findOptions())
+	if err != nil {
+		return nil, err
+	}
+	page := &AgreementPage{Items: items, Total: total}
+	if len(items) > s.Limit {
+		page.Items = items[:s.Limit]
+		last := page.Items[s.Limit-1]
+		page.NextCursor, err = encodeCursor(s.SortBy, last)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if page.Items == nil {
+		page.Items = []*Agreement{}
+	}
+	return page, nil
+}
+
+func encodeCursor(sortBy string, a *Agreement) (string, error) {
+	c := searchCursor{ID: a.ID}
+	switch sortBy {
+	case "signed_at":
+		c.Value = a.SignedAt
+	case "published_at":
+		c.Value = a.PublishedAt
+	case "updated_at":
+		c.Value = a.UpdatedAt
+	case "price":
+		c.Value = a.Price
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(raw string, isDate bool) (*searchCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrBadCursor
+	}
+	var c searchCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == "" {
+		return nil, ErrBadCursor
+	}
+	switch v := c.Value.(type) {
+	case string:
+		if !isDate {
+			return nil, ErrBadCursor
+		}
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return nil, ErrBadCursor
+		}
+		c.Value = t
+	case float64:
+		if isDate {
+			return nil, ErrBadCursor
+		}
+	default:
+		return nil, ErrBadCursor
+	}
+	return &c, nil
+}
+
+// AgreementTotals итоги по договорам
+type AgreementTotals struct {
+	Count      int64   `json:"count"`
+	TotalPrice float64 `json:"total_price"`
+}
+
+// CountTotals считает количество и сумму цен договоров по фильтру
+func CountTotals(ctx context.Context, repo IAgreementRepo, filter bson.M) (*AgreementTotals, error) {
+	rows, err := repo.Aggregate(ctx, bson.A{
+		bson.M{"$match": filter},
+		bson.M{"$group": bson.M{
+			"_id":         nil,
+			"count":       bson.M{"$sum": 1},
+			"total_price": bson.M{"$sum": "$price"},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	totals := &AgreementTotals{}
+	if len(rows) == 0 {
+		return totals, nil
+	}
+	totals.Count = toInt64(rows[0]["count"])
+	totals.TotalPrice = toFloat64(rows[0]["total_price"])
+	return totals, nil
+}
+
+func toInt64(v any) int64 {
+	switch n := v.(type) {
+	case int32:
+		return int64(n)
+	case int64:
+		return n
+	case float64:
+		return int64(n)
+	}
+	return 0
+}
+
+func toFloat64(v any) float64 {
+	switch n := v.(type) {
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
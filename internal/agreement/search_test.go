@@ -0,0 +1,153 @@
+package agreement_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestAgreementSearch_Filter(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	minPrice := 1000.0
+	tests := []struct {
+		name     string
+		search   *agreement.AgreementSearch
+		expected bson.M
+	}{
+		{
+			name:     "empty",
+			search:   &agreement.AgreementSearch{},
+			expected: bson.M{},
+		},
+		{
+			name: "all filters",
+			search: &agreement.AgreementSearch{
+				SignedFrom: &from, SignedTo: &to, PriceMin: &minPrice,
+				Status: "Исполнение", PurchaseMethod: "аукцион", CustomerId: "492275",
+				OKPD2: "28.24", Subject: "инструмент (ручной)",
+			},
+			expected: bson.M{
+				"signed_at":       bson.M{"$gte": from, "$lte": to},
+				"price":           bson.M{"$gte": minPrice},
+				"status":          "Исполнение",
+				"purchase_method": "аукцион",
+				"customer_id":     "492275",
+				"services.okpd2":  bson.M{"$regex": `^(ОКПД2:\s*)?28\.24`},
+				"subject":         bson.M{"$regex": `инструмент \(ручной\)`, "$options": "i"},
+			},
+		},
+		{
+			name:     "law 223 includes legacy",
+			search:   &agreement.AgreementSearch{Law: agreement.LawFZ223},
+			expected: bson.M{"law": bson.M{"$in": bson.A{"223", nil}}},
+		},
+		{
+			name:     "law 44",
+			search:   &agreement.AgreementSearch{Law: agreement.LawFZ44},
+			expected: bson.M{"law": "44"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.search.Filter())
+		})
+	}
+}
+
+func TestSearchAgreements(t *testing.T) {
+	ctx := context.Background()
+	date := time.Date(2025, 5, 23, 0, 0, 0, 0, time.UTC)
+	items := []*agreement.Agreement{
+		{ID: "3", SignedAt: date},
+		{ID: "2", SignedAt: date},
+		{ID: "1", SignedAt: date.Add(-24 * time.Hour)},
+	}
+	tests := []struct {
+		name           string
+		search         *agreement.AgreementSearch
+		listReturn     []*agreement.Agreement
+		listErr        error
+		expectErr      bool
+		expectedLen    int
+		expectedCursor bool
+	}{
+		{
+			name:           "has next page",
+			search:         &agreement.AgreementSearch{Limit: 2, Desc: true},
+			listReturn:     items,
+			expectedLen:    2,
+			expectedCursor: true,
+		},
+		{
+			name:        "last page",
+			search:      &agreement.AgreementSearch{Limit: 5},
+			listReturn:  items,
+			expectedLen: 3,
+		},
+		{
+			name:      "bad sort",
+			search:    &agreement.AgreementSearch{SortBy: "number"},
+			expectErr: true,
+		},
+		{
+			name:      "bad cursor",
+			search:    &agreement.AgreementSearch{Cursor: "###"},
+			expectErr: true,
+		},
+		{
+			name:      "list error",
+			search:    &agreement.AgreementSearch{},
+			listErr:   errors.New("db error"),
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+			mockRepo.On("CountDocuments", mock.Anything, mock.Anything).Return(int64(len(tt.listReturn)), nil)
+			mockRepo.On("List", mock.Anything, mock.Anything, mock.Anything).Return(tt.listReturn, tt.listErr)
+			page, err := agreement.SearchAgreements(ctx, mockRepo, tt.search)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, page.Items, tt.expectedLen)
+			assert.Equal(t, tt.expectedCursor, page.NextCursor != "")
+		})
+	}
+}
+
+func TestSearchAgreements_NextCursor(t *testing.T) {
+	ctx := context.Background()
+	date := time.Date(2025, 5, 23, 0, 0, 0, 0, time.UTC)
+	mockRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+	mockRepo.On("CountDocuments", mock.Anything, mock.Anything).Return(int64(2), nil)
+	mockRepo.On("List", mock.Anything, mock.Anything, mock.Anything).Return([]*agreement.Agreement{
+		{ID: "2", SignedAt: date}, {ID: "1", SignedAt: date},
+	}, nil).Once()
+	page, err := agreement.SearchAgreements(ctx, mockRepo, &agreement.AgreementSearch{Limit: 1, Desc: true})
+	assert.NoError(t, err)
+
+	mockRepo.On("List", mock.Anything, mock.Anything, mock.Anything).Return([]*agreement.Agreement{
+		{ID: "1", SignedAt: date},
+	}, nil).Once()
+	next, err := agreement.SearchAgreements(ctx, mockRepo, &agreement.AgreementSearch{Limit: 1, Desc: true, Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Empty(t, next.NextCursor)
+
+	// Фильтр второй страницы должен продолжать выборку после последнего элемента
+	filter := mockRepo.Calls[len(mockRepo.Calls)-1].Arguments.Get(1)
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"signed_at": bson.M{"$lt": date}},
+		bson.M{"signed_at": date, "_id": bson.M{"$lt": "2"}},
+	}}, filter)
+}
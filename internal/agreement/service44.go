@@ -0,0 +1,180 @@
+package agreement
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	"github.com/tim8842/tender-data-loader/pkg/parser"
+	"go.uber.org/zap"
+)
+
+// Парсеры реестра контрактов 44-ФЗ. Разметка та же, что у 223-ФЗ,
+// но номер контракта в ссылках идет параметром reestrNumber, а заказчик — organizationCode
+
+// ParseContract44Ids достает реестровые номера контрактов со страницы поиска
+func ParseContract44Ids(ctx context.Context, logger *zap.Logger, data []byte) (any, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	ids := []string{}
+	doc.Find(".registry-entry__header-mid__number a").Each(func(i int, s *goquery.Selection) {
+		href, exists := s.Attr("href")
+		if !exists {
+			return
+		}
+		if id := parser.GetParamFromHref(href, "reestrNumber"); id != "" {
+			ids = append(ids, id)
+		}
+	})
+	return ids, nil
+}
+
+// ParseContract44FromMain разбирает карточку контракта: номер, цену, даты, заказчика
+// и поставщиков. Для страницы без номера контракта возвращает nil
+func ParseContract44FromMain(ctx context.Context, logger *zap.Logger, body []byte) (any, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	data := &AgreementParesedData{Law: LawFZ44, Customer: &customer.Customer{}}
+	doc.Find("span.cardMainInfo__purchaseLink a").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		data.Number = strings.Join(strings.Fields(strings.TrimPrefix(strings.TrimSpace(s.Text()), "№")), "")
+		return false
+	})
+	if data.Number == "" {
+		return nil, nil
+	}
+	data.ID = data.Number
+	data.Status = cleanSupplierText(doc.Find("span.cardMainInfo__state").First().Text())
+	if text, err := parser.GetFromHtmlByTitle(doc, "span", "Цена контракта"); err == nil {
+		data.Price, _ = parser.ParsePriceToFloat(text)
+	}
+	data.SignedAt = cardDate(doc, "Заключение контракта")
+	data.PublishedAt = cardDate(doc, "Размещен контракт в реестре контрактов")
+	data.UpdatedAt = cardDate(doc, "Обновлена информация в реестре контрактов")
+	data.ExecutionStart = cardDate(doc, "Дата начала исполнения контракта")
+	data.ExecutionEnd = cardDate(doc, "Дата окончания исполнения контракта")
+	if data.ExecutionEnd.IsZero() {
+		data.ExecutionEnd = cardDate(doc, "Срок исполнения")
+	}
+	data.PurchaseMethod = cardText(doc, "Способ определения поставщика (подрядчика, исполнителя)")
+	data.Subject = cardText(doc, "Предмет контракта")
+	if el, err := parser.GetElementFromHtmlByTitle(doc, "span", "Номер извещения об осуществлении закупки"); err == nil {
+		if href, ok := el.Find("a").Attr("href"); ok {
+			data.NoticeId = parser.GetParamFromHref(href, "regNumber")
+		}
+	}
+
+	if el, err := parser.GetElementFromHtmlByTitle(doc, "span", "Заказчик"); err == nil {
+		if href, ok := el.Find("a").Attr("href"); ok {
+			data.Customer.ID = parser.GetParamFromHref(href, "organizationCode")
+		}
+	}
+	data.Customer.Name = cardText(doc, "Полное наименование заказчика")
+	data.Customer.Code = cardText(doc, "Идентификационный код заказчика (ИКУ)")
+	data.Customer.INN = cardText(doc, "ИНН")
+	data.Customer.OKOPF = cardText(doc, "Код по ОКОПФ")
+	data.Customer.Location = cardText(doc, "Место нахождения")
+	if data.Customer.ID == "" {
+		data.Customer.ID = data.Customer.INN
+	}
+	data.Suppliers = parseSuppliersFromTable(doc)
+	return data, nil
+}
+
+// ParseContract44Objects дополняет контракт объектами закупки со страницы оплаты и объектов
+func ParseContract44Objects(ctx context.Context, logger *zap.Logger, body []byte, data *AgreementParesedData) (any, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	doc.Find("h2.blockInfo__title").Each(func(i int, title *goquery.Selection) {
+		if cleanSupplierText(title.Text()) != "Объекты закупки" {
+			return
+		}
+		columns, rows := blockTable(title)
+		for _, values := range rows {
+			service := &AgreementService{Currency: "RUB"}
+			for j, v := range values {
+				if j >= len(columns) {
+					break
+				}
+				column := strings.ToLower(columns[j])
+				switch {
+				case strings.HasPrefix(column, "наименование"):
+					service.Name = v
+				case strings.Contains(column, "окпд2"):
+					if v != "" {
+						service.OKPD2 = "ОКПД2:" + v
+					}
+				case strings.HasPrefix(column, "тип объекта"):
+					service.TypeObject = v
+				case strings.HasPrefix(column, "количество"):
+					qty, unit, _ := strings.Cut(v, " ")
+					service.Quantity, _ = parser.ParsePriceToFloat(qty)
+					service.QuantityType = unit
+				case strings.HasPrefix(column, "цена за единицу"):
+					service.UnitPrice, _ = parser.ParsePriceToFloat(v)
+				case strings.HasPrefix(column, "страна происхождения"):
+					service.CountryOfOrigin = v
+				}
+			}
+			if service.Name != "" {
+				data.Services = append(data.Services, service)
+			}
+		}
+	})
+	return data, nil
+}
+
+// parseSuppliersFromTable поставщики из таблицы карточки контракта 44-ФЗ
+func parseSuppliersFromTable(doc *goquery.Document) []*supplier.Supplier {
+	var res []*supplier.Supplier
+	doc.Find("h2.blockInfo__title").Each(func(i int, title *goquery.Selection) {
+		if !strings.HasPrefix(cleanSupplierText(title.Text()), supplierBlockTitle) {
+			return
+		}
+		columns, rows := blockTable(title)
+		for _, values := range rows {
+			res = supplier.Merge(res, supplierFromColumns(columns, values))
+		}
+	})
+	return res
+}
+
+// blockTable заголовки и строки первой таблицы блока с заголовком title
+func blockTable(title *goquery.Selection) ([]string, [][]string) {
+	var columns []string
+	var rows [][]string
+	table := title.Parent().Find("table").First()
+	table.Find("th").Each(func(i int, cell *goquery.Selection) {
+		columns = append(columns, cleanSupplierText(cell.Text()))
+	})
+	table.Find("tbody tr").Each(func(i int, row *goquery.Selection) {
+		var values []string
+		row.Find("td").Each(func(j int, cell *goquery.Selection) {
+			values = append(values, cleanSupplierText(cell.Text()))
+		})
+		if len(values) > 0 {
+			rows = append(rows, values)
+		}
+	})
+	return columns, rows
+}
+
+func cardText(doc *goquery.Document, title string) string {
+	text, _ := parser.GetFromHtmlByTitle(doc, "span", title)
+	return cleanSupplierText(text)
+}
+
+func cardDate(doc *goquery.Document, title string) (t time.Time) {
+	t, _ = parser.ParseFromDateToTime(cardText(doc, title))
+	return t
+}
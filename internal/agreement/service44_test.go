@@ -0,0 +1,105 @@
+package agreement_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	"github.com/tim8842/tender-data-loader/pkg/reader"
+	"go.uber.org/zap"
+)
+
+func TestParseContract44Ids(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	dir := "../../assets/test/ParseContract44Ids"
+	tests := []struct {
+		name     string
+		file     string
+		expected []string
+	}{
+		{name: "bad doc", file: "/error.html", expected: []string{}},
+		{name: "correct doc", file: "/correct.html", expected: []string{"2770501303325000111", "3668000592825000042"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := agreement.ParseContract44Ids(ctx, logger, reader.ReadHtmlFile(dir+tt.file))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestParseContract44FromMain(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	dir := "../../assets/test/ParseContract44FromMain"
+	tests := []struct {
+		name         string
+		file         string
+		expectedData any
+	}{
+		{
+			name: "correct",
+			file: "/correct.html",
+			expectedData: &agreement.AgreementParesedData{
+				ID: "2770501303325000111", Law: agreement.LawFZ44, Number: "2770501303325000111",
+				Status: "Исполнение", NoticeId: "0373100002625000011", Price: 1234567.89,
+				SignedAt: getDate("14.03.2025"), ExecutionStart: getDate("14.03.2025"),
+				ExecutionEnd: getDate("31.12.2025"), PublishedAt: getDate("17.03.2025"),
+				UpdatedAt:      getDate("02.04.2025"),
+				PurchaseMethod: "Электронный аукцион",
+				Subject:        `Поставка инструмента для нужд ГУП "Мосводосток"`,
+				Customer: &customer.Customer{
+					ID: "03731000026", Code: "037705013033770501001", INN: "7705013033",
+					Name:     `ГОСУДАРСТВЕННОЕ УНИТАРНОЕ ПРЕДПРИЯТИЕ ГОРОДА МОСКВЫ "МОСВОДОСТОК"`,
+					OKOPF:    "65242 Государственные унитарные предприятия субъектов Российской Федерации",
+					Location: "Российская Федерация, 115035, Москва, ул Садовническая, д. 71",
+				},
+				Suppliers: []*supplier.Supplier{{
+					ID: "7726123456_772601001", Name: `ОБЩЕСТВО С ОГРАНИЧЕННОЙ ОТВЕТСТВЕННОСТЬЮ "ТЕХСНАБ"`,
+					INN: "7726123456", KPP: "772601001", Address: "117105, г Москва, ш Варшавское, д. 1", IsSME: true,
+				}},
+			},
+		},
+		{
+			name:         "error",
+			file:         "/error.html",
+			expectedData: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := agreement.ParseContract44FromMain(ctx, logger, reader.ReadHtmlFile(dir+tt.file))
+			assert.NoError(t, err)
+			if tt.expectedData == nil {
+				assert.Nil(t, result)
+				return
+			}
+			assert.Equal(t, tt.expectedData, result)
+		})
+	}
+}
+
+func TestParseContract44Objects(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	data := &agreement.AgreementParesedData{Customer: &customer.Customer{}}
+	_, err := agreement.ParseContract44Objects(ctx, logger, reader.ReadHtmlFile("../../assets/test/ParseContract44Objects/correct.html"), data)
+	assert.NoError(t, err)
+	assert.Equal(t, []*agreement.AgreementService{
+		{
+			Name: "Перфоратор электрический", TypeObject: "Товар", Quantity: 10, QuantityType: "Штука",
+			UnitPrice: 23456.78, Currency: "RUB", CountryOfOrigin: "Российская Федерация",
+			OKPD2: "ОКПД2:28.24.11.000 Инструменты ручные электрические",
+		},
+		{
+			Name: "Доставка", TypeObject: "Услуга", Quantity: 1, QuantityType: "Условная единица",
+			UnitPrice: 1000000.09, Currency: "RUB",
+			OKPD2: "ОКПД2:49.41.19.000 Услуги по перевозке грузов прочие",
+		},
+	}, data.Services)
+}
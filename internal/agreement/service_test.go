@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/tim8842/tender-data-loader/internal/agreement"
 	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
 	"github.com/tim8842/tender-data-loader/pkg/parser"
 	"github.com/tim8842/tender-data-loader/pkg/reader"
 	"go.uber.org/zap"
@@ -315,3 +316,38 @@ func TestParseCustomerFromMain(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSuppliers(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := zap.NewDevelopment()
+	dir := "../../assets/test/ParseSuppliers"
+
+	result, err := agreement.ParseAgreementFromMain(ctx, logger, reader.ReadHtmlFile(dir+"/card.html"))
+	assert.NoError(t, err)
+	data, ok := result.(*agreement.AgreementParesedData)
+	assert.True(t, ok)
+	assert.Equal(t, []*supplier.Supplier{{
+		ID:   "5110001111_511001001",
+		Name: `ОБЩЕСТВО С ОГРАНИЧЕННОЙ ОТВЕТСТВЕННОСТЬЮ "СЕВЕРНЫЙ ВЕТЕР"`,
+		INN:  "5110001111", KPP: "511001001", OGRN: "1025100001111",
+	}}, data.Suppliers)
+
+	// Печатная форма дополняет поставщика из карточки и добавляет второго
+	_, err = agreement.ParseAgreementFromHtml(ctx, logger, reader.ReadHtmlFile(dir+"/printForm.html"), data)
+	assert.NoError(t, err)
+	assert.Equal(t, []*supplier.Supplier{
+		{
+			ID:   "5110001111_511001001",
+			Name: `ОБЩЕСТВО С ОГРАНИЧЕННОЙ ОТВЕТСТВЕННОСТЬЮ "СЕВЕРНЫЙ ВЕТЕР"`,
+			INN:  "5110001111", KPP: "511001001", OGRN: "1025100001111",
+			Address: "184606, МУРМАНСКАЯ, СЕВЕРОМОРСК, СЕВЕРНАЯ, дом 1",
+			IsSME:   true,
+		},
+		{
+			ID:   "511000222233",
+			Name: "Индивидуальный предприниматель Петров Петр Петрович",
+			INN:  "511000222233", OGRN: "304510000022223",
+			Address: "184606, МУРМАНСКАЯ, СЕВЕРОМОРСК",
+		},
+	}, data.Suppliers)
+}
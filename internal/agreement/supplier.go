@@ -0,0 +1,114 @@
+package agreement
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+)
+
+const supplierBlockTitle = "Информация о поставщик"
+
+// parseSuppliersFromMain достает поставщиков из блока карточки договора
+func parseSuppliersFromMain(doc *goquery.Document) []*supplier.Supplier {
+	var res []*supplier.Supplier
+	doc.Find("h2.blockInfo__title").Each(func(i int, title *goquery.Selection) {
+		if !strings.HasPrefix(cleanSupplierText(title.Text()), supplierBlockTitle) {
+			return
+		}
+		title.Parent().Find("section.blockInfo__section").Each(func(j int, section *goquery.Selection) {
+			var columns []string
+			section.Find(".header-grey-light .row").First().Children().Each(func(k int, cell *goquery.Selection) {
+				columns = append(columns, cleanSupplierText(cell.Text()))
+			})
+			section.Find(".row").Each(func(k int, row *goquery.Selection) {
+				cells := row.Children().Filter(".table_info")
+				if cells.Length() == 0 {
+					return
+				}
+				var values []string
+				cells.Each(func(n int, cell *goquery.Selection) {
+					values = append(values, cleanSupplierText(cell.Text()))
+				})
+				res = supplier.Merge(res, supplierFromColumns(columns, values))
+			})
+		})
+	})
+	return res
+}
+
+// parseSuppliersFromHtml достает поставщиков из таблицы печатной формы
+func parseSuppliersFromHtml(doc *goquery.Document) []*supplier.Supplier {
+	var res []*supplier.Supplier
+	doc.Find(".headerBlock").Each(func(it int, table *goquery.Selection) {
+		if !strings.HasPrefix(cleanSupplierText(table.Text()), supplierBlockTitle) {
+			return
+		}
+		var columns []string
+		table.Parent().Next().Find("table.item-information").First().Find("tr").Each(func(i int, row *goquery.Selection) {
+			row.Find("th").Each(func(j int, cell *goquery.Selection) {
+				columns = append(columns, cleanSupplierText(cell.Text()))
+			})
+			var values []string
+			row.Find("td").Each(func(j int, cell *goquery.Selection) {
+				values = append(values, cleanSupplierText(cell.Text()))
+			})
+			if len(values) > 0 {
+				res = supplier.Merge(res, supplierFromColumns(columns, values))
+			}
+		})
+	})
+	return res
+}
+
+func supplierFromColumns(columns, values []string) *supplier.Supplier {
+	s := &supplier.Supplier{}
+	for i, v := range values {
+		if i >= len(columns) {
+			break
+		}
+		setSupplierField(s, columns[i], v)
+	}
+	if s.INN == "" && s.Name == "" {
+		return nil
+	}
+	s.ID = supplier.MakeID(s.INN, s.KPP, s.Name)
+	return s
+}
+
+// setSupplierField раскладывает значение колонки по полям, заголовки в карточке
+// и в печатных формах разных лет отличаются, поэтому сравниваем по вхождению
+func setSupplierField(s *supplier.Supplier, column, value string) {
+	column = strings.ToLower(column)
+	if value == "—" || value == "-" {
+		value = ""
+	}
+	switch {
+	case strings.Contains(column, "инн/кпп"):
+		parts := strings.Split(strings.ReplaceAll(value, " ", ""), "/")
+		s.INN = parts[0]
+		if len(parts) > 1 {
+			s.KPP = parts[1]
+		}
+	case strings.Contains(column, "мсп") || strings.Contains(column, "малого и среднего"):
+		s.IsSME = strings.HasPrefix(strings.ToLower(value), "да")
+	case column == "статус":
+		// 44-ФЗ: "Субъект малого предпринимательства" и т.п.
+		s.IsSME = strings.Contains(strings.ToLower(value), "малого")
+	case strings.Contains(column, "наименование") || column == "организация":
+		s.Name = value
+	case strings.Contains(column, "огрн"):
+		s.OGRN = value
+	case strings.Contains(column, "инн"):
+		s.INN = value
+	case strings.Contains(column, "кпп"):
+		s.KPP = value
+	case strings.Contains(column, "адрес") || strings.Contains(column, "место нахождения"):
+		s.Address = value
+	}
+}
+
+// cleanSupplierText схлопывает пробелы, включая неразрывные
+func cleanSupplierText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
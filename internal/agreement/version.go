@@ -0,0 +1,93 @@
+package agreement
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/pkg/diff"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// AgreementVersion прежнее состояние договора, которое заменили при повторной загрузке
+type AgreementVersion struct {
+	ID          string     `bson:"_id,omitempty" json:"id"`          // <agreement_id>_<replaced_at в мс>_<hash>
+	AgreementId string     `bson:"agreement_id" json:"agreement_id"` // номер договора
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updated_at"`     // дата обновления сохраненной версии
+	ReplacedAt  time.Time  `bson:"replaced_at" json:"replaced_at"`   // когда версию заменили
+	Changed     []string   `bson:"changed" json:"changed"`           // поля, изменившиеся в следующей версии
+	Snapshot    *Agreement `bson:"snapshot" json:"snapshot"`         // договор до замены
+	Hash        string     `bson:"hash" json:"hash"`                 // хеш снимка, см. SnapshotHash
+}
+
+func (t AgreementVersion) GetID() any {
+	return t.ID
+}
+
+// VersionID ключ версии. updated_at на zakupki часто с точностью до дня, а правка
+// парсера его не меняет, поэтому ключ строится от времени замены и хеша снимка
+func VersionID(agreementId string, replacedAt time.Time, hash string) string {
+	return fmt.Sprintf("%s_%d_%s", agreementId, replacedAt.UnixMilli(), hash)
+}
+
+// SnapshotHash хеш сохраненного договора. Одинаковый хеш у последней версии и
+// заменяемого договора значит, что эту замену уже записали, например до ретрая
+func SnapshotHash(a *Agreement) string {
+	data, err := bson.Marshal(a)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// BuildVersions сравнивает сохраненные договоры с новыми и возвращает версии
+// для тех, что изменились. Новые договоры без сохраненной копии версий не дают
+func BuildVersions(stored, incoming []*Agreement, at time.Time) []*AgreementVersion {
+	storedById := make(map[string]*Agreement, len(stored))
+	for _, a := range stored {
+		storedById[a.ID] = a
+	}
+	var versions []*AgreementVersion
+	for _, a := range incoming {
+		old, ok := storedById[a.ID]
+		if !ok {
+			continue
+		}
+		changed := diff.Fields(old, a)
+		if len(changed) == 0 {
+			continue
+		}
+		hash := SnapshotHash(old)
+		versions = append(versions, &AgreementVersion{
+			ID:          VersionID(old.ID, at, hash),
+			AgreementId: old.ID,
+			UpdatedAt:   old.UpdatedAt,
+			ReplacedAt:  at,
+			Changed:     changed,
+			Snapshot:    old,
+			Hash:        hash,
+		})
+	}
+	return versions
+}
+
+// SkipRecorded убирает версии, снимок которых совпадает с последней записанной
+// версией того же договора. latest — записанные версии от новых к старым
+func SkipRecorded(versions, latest []*AgreementVersion) []*AgreementVersion {
+	lastHash := make(map[string]string, len(latest))
+	for _, v := range latest {
+		if _, ok := lastHash[v.AgreementId]; !ok {
+			lastHash[v.AgreementId] = v.Hash
+		}
+	}
+	res := versions[:0]
+	for _, v := range versions {
+		if h, ok := lastHash[v.AgreementId]; ok && h == v.Hash {
+			continue
+		}
+		res = append(res, v)
+	}
+	return res
+}
@@ -0,0 +1,62 @@
+package agreement_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+)
+
+func TestBuildVersions(t *testing.T) {
+	date := time.Date(2025, 6, 11, 0, 0, 0, 0, time.UTC)
+	now := date.Add(48 * time.Hour)
+	stored := []*agreement.Agreement{
+		{ID: "1", Status: "Исполнение", Price: 100, UpdatedAt: date},
+		{ID: "2", Status: "Исполнение", Price: 50, UpdatedAt: date},
+	}
+	incoming := []*agreement.Agreement{
+		{ID: "1", Status: "Исполнение завершено", Price: 120, UpdatedAt: date.Add(24 * time.Hour)},
+		// время из mongo приходит в UTC, это не изменение
+		{ID: "2", Status: "Исполнение", Price: 50, UpdatedAt: date.Local()},
+		{ID: "3", Status: "Исполнение"},
+	}
+
+	versions := agreement.BuildVersions(stored, incoming, now)
+
+	assert.Equal(t, []*agreement.AgreementVersion{{
+		ID:          agreement.VersionID("1", now, agreement.SnapshotHash(stored[0])),
+		AgreementId: "1",
+		UpdatedAt:   date,
+		ReplacedAt:  now,
+		Changed:     []string{"status", "price", "updated_at"},
+		Snapshot:    stored[0],
+		Hash:        agreement.SnapshotHash(stored[0]),
+	}}, versions)
+	assert.Regexp(t, `^1_1749772800000_[0-9a-f]{16}$`, versions[0].ID)
+}
+
+// Две замены подряд при том же updated_at (например, после правки парсера)
+// дают две версии, а ретрай той же замены — ни одной новой
+func TestBuildVersions_SameUpdatedAt(t *testing.T) {
+	date := time.Date(2025, 6, 11, 0, 0, 0, 0, time.UTC)
+	first := &agreement.Agreement{ID: "1", Status: "Исполнение", UpdatedAt: date}
+	second := &agreement.Agreement{ID: "1", Status: "Исполнение", Subject: "Поставка", UpdatedAt: date}
+	third := &agreement.Agreement{ID: "1", Status: "Исполнение", Subject: "Поставка бумаги", UpdatedAt: date}
+
+	v1 := agreement.BuildVersions([]*agreement.Agreement{first}, []*agreement.Agreement{second}, date.Add(time.Hour))
+	v2 := agreement.BuildVersions([]*agreement.Agreement{second}, []*agreement.Agreement{third}, date.Add(2*time.Hour))
+	assert.Len(t, v1, 1)
+	assert.Len(t, v2, 1)
+	assert.NotEqual(t, v1[0].ID, v2[0].ID)
+	assert.Equal(t, first, v1[0].Snapshot)
+	assert.Equal(t, second, v2[0].Snapshot)
+
+	// Замену second -> third записали, но сам договор не сохранился, и пачку повторили
+	retry := agreement.BuildVersions([]*agreement.Agreement{second}, []*agreement.Agreement{third}, date.Add(3*time.Hour))
+	latest := []*agreement.AgreementVersion{v2[0], v1[0]}
+	assert.Empty(t, agreement.SkipRecorded(retry, latest))
+	// Первая замена после второй снова пишется: последняя версия у договора другая
+	again := agreement.BuildVersions([]*agreement.Agreement{first}, []*agreement.Agreement{second}, date.Add(4*time.Hour))
+	assert.Len(t, agreement.SkipRecorded(again, latest), 1)
+}
@@ -1,13 +1,35 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/tim8842/tender-data-loader/internal/search"
+	"github.com/tim8842/tender-data-loader/pkg"
+	"github.com/tim8842/tender-data-loader/pkg/archive"
 )
 
+// Профили окружения. В yaml-файле секция profiles.<профиль> перекрывает общие параметры
+const (
+	ProfileDev  = "dev"
+	ProfileTest = "test"
+	ProfileProd = "prod"
+)
+
+var profiles = []string{ProfileDev, ProfileTest, ProfileProd}
+
+// ErrInvalidConfig конфигурация не прошла проверку, в ошибке перечислены все найденные проблемы
+var ErrInvalidConfig = errors.New("некорректная конфигурация")
+
 type Config struct {
+	Profile                                  string // dev, test или prod
 	MongoUser                                string
 	MongoPassword                            string
 	MongoHost                                string
@@ -15,66 +37,382 @@ type Config struct {
 	MongoDB                                  string
 	Port                                     string
 	UrlGetProxy                              string
-	UrlZakupkiAgreementGetNumbersFirst       string
+	UrlZakupkiAgreementSearch                string // шаблон адреса поиска договоров, см. search.Parse
+	UrlZakupkiAgreementGetNumbersFirst       string // устаревшие фрагменты адреса поиска, из них собирается шаблон
 	UrlZakupkiAgreementGetNumbersSecond      string
 	UrlZakupkiAgreementGetNumbersThird       string
 	UrlZakupkiAgreementGetNumbersForth       string
 	UrlZakupkiAgreementGetAgreegmentWeb      string
 	UrlZakupkiAgreementGetAgreegmentShowHtml string
 	UrlZakupkiAgreementGetCustomerWeb        string
+	UrlZakupkiContract44Search               string // шаблон адреса поиска контрактов 44-ФЗ, пусто — загрузка 44-ФЗ выключена
+	UrlZakupkiContract44GetNumbersFirst      string // устаревшие фрагменты адреса поиска 44-ФЗ
+	UrlZakupkiContract44GetNumbersSecond     string
+	UrlZakupkiContract44GetNumbersThird      string
+	UrlZakupkiContract44GetNumbersForth      string
+	UrlZakupkiContract44GetCardWeb           string        // карточка контракта, к адресу дописывается реестровый номер
+	UrlZakupkiContract44GetObjectsWeb        string        // объекты закупки контракта, к адресу дописывается реестровый номер
+	UrlZakupkiNoticeGetCardWeb               string        // общая информация извещения 223-ФЗ, пусто — загрузка извещений выключена
+	UrlZakupkiNoticeGetLotsWeb               string        // список лотов, к адресам извещений дописывается noticeInfoId
+	UrlZakupkiNoticeGetDocumentsWeb          string        // документы извещения
+	NoticeSchedule                           string        // cron-выражение запуска загрузки извещений
+	RecentAgreementDays                      int           // за сколько последних дней перечитываем договоры
+	RecentAgreementInterval                  time.Duration // интервал между проходами
+	BackToNowAgreementSchedule               string        // cron-выражение запуска догрузки
+	BackfillFrom                             time.Time     // с какого дня догружаются договоры по шардам
+	BackfillShardWorkers                     int           // сколько шардов догрузки грузить параллельно, 0 — последовательная догрузка одним курсором
+	BackfillShardLease                       time.Duration // аренда шарда, воркер продлевает ее, пока грузит
+	TaskJitter                               time.Duration // случайная добавка к запуску по расписанию
+	FetchWorkers                             int           // сколько договоров загружаем одновременно
+	RateLimitRPS                             float64       // запросов в секунду на хост, 0 — без ограничения
+	RateLimitBurst                           int           // сколько запросов можно сделать без ожидания
+	ProxyEnabled                             bool          // ходить через пул прокси из URL_GET_PROXY
+	ProxyPoolSize                            int           // сколько прокси держать в пуле
+	ProxyMaxFailures                         int           // после скольких ошибок подряд прокси выкидывается
+	ArchiveMode                              string        // off, record или replay
+	ArchiveBackend                           string        // disk или gridfs
+	ArchiveDir                               string        // каталог архива для disk
+	WebhookTimeout                           time.Duration // таймаут одного запроса к подписчику
+	WebhookMaxRetries                        int           // сколько раз повторять доставку перед dead letter
+	OpenDataSource                           string        // каталог, ftp или http-зеркало с zip-архивами открытых данных, пусто — импорт выключен
+	OpenDataSchedule                         string        // cron-выражение запуска импорта открытых данных
+	ParserRulesFile                          string        // yaml с правилами разбора страниц, пусто — встроенные правила
+	FillRateThreshold                        float64       // доля заполненных критичных полей в пачке, ниже которой задача встает на паузу
+	FillRateMinBatch                         int           // пачки меньше этого размера не останавливают задачу
+	FillRateHistory                          int           // сколько последних пачек каждой задачи держать для отчета
+	HealthCheckTimeout                       time.Duration // таймаут одной проверки готовности
+	HealthMaxBatchAge                        time.Duration // сервис не готов, если догрузка не сохраняла пачки дольше
+	ShutdownTimeout                          time.Duration // сколько ждать задачи при остановке, потом текущая пачка отбрасывается
+	LogDir                                   string        // каталог файла логов
+	LogMaxSize                               int           // размер файла логов в мегабайтах до ротации
+	LogMaxBackups                            int           // сколько старых файлов логов хранить
+	LogMaxAge                                int           // сколько дней хранить старые файлы логов
+	LogCompress                              bool          // сжимать старые файлы логов
+	SearchPerPage                            int           // записей на странице поиска
+	SearchStatuses                           []string      // этапы исполнения договоров в поиске, пусто — все
+	SearchRegions                            []string      // коды регионов заказчика в поиске, пусто — все
+	SearchPriceFrom                          float64       // цена договора от, 0 — без ограничения
+	SearchPriceTo                            float64       // цена договора до, 0 — без ограничения
+}
+
+// setting параметр конфигурации: переменная окружения, путь в yaml-файле и значение по умолчанию.
+// required — когда параметр обязателен, nil — необязательный
+type setting struct {
+	env      string
+	path     string
+	def      string
+	dst      any
+	required func(c *Config) bool
+}
+
+func always(*Config) bool { return true }
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{"MONGO_USER", "mongo.user", "", &c.MongoUser, always},
+		{"MONGO_PASSWORD", "mongo.password", "", &c.MongoPassword, always},
+		{"MONGO_HOST", "mongo.host", "", &c.MongoHost, always},
+		{"MONGO_PORT", "mongo.port", "", &c.MongoPort, always},
+		{"MONGO_DB", "mongo.db", "tenderdb", &c.MongoDB, nil},
+
+		{"PORT", "http.port", "8080", &c.Port, nil},
+		{"HEALTH_CHECK_TIMEOUT", "http.health_check_timeout", "3s", &c.HealthCheckTimeout, nil},
+		{"HEALTH_MAX_BATCH_AGE", "http.health_max_batch_age", "3h", &c.HealthMaxBatchAge, nil},
+
+		{"FETCH_WORKERS", "scraper.fetch_workers", "5", &c.FetchWorkers, nil},
+		{"RATE_LIMIT_RPS", "scraper.rate_limit_rps", "2", &c.RateLimitRPS, nil},
+		{"RATE_LIMIT_BURST", "scraper.rate_limit_burst", "4", &c.RateLimitBurst, nil},
+		{"PARSER_RULES_FILE", "scraper.parser_rules_file", "", &c.ParserRulesFile, nil},
+		{"FILL_RATE_THRESHOLD", "scraper.fill_rate.threshold", "0.5", &c.FillRateThreshold, nil},
+		{"FILL_RATE_MIN_BATCH", "scraper.fill_rate.min_batch", "10", &c.FillRateMinBatch, nil},
+		{"FILL_RATE_HISTORY", "scraper.fill_rate.history", "100", &c.FillRateHistory, nil},
+		{"SEARCH_PER_PAGE", "scraper.search.per_page", "50", &c.SearchPerPage, nil},
+		{"SEARCH_STATUSES", "scraper.search.statuses", "", &c.SearchStatuses, nil},
+		{"SEARCH_REGIONS", "scraper.search.regions", "", &c.SearchRegions, nil},
+		{"SEARCH_PRICE_FROM", "scraper.search.price_from", "0", &c.SearchPriceFrom, nil},
+		{"SEARCH_PRICE_TO", "scraper.search.price_to", "0", &c.SearchPriceTo, nil},
+		// Шаблон поиска обязателен, но может собираться из фрагментов, см. resolveSearch
+		{"URL_ZAKUPKI_AGREEMENT_SEARCH", "scraper.urls.agreement.search", "", &c.UrlZakupkiAgreementSearch, nil},
+		{"URL_ZAKUPKI_AGREEMENT_GET_NUMBERS_FIRST", "scraper.urls.agreement.numbers_first", "", &c.UrlZakupkiAgreementGetNumbersFirst, nil},
+		{"URL_ZAKUPKI_AGREEMENT_GET_NUMBERS_SECOND", "scraper.urls.agreement.numbers_second", "", &c.UrlZakupkiAgreementGetNumbersSecond, nil},
+		{"URL_ZAKUPKI_AGREEMENT_GET_NUMBERS_THIRD", "scraper.urls.agreement.numbers_third", "", &c.UrlZakupkiAgreementGetNumbersThird, nil},
+		{"URL_ZAKUPKI_AGREEMENT_GET_NUMBERS_FORTH", "scraper.urls.agreement.numbers_fourth", "", &c.UrlZakupkiAgreementGetNumbersForth, nil},
+		{"URL_ZAKUPKI_AGREEMENT_GET_AGREEGMENT_WEB", "scraper.urls.agreement.card_web", "", &c.UrlZakupkiAgreementGetAgreegmentWeb, always},
+		{"URL_ZAKUPKI_AGREEMENT_GET_AGREEGMENT_SHOW_HTML", "scraper.urls.agreement.print_form", "", &c.UrlZakupkiAgreementGetAgreegmentShowHtml, always},
+		{"URL_ZAKUPKI_AGREEMENT_GET_CUSTOMER_WEB", "scraper.urls.agreement.customer_web", "", &c.UrlZakupkiAgreementGetCustomerWeb, always},
+		// Адреса 44-ФЗ необязательны, но задаются все вместе
+		{"URL_ZAKUPKI_CONTRACT44_SEARCH", "scraper.urls.contract44.search", "", &c.UrlZakupkiContract44Search, nil},
+		{"URL_ZAKUPKI_CONTRACT44_GET_NUMBERS_FIRST", "scraper.urls.contract44.numbers_first", "", &c.UrlZakupkiContract44GetNumbersFirst, nil},
+		{"URL_ZAKUPKI_CONTRACT44_GET_NUMBERS_SECOND", "scraper.urls.contract44.numbers_second", "", &c.UrlZakupkiContract44GetNumbersSecond, nil},
+		{"URL_ZAKUPKI_CONTRACT44_GET_NUMBERS_THIRD", "scraper.urls.contract44.numbers_third", "", &c.UrlZakupkiContract44GetNumbersThird, nil},
+		{"URL_ZAKUPKI_CONTRACT44_GET_NUMBERS_FORTH", "scraper.urls.contract44.numbers_fourth", "", &c.UrlZakupkiContract44GetNumbersForth, nil},
+		{"URL_ZAKUPKI_CONTRACT44_GET_CARD_WEB", "scraper.urls.contract44.card_web", "", &c.UrlZakupkiContract44GetCardWeb, (*Config).Contract44Enabled},
+		{"URL_ZAKUPKI_CONTRACT44_GET_OBJECTS_WEB", "scraper.urls.contract44.objects_web", "", &c.UrlZakupkiContract44GetObjectsWeb, (*Config).Contract44Enabled},
+		{"URL_ZAKUPKI_NOTICE_GET_CARD_WEB", "scraper.urls.notice.card_web", "", &c.UrlZakupkiNoticeGetCardWeb, nil},
+		{"URL_ZAKUPKI_NOTICE_GET_LOTS_WEB", "scraper.urls.notice.lots_web", "", &c.UrlZakupkiNoticeGetLotsWeb, (*Config).NoticeEnabled},
+		{"URL_ZAKUPKI_NOTICE_GET_DOCUMENTS_WEB", "scraper.urls.notice.documents_web", "", &c.UrlZakupkiNoticeGetDocumentsWeb, (*Config).NoticeEnabled},
+
+		{"ARCHIVE_MODE", "archive.mode", string(archive.ModeOff), &c.ArchiveMode, nil},
+		{"ARCHIVE_BACKEND", "archive.backend", archive.BackendDisk, &c.ArchiveBackend, nil},
+		{"ARCHIVE_DIR", "archive.dir", "./archive", &c.ArchiveDir, nil},
+
+		{"URL_GET_PROXY", "proxy.source_url", "", &c.UrlGetProxy, always},
+		{"PROXY_ENABLED", "proxy.enabled", "false", &c.ProxyEnabled, nil},
+		{"PROXY_POOL_SIZE", "proxy.pool_size", "10", &c.ProxyPoolSize, nil},
+		{"PROXY_MAX_FAILURES", "proxy.max_failures", "3", &c.ProxyMaxFailures, nil},
+
+		{"TASK_JITTER", "tasks.jitter", "30s", &c.TaskJitter, nil},
+		{"SHUTDOWN_TIMEOUT", "tasks.shutdown_timeout", "30s", &c.ShutdownTimeout, nil},
+		{"RECENT_AGREEMENT_DAYS", "tasks.recent_agreement.days", "7", &c.RecentAgreementDays, nil},
+		{"RECENT_AGREEMENT_INTERVAL", "tasks.recent_agreement.interval", "30m", &c.RecentAgreementInterval, nil},
+		{"BACK_TO_NOW_AGREEMENT_SCHEDULE", "tasks.back_to_now_agreement.schedule", "@hourly", &c.BackToNowAgreementSchedule, nil},
+		{"BACKFILL_FROM", "tasks.back_to_now_agreement.from", "2011-02-02", &c.BackfillFrom, nil},
+		{"BACKFILL_SHARD_WORKERS", "tasks.back_to_now_agreement.shard_workers", "0", &c.BackfillShardWorkers, nil},
+		{"BACKFILL_SHARD_LEASE", "tasks.back_to_now_agreement.shard_lease", "2m", &c.BackfillShardLease, nil},
+		{"NOTICE_SCHEDULE", "tasks.notice.schedule", "@hourly", &c.NoticeSchedule, nil},
+		{"OPENDATA_SOURCE", "tasks.opendata.source", "", &c.OpenDataSource, nil},
+		{"OPENDATA_SCHEDULE", "tasks.opendata.schedule", "@daily", &c.OpenDataSchedule, nil},
+
+		{"WEBHOOK_TIMEOUT", "webhook.timeout", "10s", &c.WebhookTimeout, nil},
+		{"WEBHOOK_MAX_RETRIES", "webhook.max_retries", "5", &c.WebhookMaxRetries, nil},
+
+		{"LOG_DIR", "logging.dir", "./logs", &c.LogDir, nil},
+		{"LOG_MAX_SIZE", "logging.max_size_mb", "100", &c.LogMaxSize, nil},
+		{"LOG_MAX_BACKUPS", "logging.max_backups", "7", &c.LogMaxBackups, nil},
+		{"LOG_MAX_AGE", "logging.max_age_days", "30", &c.LogMaxAge, nil},
+		{"LOG_COMPRESS", "logging.compress", "true", &c.LogCompress, nil},
+	}
 }
 
-func LoadConfig(fileToEnv string) (*Config, error) {
-	err := godotenv.Load(fileToEnv)
-	if err != nil {
-		panic("can not initialize logger")
-	}
-
-	cfg := &Config{
-		MongoUser:                                os.Getenv("MONGO_USER"),
-		MongoPassword:                            os.Getenv("MONGO_PASSWORD"),
-		MongoHost:                                os.Getenv("MONGO_HOST"),
-		MongoPort:                                os.Getenv("MONGO_PORT"),
-		MongoDB:                                  getOrDefault("MONGO_DB", "tenderdb"),
-		Port:                                     getOrDefault("PORT", "8080"),
-		UrlGetProxy:                              os.Getenv("URL_GET_PROXY"),
-		UrlZakupkiAgreementGetNumbersFirst:       os.Getenv("URL_ZAKUPKI_AGREEMENT_GET_NUMBERS_FIRST"),
-		UrlZakupkiAgreementGetNumbersSecond:      os.Getenv("URL_ZAKUPKI_AGREEMENT_GET_NUMBERS_SECOND"),
-		UrlZakupkiAgreementGetNumbersThird:       os.Getenv("URL_ZAKUPKI_AGREEMENT_GET_NUMBERS_THIRD"),
-		UrlZakupkiAgreementGetNumbersForth:       os.Getenv("URL_ZAKUPKI_AGREEMENT_GET_NUMBERS_FORTH"),
-		UrlZakupkiAgreementGetAgreegmentWeb:      os.Getenv("URL_ZAKUPKI_AGREEMENT_GET_AGREEGMENT_WEB"),
-		UrlZakupkiAgreementGetAgreegmentShowHtml: os.Getenv("URL_ZAKUPKI_AGREEMENT_GET_AGREEGMENT_SHOW_HTML"),
-		UrlZakupkiAgreementGetCustomerWeb:        os.Getenv("URL_ZAKUPKI_AGREEMENT_GET_CUSTOMER_WEB"),
-	}
-
-	required := map[string]string{
-		"MONGO_USER":     cfg.MongoUser,
-		"MONGO_PASSWORD": cfg.MongoPassword,
-		"MONGO_HOST":     cfg.MongoHost,
-		"MONGO_PORT":     cfg.MongoPort,
-		"URL_GET_PROXY":  cfg.UrlGetProxy,
-		"URL_ZAKUPKI_AGREEMENT_GET_NUMBERS_FIRST":        cfg.UrlZakupkiAgreementGetNumbersFirst,
-		"URL_ZAKUPKI_AGREEMENT_GET_NUMBERS_SECOND":       cfg.UrlZakupkiAgreementGetNumbersSecond,
-		"URL_ZAKUPKI_AGREEMENT_GET_NUMBERS_THIRD":        cfg.UrlZakupkiAgreementGetNumbersThird,
-		"URL_ZAKUPKI_AGREEMENT_GET_NUMBERS_FORTH":        cfg.UrlZakupkiAgreementGetNumbersForth,
-		"URL_ZAKUPKI_AGREEMENT_GET_AGREEGMENT_WEB":       cfg.UrlZakupkiAgreementGetAgreegmentWeb,
-		"URL_ZAKUPKI_AGREEMENT_GET_AGREEGMENT_SHOW_HTML": cfg.UrlZakupkiAgreementGetAgreegmentShowHtml,
-		"URL_ZAKUPKI_AGREEMENT_GET_CUSTOMER_WEB":         cfg.UrlZakupkiAgreementGetCustomerWeb,
-	}
-
-	for key, val := range required {
-		if val == "" {
-			return nil, fmt.Errorf("отсутствует обязательная переменная окружения: %s", key)
+// LoadConfig загружает конфигурацию из yaml-файла (.yaml, .yml) с секциями или из .env.
+// Переменные окружения перекрывают значения из файла. Профиль берется из APP_PROFILE,
+// потом из ключа profile в файле, по умолчанию dev. Ошибки проверки возвращаются все сразу
+func LoadConfig(path string) (*Config, error) {
+	file := &fileValues{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var err error
+		if file, err = readFile(path); err != nil {
+			return nil, err
+		}
+	default:
+		if err := godotenv.Load(path); err != nil {
+			return nil, fmt.Errorf("ошибка чтения файла конфигурации %s: %w", path, err)
 		}
 	}
 
+	cfg := &Config{Profile: ProfileDev}
+	if file.profile != "" {
+		cfg.Profile = file.profile
+	}
+	if v := os.Getenv("APP_PROFILE"); v != "" {
+		cfg.Profile = v
+	}
+	errs := file.errs
+	if !slices.Contains(profiles, cfg.Profile) {
+		errs = append(errs, fmt.Errorf("неизвестный профиль %s, допустимы: %s", cfg.Profile, strings.Join(profiles, ", ")))
+	}
+	values := file.values(cfg.Profile)
+
+	settings := cfg.settings()
+	known := make(map[string]bool, len(settings))
+	for _, s := range settings {
+		known[s.path] = true
+	}
+	errs = append(errs, file.unknown(known)...)
+
+	var missing []setting
+	for _, s := range settings {
+		raw, source := os.Getenv(s.env), "переменной окружения "+s.env
+		if raw == "" {
+			if v, ok := values[s.path]; ok {
+				raw, source = v.value, fmt.Sprintf("параметра %s (строка %d)", s.path, v.line)
+			}
+		}
+		if raw == "" {
+			if s.required != nil {
+				missing = append(missing, s)
+			}
+			raw, source = s.def, "по умолчанию для "+s.env
+		}
+		if err := s.set(raw); err != nil {
+			errs = append(errs, fmt.Errorf("некорректное значение %s: %w", source, err))
+			// Значение по умолчанию, чтобы validate не сообщал о том же параметре второй раз
+			_ = s.set(s.def)
+		}
+	}
+	errs = append(errs, cfg.resolveSearch()...)
+	// Обязательность проверяется после разбора: группы адресов включаются первым адресом
+	for _, s := range missing {
+		if s.required(cfg) {
+			errs = append(errs, fmt.Errorf("отсутствует обязательная переменная окружения: %s (параметр %s)", s.env, s.path))
+		}
+	}
+	errs = append(errs, cfg.validate()...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%w:\n%w", ErrInvalidConfig, errors.Join(errs...))
+	}
 	return cfg, nil
 }
 
-func getOrDefault(key, fallback string) string {
-	v := os.Getenv(key)
-	if v == "" {
-		return fallback
+// validate проверяет допустимые значения параметров
+func (c *Config) validate() []error {
+	var errs []error
+	if c.FillRateThreshold < 0 || c.FillRateThreshold > 1 {
+		errs = append(errs, fmt.Errorf("некорректное значение переменной окружения FILL_RATE_THRESHOLD: %v, ожидается от 0 до 1", c.FillRateThreshold))
+	}
+	if c.FetchWorkers < 1 {
+		errs = append(errs, fmt.Errorf("некорректное значение переменной окружения FETCH_WORKERS: %d, ожидается хотя бы 1", c.FetchWorkers))
+	}
+	if c.BackfillShardWorkers < 0 {
+		errs = append(errs, fmt.Errorf("некорректное значение переменной окружения BACKFILL_SHARD_WORKERS: %d", c.BackfillShardWorkers))
+	}
+	if c.BackfillShardLease < 10*time.Second {
+		errs = append(errs, fmt.Errorf("некорректное значение переменной окружения BACKFILL_SHARD_LEASE: %s, ожидается не меньше 10s", c.BackfillShardLease))
+	}
+	if c.RecentAgreementDays < 1 {
+		errs = append(errs, fmt.Errorf("некорректное значение переменной окружения RECENT_AGREEMENT_DAYS: %d, ожидается хотя бы 1", c.RecentAgreementDays))
+	}
+	if c.RecentAgreementInterval <= 0 {
+		errs = append(errs, fmt.Errorf("некорректное значение переменной окружения RECENT_AGREEMENT_INTERVAL: %s, ожидается больше 0", c.RecentAgreementInterval))
+	}
+	if c.RateLimitRPS < 0 {
+		errs = append(errs, fmt.Errorf("некорректное значение переменной окружения RATE_LIMIT_RPS: %v", c.RateLimitRPS))
+	}
+	if _, err := archive.ParseMode(c.ArchiveMode); err != nil {
+		errs = append(errs, fmt.Errorf("некорректное значение переменной окружения ARCHIVE_MODE: %w", err))
+	}
+	if c.ArchiveBackend != archive.BackendDisk && c.ArchiveBackend != archive.BackendGridFS {
+		errs = append(errs, fmt.Errorf("некорректное значение переменной окружения ARCHIVE_BACKEND: %s", c.ArchiveBackend))
+	}
+	templates := []struct{ key, raw string }{
+		{"URL_ZAKUPKI_AGREEMENT_SEARCH", c.UrlZakupkiAgreementSearch},
+		{"URL_ZAKUPKI_CONTRACT44_SEARCH", c.UrlZakupkiContract44Search},
+	}
+	for _, t := range templates {
+		if _, err := search.Parse(t.raw); err != nil {
+			errs = append(errs, fmt.Errorf("некорректный шаблон %s: %w", t.key, err))
+		}
+	}
+	schedules := []struct{ key, expr string }{
+		{"BACK_TO_NOW_AGREEMENT_SCHEDULE", c.BackToNowAgreementSchedule},
+		{"NOTICE_SCHEDULE", c.NoticeSchedule},
+		{"OPENDATA_SCHEDULE", c.OpenDataSchedule},
+	}
+	for _, s := range schedules {
+		if _, err := pkg.Cron(s.expr); err != nil {
+			errs = append(errs, fmt.Errorf("некорректное значение переменной окружения %s: %w", s.key, err))
+		}
+	}
+	return errs
+}
+
+// resolveSearch собирает шаблоны поиска из устаревших фрагментов, если шаблон не задан
+func (c *Config) resolveSearch() []error {
+	var errs []error
+	if err := fromFragments(&c.UrlZakupkiAgreementSearch, "URL_ZAKUPKI_AGREEMENT",
+		c.UrlZakupkiAgreementGetNumbersFirst, c.UrlZakupkiAgreementGetNumbersSecond,
+		c.UrlZakupkiAgreementGetNumbersThird, c.UrlZakupkiAgreementGetNumbersForth); err != nil {
+		errs = append(errs, err)
+	}
+	if c.UrlZakupkiAgreementSearch == "" {
+		errs = append(errs, errors.New("отсутствует обязательная переменная окружения: URL_ZAKUPKI_AGREEMENT_SEARCH (параметр scraper.urls.agreement.search)"))
+	}
+	if err := fromFragments(&c.UrlZakupkiContract44Search, "URL_ZAKUPKI_CONTRACT44",
+		c.UrlZakupkiContract44GetNumbersFirst, c.UrlZakupkiContract44GetNumbersSecond,
+		c.UrlZakupkiContract44GetNumbersThird, c.UrlZakupkiContract44GetNumbersForth); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func fromFragments(template *string, prefix string, fragments ...string) error {
+	set := 0
+	for _, f := range fragments {
+		if f != "" {
+			set++
+		}
+	}
+	switch {
+	case set == 0:
+		return nil
+	case *template != "":
+		return fmt.Errorf("задан и шаблон %s_SEARCH, и фрагменты %s_GET_NUMBERS_*, оставьте что-то одно", prefix, prefix)
+	case set != len(fragments):
+		return fmt.Errorf("фрагменты адреса %s_GET_NUMBERS_* задаются все вместе", prefix)
+	}
+	*template = search.FromFragments(fragments[0], fragments[1], fragments[2], fragments[3])
+	return nil
+}
+
+// SearchParams фильтры поиска из конфигурации с сортировкой задачи. Период и страницу
+// тоже задает задача
+func (c *Config) SearchParams(sort string) search.Params {
+	return search.Params{
+		DateField: search.DateSigned,
+		Statuses:  c.SearchStatuses,
+		Sort:      sort,
+		PerPage:   c.SearchPerPage,
+		Regions:   c.SearchRegions,
+		PriceFrom: c.SearchPriceFrom,
+		PriceTo:   c.SearchPriceTo,
+	}
+}
+
+// Contract44Enabled включена ли загрузка реестра контрактов 44-ФЗ
+func (c *Config) Contract44Enabled() bool {
+	return c.UrlZakupkiContract44Search != ""
+}
+
+// NoticeEnabled включена ли загрузка извещений о закупках
+func (c *Config) NoticeEnabled() bool {
+	return c.UrlZakupkiNoticeGetCardWeb != ""
+}
+
+// set разбирает строковое значение в поле конфигурации по его типу
+func (s setting) set(raw string) error {
+	switch dst := s.dst.(type) {
+	case *string:
+		*dst = raw
+	case *int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		*dst = n
+	case *[]string:
+		*dst = nil
+		for _, v := range strings.Split(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				*dst = append(*dst, v)
+			}
+		}
+	case *bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		*dst = b
+	case *float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		*dst = f
+	case *time.Time:
+		t, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			return err
+		}
+		*dst = t
+	case *time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		*dst = d
+	default:
+		return fmt.Errorf("unsupported setting type %T", s.dst)
 	}
-	return v
+	return nil
 }
@@ -5,8 +5,10 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/internal/search"
 )
 
 func setEnvVars() map[string]string {
@@ -41,11 +43,7 @@ func clearEnvVars(keys []string) {
 func TestLoadConfig_Successful(t *testing.T) {
 	envVars := setEnvVars()
 	defer clearEnvVars([]string{"MONGO_USER", "MONGO_PASSWORD", "MONGO_HOST", "MONGO_PORT", "URL_GET_PROXY"})
-	envPath, err := filepath.Abs("../../configs/.env.test")
-	if err != nil {
-		panic(err)
-	}
-	cfg, err := LoadConfig(envPath)
+	cfg, err := LoadConfig(".env.test.without.req")
 	assert.Nil(t, err)
 	assert.NotNil(t, cfg)
 
@@ -62,11 +60,7 @@ func TestLoadConfig_DefaultValues(t *testing.T) {
 	_ = setEnvVars()
 	clearEnvVars([]string{"MONGO_DB", "PORT"})
 	defer clearEnvVars([]string{"MONGO_USER", "MONGO_PASSWORD", "MONGO_HOST", "MONGO_PORT", "URL_GET_PROXY"})
-	envPath, err := filepath.Abs("../../configs/.env.test")
-	if err != nil {
-		panic(err)
-	}
-	cfg, err := LoadConfig(envPath)
+	cfg, err := LoadConfig(".env.test.without.req")
 	assert.Nil(t, err)
 	assert.Equal(t, "tenderdb", cfg.MongoDB)
 	assert.Equal(t, "8080", cfg.Port)
@@ -78,3 +72,524 @@ func TestLoadConfig_MissingRequiredVar(t *testing.T) {
 	assert.Error(t, err)
 	assert.True(t, strings.Contains(err.Error(), "отсутствует обязательная переменная"))
 }
+
+// Тестируем числовые параметры и их значения по умолчанию
+func TestLoadConfig_RecentAgreement(t *testing.T) {
+	tests := []struct {
+		name             string
+		days             string
+		interval         string
+		expectErr        bool
+		expectedDays     int
+		expectedInterval time.Duration
+	}{
+		{"defaults", "", "", false, 7, 30 * time.Minute},
+		{"custom", "3", "10m", false, 3, 10 * time.Minute},
+		{"bad days", "three", "", true, 0, 0},
+		{"bad interval", "", "10", true, 0, 0},
+		{"zero days", "0", "", true, 0, 0},
+		{"zero interval", "", "0s", true, 0, 0},
+		{"negative interval", "", "-5m", true, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MONGO_USER", "test_user")
+			t.Setenv("MONGO_PASSWORD", "test_password")
+			t.Setenv("RECENT_AGREEMENT_DAYS", tt.days)
+			t.Setenv("RECENT_AGREEMENT_INTERVAL", tt.interval)
+			cfg, err := LoadConfig(".env.test.without.req")
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedDays, cfg.RecentAgreementDays)
+			assert.Equal(t, tt.expectedInterval, cfg.RecentAgreementInterval)
+		})
+	}
+}
+
+func TestLoadConfig_RateLimit(t *testing.T) {
+	tests := []struct {
+		name            string
+		workers         string
+		rps             string
+		burst           string
+		expectErr       bool
+		expectedWorkers int
+		expectedRPS     float64
+		expectedBurst   int
+	}{
+		{"defaults", "", "", "", false, 5, 2, 4},
+		{"custom", "10", "0.5", "1", false, 10, 0.5, 1},
+		{"bad workers", "many", "", "", true, 0, 0, 0},
+		{"bad rps", "", "fast", "", true, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MONGO_USER", "test_user")
+			t.Setenv("MONGO_PASSWORD", "test_password")
+			t.Setenv("FETCH_WORKERS", tt.workers)
+			t.Setenv("RATE_LIMIT_RPS", tt.rps)
+			t.Setenv("RATE_LIMIT_BURST", tt.burst)
+			cfg, err := LoadConfig(".env.test.without.req")
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedWorkers, cfg.FetchWorkers)
+			assert.Equal(t, tt.expectedRPS, cfg.RateLimitRPS)
+			assert.Equal(t, tt.expectedBurst, cfg.RateLimitBurst)
+		})
+	}
+}
+
+func TestLoadConfig_Proxy(t *testing.T) {
+	t.Setenv("MONGO_USER", "test_user")
+	t.Setenv("MONGO_PASSWORD", "test_password")
+	cfg, err := LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.False(t, cfg.ProxyEnabled)
+	assert.Equal(t, 10, cfg.ProxyPoolSize)
+	assert.Equal(t, 3, cfg.ProxyMaxFailures)
+
+	t.Setenv("PROXY_ENABLED", "true")
+	t.Setenv("PROXY_POOL_SIZE", "4")
+	cfg, err = LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.True(t, cfg.ProxyEnabled)
+	assert.Equal(t, 4, cfg.ProxyPoolSize)
+
+	t.Setenv("PROXY_ENABLED", "yes please")
+	_, err = LoadConfig(".env.test.without.req")
+	assert.Error(t, err)
+}
+
+func TestLoadConfig_Webhook(t *testing.T) {
+	t.Setenv("MONGO_USER", "test_user")
+	t.Setenv("MONGO_PASSWORD", "test_password")
+	cfg, err := LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, cfg.WebhookTimeout)
+	assert.Equal(t, 5, cfg.WebhookMaxRetries)
+
+	t.Setenv("WEBHOOK_TIMEOUT", "3s")
+	t.Setenv("WEBHOOK_MAX_RETRIES", "1")
+	cfg, err = LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Second, cfg.WebhookTimeout)
+	assert.Equal(t, 1, cfg.WebhookMaxRetries)
+
+	t.Setenv("WEBHOOK_MAX_RETRIES", "many")
+	_, err = LoadConfig(".env.test.without.req")
+	assert.Error(t, err)
+}
+
+func TestLoadConfig_Contract44(t *testing.T) {
+	t.Setenv("MONGO_USER", "test_user")
+	t.Setenv("MONGO_PASSWORD", "test_password")
+	cfg, err := LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.False(t, cfg.Contract44Enabled())
+
+	t.Setenv("URL_ZAKUPKI_CONTRACT44_GET_NUMBERS_FIRST", "https://example.com/epz/contract/search/results.html?fz44=on&contractDateFrom=")
+	_, err = LoadConfig(".env.test.without.req")
+	assert.Error(t, err)
+
+	t.Setenv("URL_ZAKUPKI_CONTRACT44_GET_NUMBERS_SECOND", "&contractDateTo=")
+	t.Setenv("URL_ZAKUPKI_CONTRACT44_GET_NUMBERS_THIRD", "&pageNumber=")
+	t.Setenv("URL_ZAKUPKI_CONTRACT44_GET_NUMBERS_FORTH", "&recordsPerPage=_50")
+	t.Setenv("URL_ZAKUPKI_CONTRACT44_GET_CARD_WEB", "https://example.com/epz/contract/contractCard/common-info.html?reestrNumber=")
+	t.Setenv("URL_ZAKUPKI_CONTRACT44_GET_OBJECTS_WEB", "https://example.com/epz/contract/contractCard/payment-info-and-target-of-order.html?reestrNumber=")
+	cfg, err = LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.True(t, cfg.Contract44Enabled())
+	assert.Equal(t, "&pageNumber=", cfg.UrlZakupkiContract44GetNumbersThird)
+}
+
+func TestLoadConfig_Notice(t *testing.T) {
+	t.Setenv("MONGO_USER", "test_user")
+	t.Setenv("MONGO_PASSWORD", "test_password")
+	cfg, err := LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.False(t, cfg.NoticeEnabled())
+	assert.Equal(t, "@hourly", cfg.NoticeSchedule)
+
+	t.Setenv("URL_ZAKUPKI_NOTICE_GET_CARD_WEB", "https://example.com/epz/order/notice/notice223/common-info.html?noticeInfoId=")
+	_, err = LoadConfig(".env.test.without.req")
+	assert.Error(t, err)
+
+	t.Setenv("URL_ZAKUPKI_NOTICE_GET_LOTS_WEB", "https://example.com/epz/order/notice/notice223/lot-list.html?noticeInfoId=")
+	t.Setenv("URL_ZAKUPKI_NOTICE_GET_DOCUMENTS_WEB", "https://example.com/epz/order/notice/notice223/documents.html?noticeInfoId=")
+	cfg, err = LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.True(t, cfg.NoticeEnabled())
+}
+
+func TestLoadConfig_OpenData(t *testing.T) {
+	t.Setenv("MONGO_USER", "test_user")
+	t.Setenv("MONGO_PASSWORD", "test_password")
+	cfg, err := LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.Equal(t, "", cfg.OpenDataSource)
+	assert.Equal(t, "@daily", cfg.OpenDataSchedule)
+
+	t.Setenv("OPENDATA_SOURCE", "https://mirror.example.com/223/contract/")
+	t.Setenv("OPENDATA_SCHEDULE", "0 3 * * *")
+	cfg, err = LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://mirror.example.com/223/contract/", cfg.OpenDataSource)
+	assert.Equal(t, "0 3 * * *", cfg.OpenDataSchedule)
+}
+
+func TestLoadConfig_Archive(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		backend   string
+		expectErr bool
+	}{
+		{"defaults", "", "", false},
+		{"record gridfs", "record", "gridfs", false},
+		{"bad mode", "write", "", true},
+		{"bad backend", "replay", "s3", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MONGO_USER", "test_user")
+			t.Setenv("MONGO_PASSWORD", "test_password")
+			t.Setenv("ARCHIVE_MODE", tt.mode)
+			t.Setenv("ARCHIVE_BACKEND", tt.backend)
+			cfg, err := LoadConfig(".env.test.without.req")
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.mode == "" {
+				assert.Equal(t, "off", cfg.ArchiveMode)
+				assert.Equal(t, "disk", cfg.ArchiveBackend)
+				assert.Equal(t, "./archive", cfg.ArchiveDir)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ParserRulesFile(t *testing.T) {
+	t.Setenv("MONGO_USER", "test_user")
+	t.Setenv("MONGO_PASSWORD", "test_password")
+	cfg, err := LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.Equal(t, "", cfg.ParserRulesFile)
+
+	t.Setenv("PARSER_RULES_FILE", "/etc/tender/rules.yaml")
+	cfg, err = LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.Equal(t, "/etc/tender/rules.yaml", cfg.ParserRulesFile)
+}
+
+func TestLoadConfig_FillRate(t *testing.T) {
+	t.Setenv("MONGO_USER", "test_user")
+	t.Setenv("MONGO_PASSWORD", "test_password")
+	cfg, err := LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, cfg.FillRateThreshold)
+	assert.Equal(t, 10, cfg.FillRateMinBatch)
+	assert.Equal(t, 100, cfg.FillRateHistory)
+
+	t.Setenv("FILL_RATE_THRESHOLD", "0.8")
+	cfg, err = LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.8, cfg.FillRateThreshold)
+
+	t.Setenv("FILL_RATE_THRESHOLD", "80")
+	_, err = LoadConfig(".env.test.without.req")
+	assert.Error(t, err)
+}
+
+func TestLoadConfig_Health(t *testing.T) {
+	t.Setenv("MONGO_USER", "test_user")
+	t.Setenv("MONGO_PASSWORD", "test_password")
+	cfg, err := LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Second, cfg.HealthCheckTimeout)
+	assert.Equal(t, 3*time.Hour, cfg.HealthMaxBatchAge)
+
+	t.Setenv("HEALTH_MAX_BATCH_AGE", "soon")
+	_, err = LoadConfig(".env.test.without.req")
+	assert.Error(t, err)
+}
+
+func TestLoadConfig_ShutdownTimeout(t *testing.T) {
+	t.Setenv("MONGO_USER", "test_user")
+	t.Setenv("MONGO_PASSWORD", "test_password")
+	cfg, err := LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, cfg.ShutdownTimeout)
+
+	t.Setenv("SHUTDOWN_TIMEOUT", "2m")
+	cfg, err = LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, cfg.ShutdownTimeout)
+}
+
+func TestLoadConfig_MissingFile(t *testing.T) {
+	_, err := LoadConfig("no-such-file.env")
+	assert.ErrorContains(t, err, "ошибка чтения файла конфигурации")
+	_, err = LoadConfig("no-such-file.yaml")
+	assert.ErrorContains(t, err, "ошибка чтения файла конфигурации")
+}
+
+const yamlConfig = `
+profile: test
+mongo:
+  user: tender
+  password: secret
+  host: localhost
+  port: 27017
+http:
+  port: 9090
+scraper:
+  fetch_workers: 3
+  urls:
+    agreement:
+      numbers_first: "https://example.com/search?from="
+      numbers_second: "&to="
+      numbers_third: "&page="
+      numbers_fourth: "&size=50"
+      card_web: "https://example.com/card?id="
+      print_form: "https://example.com/print?id="
+      customer_web: "https://example.com/customer?id="
+proxy:
+  source_url: "https://proxy.example.com/list"
+tasks:
+  shutdown_timeout: 1m
+  back_to_now_agreement:
+    schedule: "0 * * * *"
+logging:
+  compress: false
+profiles:
+  prod:
+    mongo:
+      host: mongo.internal
+    proxy:
+      enabled: true
+  test:
+    mongo:
+      db: tenderdb_test
+`
+
+// writeConfig пишет yaml во временный файл. Переменные окружения параметров очищаются,
+// чтобы значения брались из файла
+func writeConfig(t *testing.T, content string) string {
+	t.Setenv("APP_PROFILE", "")
+	for _, s := range (&Config{}).settings() {
+		t.Setenv(s.env, "")
+	}
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoadConfig_YAML(t *testing.T) {
+	path := writeConfig(t, yamlConfig)
+	tests := []struct {
+		name    string
+		env     map[string]string
+		profile string
+		host    string
+		db      string
+		proxy   bool
+		workers int
+	}{
+		{"profile from file", nil, ProfileTest, "localhost", "tenderdb_test", false, 3},
+		{"profile from env", map[string]string{"APP_PROFILE": "prod"}, ProfileProd, "mongo.internal", "tenderdb", true, 3},
+		{"env overrides file", map[string]string{"MONGO_HOST": "db", "FETCH_WORKERS": "8"}, ProfileTest, "db", "tenderdb_test", false, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := LoadConfig(path)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.profile, cfg.Profile)
+			assert.Equal(t, tt.host, cfg.MongoHost)
+			assert.Equal(t, tt.db, cfg.MongoDB)
+			assert.Equal(t, tt.proxy, cfg.ProxyEnabled)
+			assert.Equal(t, tt.workers, cfg.FetchWorkers)
+			assert.Equal(t, "9090", cfg.Port)
+			assert.Equal(t, "&size=50", cfg.UrlZakupkiAgreementGetNumbersForth)
+			assert.Equal(t, time.Minute, cfg.ShutdownTimeout)
+			assert.Equal(t, "0 * * * *", cfg.BackToNowAgreementSchedule)
+			assert.False(t, cfg.LogCompress)
+			assert.Equal(t, "./logs", cfg.LogDir)
+		})
+	}
+}
+
+// Пример из configs должен загружаться во всех профилях
+func TestLoadConfig_Example(t *testing.T) {
+	writeConfig(t, "")
+	for _, profile := range profiles {
+		t.Setenv("APP_PROFILE", profile)
+		cfg, err := LoadConfig("../../configs/config.example.yaml")
+		if assert.NoError(t, err, profile) {
+			assert.Equal(t, profile, cfg.Profile)
+		}
+	}
+}
+
+func TestLoadConfig_YAMLAllErrors(t *testing.T) {
+	path := writeConfig(t, `
+profile: staging
+mongo:
+  user: tender
+  hots: localhost
+scraper:
+  fetch_workers: many
+  fill_rate:
+    threshold: 2
+  urls:
+    contract44:
+      search: "https://example.com/search?from={{from}}&page={{page}}"
+tasks:
+  notice:
+    schedule: "every hour"
+profiles:
+  qa:
+    mongo:
+      host: qa
+`)
+	_, err := LoadConfig(path)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	for _, expected := range []string{
+		"неизвестный профиль qa (строка 17)",
+		"неизвестный профиль staging",
+		"неизвестный параметр mongo.hots (строка 5)",
+		"некорректное значение параметра scraper.fetch_workers (строка 7)",
+		"отсутствует обязательная переменная окружения: MONGO_PASSWORD (параметр mongo.password)",
+		"отсутствует обязательная переменная окружения: URL_ZAKUPKI_AGREEMENT_SEARCH",
+		"отсутствует обязательная переменная окружения: URL_ZAKUPKI_CONTRACT44_GET_CARD_WEB",
+		"FILL_RATE_THRESHOLD: 2",
+		"NOTICE_SCHEDULE",
+	} {
+		assert.ErrorContains(t, err, expected)
+	}
+}
+
+func TestLoadConfig_YAMLInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"bad yaml", "mongo: [", "ошибка разбора файла конфигурации"},
+		{"not a mapping", "- mongo", "ожидаются секции параметров"},
+		{"list value", "mongo:\n  user: [a, b]\n", "параметр mongo.user (строка 2): ожидается одно значение"},
+		{"scalar section", "profiles: prod\n", "ожидаются секции профилей"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, tt.content))
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestLoadConfig_Search(t *testing.T) {
+	base := `
+mongo: {user: u, password: p, host: h, port: "1"}
+proxy: {source_url: "https://proxy.example.com"}
+scraper:
+  search:
+    statuses: "0, 1"
+    price_from: 1000
+  urls:
+    agreement:
+      card_web: w
+      print_form: s
+      customer_web: c
+`
+	tests := []struct {
+		name     string
+		urls     string
+		expected string
+		err      string
+	}{
+		{
+			name:     "template",
+			urls:     `      search: "https://example.com/search?stage={{statuses}}&from={{from}}&page={{page}}"`,
+			expected: "https://example.com/search?stage={{statuses}}&from={{from}}&page={{page}}",
+		},
+		{
+			name: "fragments",
+			urls: `      numbers_first: "https://example.com/search?from="
+      numbers_second: "&to="
+      numbers_third: "&page="
+      numbers_fourth: "&size=_50"`,
+			expected: "https://example.com/search?from={{from}}&to={{to}}&page={{page}}&size=_50",
+		},
+		{
+			name: "template and fragments",
+			urls: `      search: "https://example.com/search?page={{page}}"
+      numbers_first: "https://example.com/search?from="`,
+			err: "оставьте что-то одно",
+		},
+		{
+			name: "partial fragments",
+			urls: `      numbers_first: "https://example.com/search?from="`,
+			err:  "фрагменты адреса URL_ZAKUPKI_AGREEMENT_GET_NUMBERS_* задаются все вместе",
+		},
+		{
+			name: "unknown placeholder",
+			urls: `      search: "https://example.com/search?page={{pageNumber}}"`,
+			err:  "некорректный шаблон URL_ZAKUPKI_AGREEMENT_SEARCH: unknown placeholder {{pageNumber}}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadConfig(writeConfig(t, base+tt.urls+"\n"))
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.expected, cfg.UrlZakupkiAgreementSearch)
+			params := cfg.SearchParams(search.SortSignedDate)
+			assert.Equal(t, search.SortSignedDate, params.Sort)
+			assert.Equal(t, []string{"0", "1"}, params.Statuses)
+			assert.Equal(t, 1000.0, params.PriceFrom)
+			assert.Equal(t, 50, params.PerPage)
+			assert.False(t, cfg.Contract44Enabled())
+		})
+	}
+}
+
+func TestLoadConfig_BackfillShards(t *testing.T) {
+	t.Setenv("MONGO_USER", "test_user")
+	t.Setenv("MONGO_PASSWORD", "test_password")
+	cfg, err := LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cfg.BackfillShardWorkers)
+	assert.Equal(t, 2*time.Minute, cfg.BackfillShardLease)
+	assert.Equal(t, time.Date(2011, 2, 2, 0, 0, 0, 0, time.UTC), cfg.BackfillFrom)
+
+	t.Setenv("BACKFILL_SHARD_WORKERS", "4")
+	t.Setenv("BACKFILL_FROM", "2020-01-01")
+	cfg, err = LoadConfig(".env.test.without.req")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, cfg.BackfillShardWorkers)
+	assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), cfg.BackfillFrom)
+
+	t.Setenv("BACKFILL_FROM", "01.01.2020")
+	t.Setenv("BACKFILL_SHARD_LEASE", "1s")
+	_, err = LoadConfig(".env.test.without.req")
+	assert.ErrorContains(t, err, "BACKFILL_FROM")
+	assert.ErrorContains(t, err, "BACKFILL_SHARD_LEASE")
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileValue скалярное значение из yaml-файла и строка, где оно задано
+type fileValue struct {
+	value string
+	line  int
+}
+
+// fileValues параметры yaml-файла, разложенные по путям вида mongo.host.
+// Секции profiles.<профиль> лежат отдельно и накладываются поверх общих
+type fileValues struct {
+	profile  string
+	common   map[string]fileValue
+	profiles map[string]map[string]fileValue
+	errs     []error
+}
+
+func readFile(path string) (*fileValues, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла конфигурации %s: %w", path, err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("ошибка разбора файла конфигурации %s: %w", path, err)
+	}
+	file := &fileValues{common: map[string]fileValue{}, profiles: map[string]map[string]fileValue{}}
+	if len(doc.Content) == 0 {
+		return file, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("ошибка разбора файла конфигурации %s: ожидаются секции параметров", path)
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		switch key.Value {
+		case "profile":
+			file.profile = scalar(value, "profile", &file.errs)
+		case "profiles":
+			if value.Kind != yaml.MappingNode {
+				file.errs = append(file.errs, fmt.Errorf("параметр profiles (строка %d): ожидаются секции профилей", value.Line))
+				continue
+			}
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				name := value.Content[j].Value
+				if !slices.Contains(profiles, name) {
+					file.errs = append(file.errs, fmt.Errorf("неизвестный профиль %s (строка %d)", name, value.Content[j].Line))
+					continue
+				}
+				file.profiles[name] = map[string]fileValue{}
+				flatten("", value.Content[j+1], file.profiles[name], &file.errs)
+			}
+		default:
+			flatten("", &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{key, value}}, file.common, &file.errs)
+		}
+	}
+	return file, nil
+}
+
+// flatten раскладывает вложенные секции в пары путь — значение
+func flatten(prefix string, node *yaml.Node, out map[string]fileValue, errs *[]error) {
+	if node.Kind != yaml.MappingNode {
+		*errs = append(*errs, fmt.Errorf("параметр %s (строка %d): ожидается секция", prefix, node.Line))
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		path := node.Content[i].Value
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		value := node.Content[i+1]
+		if value.Kind == yaml.MappingNode {
+			flatten(path, value, out, errs)
+			continue
+		}
+		out[path] = fileValue{value: scalar(value, path, errs), line: value.Line}
+	}
+}
+
+func scalar(node *yaml.Node, path string, errs *[]error) string {
+	if node.Kind != yaml.ScalarNode {
+		*errs = append(*errs, fmt.Errorf("параметр %s (строка %d): ожидается одно значение", path, node.Line))
+		return ""
+	}
+	if node.Tag == "!!null" {
+		return ""
+	}
+	return node.Value
+}
+
+// values общие параметры с наложенным профилем
+func (f *fileValues) values(profile string) map[string]fileValue {
+	out := make(map[string]fileValue, len(f.common))
+	for path, v := range f.common {
+		out[path] = v
+	}
+	for path, v := range f.profiles[profile] {
+		out[path] = v
+	}
+	return out
+}
+
+// unknown ошибки для путей, которых нет среди параметров: опечатки не должны тихо игнорироваться
+func (f *fileValues) unknown(known map[string]bool) []error {
+	var errs []error
+	check := func(prefix string, values map[string]fileValue) {
+		paths := make([]string, 0, len(values))
+		for path := range values {
+			if !known[path] {
+				paths = append(paths, path)
+			}
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			errs = append(errs, fmt.Errorf("неизвестный параметр %s%s (строка %d)", prefix, path, values[path].line))
+		}
+	}
+	check("", f.common)
+	for _, name := range profiles {
+		check("profiles."+name+".", f.profiles[name])
+	}
+	return errs
+}
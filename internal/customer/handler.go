@@ -0,0 +1,91 @@
+package customer
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// CustomerHandler обрабатывает запросы к заказчикам
+type CustomerHandler struct {
+	logger   *zap.Logger
+	custRepo ICustomerRepo
+}
+
+// NewCustomerHandler создает новый handler
+func NewCustomerHandler(
+	logger *zap.Logger,
+	custRepo ICustomerRepo,
+) *CustomerHandler {
+	return &CustomerHandler{
+		logger:   logger,
+		custRepo: custRepo,
+	}
+}
+
+// GetCustomerByID godoc
+// @Summary Получить заказчика по ID
+// @Description Возвращает заказчика по его ID (agencyId)
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param id path string true "ID заказчика"
+// @Success 200 {object} model.Customer
+// @Failure 404 {object} map[string]string
+// @Router /customers/{id} [get]
+func (h *CustomerHandler) GetCustomerByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	h.logger.Info("Получение заказчика", zap.String("id", id))
+	customer, err := h.custRepo.GetByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) || strings.Contains(err.Error(), "not found") {
+			h.logger.Warn("Заказчик не найден", zap.String("id", id), zap.Error(err))
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "customer not found",
+			})
+		}
+
+		h.logger.Error("Ошибка при получении заказчика", zap.String("id", id), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "internal server error",
+		})
+	}
+
+	return c.JSON(customer)
+}
+
+// SearchCustomers godoc
+// @Summary Поиск заказчиков
+// @Description Возвращает заказчиков по ИНН, наименованию и ОКОПФ
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param inn query string false "ИНН"
+// @Param name query string false "Подстрока наименования"
+// @Param okopf query string false "Префикс ОКОПФ"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param limit query int false "Размер страницы (по умолчанию 50, максимум 500)"
+// @Success 200 {object} model.CustomerPage
+// @Failure 500 {object} map[string]string
+// @Router /customers [get]
+func (h *CustomerHandler) SearchCustomers(c *fiber.Ctx) error {
+	search := &CustomerSearch{
+		INN:    c.Query("inn"),
+		Name:   c.Query("name"),
+		OKOPF:  c.Query("okopf"),
+		Cursor: c.Query("cursor"),
+		Limit:  c.QueryInt("limit", DefaultSearchLimit),
+	}
+	page, err := SearchCustomers(c.Context(), h.custRepo, search)
+	if err != nil {
+		h.logger.Error("Ошибка при поиске заказчиков", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "internal server error",
+		})
+	}
+	return c.JSON(page)
+}
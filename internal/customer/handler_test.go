@@ -0,0 +1,124 @@
+package customer_test
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+func TestCustomerHandler_GetCustomerByID(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		mockReturn     *customer.Customer
+		mockError      error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "successful get",
+			id:             "492275",
+			mockReturn:     &customer.Customer{ID: "492275", INN: "7705013033"},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `{"id":"492275","code":"","name":"","inn":"7705013033","okopf":"","main_work":"","location":""}`,
+		},
+		{
+			name:           "not found",
+			id:             "1",
+			mockError:      mongo.ErrNoDocuments,
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody:   `{"error":"customer not found"}`,
+		},
+		{
+			name:           "internal error",
+			id:             "2",
+			mockError:      errors.New("internal error"),
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			mockRepo := new(inmock.MockGenericRepository[*customer.Customer])
+			mockRepo.On("GetByID", mock.Anything, tt.id).Return(tt.mockReturn, tt.mockError)
+			h := customer.NewCustomerHandler(zap.NewNop(), mockRepo)
+			app.Get("/customers/:id", h.GetCustomerByID)
+			resp, err := app.Test(httptest.NewRequest("GET", "/customers/"+tt.id, nil))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			assert.JSONEq(t, tt.expectedBody, string(body))
+		})
+	}
+}
+
+func TestCustomerHandler_SearchCustomers(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockReturn     []*customer.Customer
+		mockError      error
+		expectedFilter bson.M
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "search by inn and name",
+			query: "?inn=7705013033&name=мосводосток&limit=1",
+			mockReturn: []*customer.Customer{
+				{ID: "1"}, {ID: "2"},
+			},
+			expectedFilter: bson.M{
+				"inn":  "7705013033",
+				"name": bson.M{"$regex": "мосводосток", "$options": "i"},
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `{"items":[{"id":"1","code":"","name":"","inn":"","okopf":"","main_work":"","location":""}],"next_cursor":"1"}`,
+		},
+		{
+			name:           "search by okopf with cursor",
+			query:          "?okopf=65242&cursor=10",
+			expectedFilter: bson.M{"okopf": bson.M{"$regex": "^65242", "$options": "i"}, "_id": bson.M{"$gt": "10"}},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `{"items":[]}`,
+		},
+		{
+			name:           "internal error",
+			query:          "",
+			mockError:      errors.New("db error"),
+			expectedFilter: bson.M{},
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			mockRepo := new(inmock.MockGenericRepository[*customer.Customer])
+			mockRepo.On("List", mock.Anything, tt.expectedFilter, mock.Anything).Return(tt.mockReturn, tt.mockError)
+			h := customer.NewCustomerHandler(zap.NewNop(), mockRepo)
+			app.Get("/customers", h.SearchCustomers)
+			resp, err := app.Test(httptest.NewRequest("GET", "/customers"+tt.query, nil))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			assert.JSONEq(t, tt.expectedBody, string(body))
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
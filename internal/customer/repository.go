@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/tim8842/tender-data-loader/pkg/repository"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type CustomerRepo struct {
@@ -14,6 +15,16 @@ func (r *CustomerRepo) BulkMergeMany(ctx context.Context, docs []*Customer) erro
 	return r.BulkCreateOrUpdateMany(ctx, docs)
 }
 
+func (r *CustomerRepo) GetByID(ctx context.Context, id string) (*Customer, error) {
+	return r.GenericRepository.GetByID(ctx, id)
+}
+
+func (r *CustomerRepo) List(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*Customer, error) {
+	return r.GenericRepository.List(ctx, filter, opts...)
+}
+
 type ICustomerRepo interface {
 	BulkMergeMany(ctx context.Context, docs []*Customer) error
+	GetByID(ctx context.Context, id string) (*Customer, error)
+	List(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*Customer, error)
 }
@@ -0,0 +1,74 @@
+package customer
+
+import (
+	"context"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 500
+)
+
+// CustomerSearch параметры поиска заказчиков
+type CustomerSearch struct {
+	INN   string
+	Name  string // подстрока наименования
+	OKOPF string // префикс ОКОПФ (код или наименование)
+
+	Cursor string // _id последнего заказчика предыдущей страницы
+	Limit  int
+}
+
+// CustomerPage страница результатов поиска
+type CustomerPage struct {
+	Items      []*Customer `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// Filter строит фильтр mongo
+func (s *CustomerSearch) Filter() bson.M {
+	filter := bson.M{}
+	if s.INN != "" {
+		filter["inn"] = s.INN
+	}
+	if s.Name != "" {
+		filter["name"] = bson.M{"$regex": regexp.QuoteMeta(s.Name), "$options": "i"}
+	}
+	if s.OKOPF != "" {
+		filter["okopf"] = bson.M{"$regex": "^" + regexp.QuoteMeta(s.OKOPF), "$options": "i"}
+	}
+	if s.Cursor != "" {
+		filter["_id"] = bson.M{"$gt": s.Cursor}
+	}
+	return filter
+}
+
+// SearchCustomers ищет заказчиков, сортировка по _id
+func SearchCustomers(ctx context.Context, repo ICustomerRepo, s *CustomerSearch) (*CustomerPage, error) {
+	if s.Limit <= 0 {
+		s.Limit = DefaultSearchLimit
+	}
+	if s.Limit > MaxSearchLimit {
+		s.Limit = MaxSearchLimit
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(s.Limit + 1))
+	items, err := repo.List(ctx, s.Filter(), opts)
+	if err != nil {
+		return nil, err
+	}
+	page := &CustomerPage{Items: items}
+	if len(items) > s.Limit {
+		page.Items = items[:s.Limit]
+		page.NextCursor = page.Items[s.Limit-1].ID
+	}
+	if page.Items == nil {
+		page.Items = []*Customer{}
+	}
+	return page, nil
+}
@@ -0,0 +1,152 @@
+package export
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultBatchSize = 500
+	// maxCachedCustomers после этого кеш заказчиков сбрасывается, чтобы память не росла с выгрузкой
+	maxCachedCustomers = 10000
+)
+
+// Columns колонки выгрузки: договор, заказчик и услуга. Договор без услуг дает одну строку
+var Columns = []string{
+	"id", "number", "law", "status", "notice_id", "price",
+	"signed_at", "execution_start", "execution_end", "published_at", "updated_at",
+	"purchase_method", "subject", "supplier_ids",
+	"customer_id", "customer_code", "customer_name", "customer_inn", "customer_okopf",
+	"customer_main_work", "customer_location",
+	"service_no", "service_name", "service_type_object", "service_quantity", "service_quantity_type",
+	"service_unit_price", "service_currency", "service_okpd", "service_okpd2",
+	"service_country_of_origin", "service_country_registered",
+}
+
+// IAgreementStreamer читает договоры курсором, не загружая выборку целиком
+type IAgreementStreamer interface {
+	Stream(ctx context.Context, filter interface{}, fn func(*agreement.Agreement) error, opts ...*options.FindOptions) error
+}
+
+// Exporter выгружает договоры с услугами и данными заказчика
+type Exporter struct {
+	agreeRepo IAgreementStreamer
+	custRepo  customer.ICustomerRepo
+	batchSize int
+}
+
+func NewExporter(agreeRepo IAgreementStreamer, custRepo customer.ICustomerRepo, batchSize int) *Exporter {
+	if batchSize < 1 {
+		batchSize = DefaultBatchSize
+	}
+	return &Exporter{agreeRepo: agreeRepo, custRepo: custRepo, batchSize: batchSize}
+}
+
+// Export пишет в w договоры по фильтру в порядке signed_at, _id и возвращает число строк.
+// Заказчики подтягиваются пачками по batchSize договоров
+func (e *Exporter) Export(ctx context.Context, w io.Writer, format Format, filter bson.M) (int, error) {
+	rw, err := newRowWriter(format, w)
+	if err != nil {
+		return 0, err
+	}
+	rows := 0
+	customers := map[string]*customer.Customer{}
+	batch := make([]*agreement.Agreement, 0, e.batchSize)
+	flush := func() error {
+		if err := e.loadCustomers(ctx, batch, customers); err != nil {
+			return err
+		}
+		for _, a := range batch {
+			for _, row := range Flatten(a, customers[a.CustomerId]) {
+				if err := rw.Write(row); err != nil {
+					return err
+				}
+				rows++
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "signed_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetBatchSize(int32(e.batchSize))
+	err = e.agreeRepo.Stream(ctx, filter, func(a *agreement.Agreement) error {
+		batch = append(batch, a)
+		if len(batch) < e.batchSize {
+			return nil
+		}
+		return flush()
+	}, opts)
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		rw.Close()
+		return rows, err
+	}
+	return rows, rw.Close()
+}
+
+func (e *Exporter) loadCustomers(ctx context.Context, batch []*agreement.Agreement, cache map[string]*customer.Customer) error {
+	if len(cache) > maxCachedCustomers {
+		clear(cache)
+	}
+	var ids []string
+	seen := map[string]bool{}
+	for _, a := range batch {
+		if _, ok := cache[a.CustomerId]; !ok && a.CustomerId != "" && !seen[a.CustomerId] {
+			seen[a.CustomerId] = true
+			ids = append(ids, a.CustomerId)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	found, err := e.custRepo.List(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return err
+	}
+	for _, c := range found {
+		cache[c.ID] = c
+	}
+	return nil
+}
+
+// Flatten раскладывает договор на строки, по одной на услугу
+func Flatten(a *agreement.Agreement, c *customer.Customer) [][]any {
+	if c == nil {
+		c = &customer.Customer{ID: a.CustomerId}
+	}
+	law := a.Law
+	if law == "" {
+		law = agreement.LawFZ223
+	}
+	head := []any{
+		a.ID, a.Number, law, a.Status, a.NoticeId, a.Price,
+		a.SignedAt, a.ExecutionStart, a.ExecutionEnd, a.PublishedAt, a.UpdatedAt,
+		a.PurchaseMethod, a.Subject, strings.Join(a.SupplierIds, ";"),
+		a.CustomerId, c.Code, c.Name, c.INN, c.OKOPF, c.MainWork, c.Location,
+	}
+	if len(a.Services) == 0 {
+		row := append(head, make([]any, len(Columns)-len(head))...)
+		return [][]any{row}
+	}
+	rows := make([][]any, 0, len(a.Services))
+	for i, s := range a.Services {
+		row := make([]any, 0, len(Columns))
+		row = append(row, head...)
+		row = append(row,
+			i+1, strings.TrimSpace(s.Name), strings.TrimSpace(s.TypeObject), s.Quantity, strings.TrimSpace(s.QuantityType),
+			s.UnitPrice, s.Currency, strings.TrimSpace(s.OKPD), strings.TrimSpace(s.OKPD2),
+			s.CountryOfOrigin, s.CountryRegistered,
+		)
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -0,0 +1,181 @@
+package export_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/export"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+func testAgreements() []*agreement.Agreement {
+	signed := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+	return []*agreement.Agreement{
+		{
+			ID: "1", Number: "N-1", Price: 1500.5, SignedAt: signed, CustomerId: "c1",
+			SupplierIds: []string{"7701", "7702"},
+			Services: []*agreement.AgreementService{
+				{Name: " Бумага ", Quantity: 10, UnitPrice: 100, OKPD2: "17.12"},
+				{Name: "Ручки", Quantity: 5, UnitPrice: 10.1},
+			},
+		},
+		{ID: "2", Number: "N-2", CustomerId: "c2"},
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	a := testAgreements()
+
+	rows := export.Flatten(a[0], &customer.Customer{ID: "c1", INN: "7705013033"})
+	assert.Len(t, rows, 2)
+	for _, row := range rows {
+		assert.Len(t, row, len(export.Columns))
+		assert.Equal(t, agreement.LawFZ223, row[2])
+		assert.Equal(t, "7701;7702", row[13])
+		assert.Equal(t, "7705013033", row[17])
+	}
+	assert.Equal(t, 1, rows[0][21])
+	assert.Equal(t, "Бумага", rows[0][22])
+	assert.Equal(t, 2, rows[1][21])
+
+	// Без услуг и без заказчика в базе остается одна строка с ID заказчика
+	rows = export.Flatten(a[1], nil)
+	assert.Len(t, rows, 1)
+	assert.Len(t, rows[0], len(export.Columns))
+	assert.Equal(t, "c2", rows[0][14])
+	assert.Nil(t, rows[0][21])
+}
+
+func newExporter(t *testing.T, streamErr error) *export.Exporter {
+	agreeRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+	agreeRepo.On("Stream", mock.Anything, bson.M{}, mock.Anything).Return(testAgreements(), streamErr)
+	custRepo := new(inmock.MockGenericRepository[*customer.Customer])
+	custRepo.On("List", mock.Anything, bson.M{"_id": bson.M{"$in": []string{"c1", "c2"}}}, mock.Anything).
+		Return([]*customer.Customer{{ID: "c1", Name: "Заказчик"}}, nil)
+	t.Cleanup(func() {
+		agreeRepo.AssertExpectations(t)
+	})
+	return export.NewExporter(agreeRepo, custRepo, 10)
+}
+
+func TestExporter_Export(t *testing.T) {
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		rows, err := newExporter(t, nil).Export(t.Context(), &buf, export.FormatCSV, bson.M{})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, rows)
+		assert.True(t, strings.HasPrefix(buf.String(), "\xEF\xBB\xBF"))
+		records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), "\xEF\xBB\xBF"))).ReadAll()
+		assert.NoError(t, err)
+		assert.Len(t, records, 4)
+		assert.Equal(t, export.Columns, records[0])
+		assert.Equal(t, "2025-03-14", records[1][6])
+		assert.Equal(t, "Заказчик", records[1][16])
+		assert.Equal(t, "", records[3][6])
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		var buf bytes.Buffer
+		rows, err := newExporter(t, nil).Export(t.Context(), &buf, export.FormatJSONL, bson.M{})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, rows)
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Len(t, lines, 3)
+		var first map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+		assert.Equal(t, "1", first["id"])
+		assert.Equal(t, "Бумага", first["service_name"])
+		assert.Equal(t, 1500.5, first["price"])
+	})
+
+	t.Run("xlsx", func(t *testing.T) {
+		var buf bytes.Buffer
+		rows, err := newExporter(t, nil).Export(t.Context(), &buf, export.FormatXLSX, bson.M{})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, rows)
+		f, err := excelize.OpenReader(&buf)
+		assert.NoError(t, err)
+		defer f.Close()
+		sheet, err := f.GetRows("Sheet1")
+		assert.NoError(t, err)
+		assert.Len(t, sheet, 4)
+		assert.Equal(t, "id", sheet[0][0])
+		assert.Equal(t, "N-2", sheet[3][1])
+	})
+
+	t.Run("stream error", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, err := newExporter(t, errors.New("cursor error")).Export(t.Context(), &buf, export.FormatCSV, bson.M{})
+		assert.Error(t, err)
+	})
+}
+
+func TestExportHandler_ExportAgreements(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedType   string
+	}{
+		{
+			name:           "bad format",
+			query:          "?format=pdf",
+			expectedStatus: fiber.StatusBadRequest,
+			expectedType:   fiber.MIMEApplicationJSON,
+		},
+		{
+			name:           "bad date",
+			query:          "?format=csv&signed_from=14.03.2025",
+			expectedStatus: fiber.StatusBadRequest,
+			expectedType:   fiber.MIMEApplicationJSON,
+		},
+		{
+			name:           "csv",
+			query:          "?format=csv",
+			expectedStatus: fiber.StatusOK,
+			expectedType:   "text/csv; charset=utf-8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var exporter *export.Exporter
+			if tt.expectedStatus == fiber.StatusOK {
+				exporter = newExporter(t, nil)
+			}
+			app := fiber.New()
+			h := export.NewExportHandler(zap.NewNop(), exporter)
+			app.Get("/export/agreements", h.ExportAgreements)
+			resp, err := app.Test(httptest.NewRequest("GET", "/export/agreements"+tt.query, nil))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			assert.Equal(t, tt.expectedType, resp.Header.Get(fiber.HeaderContentType))
+			if tt.expectedStatus != fiber.StatusOK {
+				return
+			}
+			assert.Contains(t, resp.Header.Get(fiber.HeaderContentDisposition), ".csv")
+			records, err := csv.NewReader(bufio.NewReader(resp.Body)).ReadAll()
+			assert.NoError(t, err)
+			assert.Len(t, records, 4)
+			rest, _ := io.ReadAll(resp.Body)
+			assert.Empty(t, rest)
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+	FormatXLSX  Format = "xlsx"
+)
+
+var ErrBadFormat = errors.New("format must be csv, jsonl or xlsx")
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatCSV, FormatJSONL, FormatXLSX:
+		return f, nil
+	default:
+		return "", ErrBadFormat
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	default:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+}
+
+// rowWriter пишет строки выгрузки в нужном формате
+type rowWriter interface {
+	Write(row []any) error
+	Close() error
+}
+
+func newRowWriter(f Format, w io.Writer) (rowWriter, error) {
+	switch f {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatJSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	default:
+		return nil, ErrBadFormat
+	}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	// BOM, чтобы Excel открывал кириллицу без выбора кодировки
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return nil, err
+	}
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	header := make([]any, len(Columns))
+	for i, c := range Columns {
+		header[i] = c
+	}
+	return cw, cw.Write(header)
+}
+
+func (c *csvWriter) Write(row []any) error {
+	rec := make([]string, len(row))
+	for i, v := range row {
+		rec[i] = formatValue(v)
+	}
+	return c.w.Write(rec)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonlWriter объект на строку с теми же колонками, что и в csv
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonlWriter) Write(row []any) error {
+	obj := make(map[string]any, len(row))
+	for i, v := range row {
+		if t, ok := v.(time.Time); ok {
+			v = formatValue(t)
+		}
+		obj[Columns[i]] = v
+	}
+	return j.enc.Encode(obj)
+}
+
+func (j *jsonlWriter) Close() error {
+	return nil
+}
+
+// xlsxWriter пишет через StreamWriter, строки сбрасываются во временный файл, а не копятся в памяти
+type xlsxWriter struct {
+	out  io.Writer
+	file *excelize.File
+	sw   *excelize.StreamWriter
+	row  int
+}
+
+const xlsxMaxRows = excelize.TotalRows
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	f := excelize.NewFile()
+	sw, err := f.NewStreamWriter("Sheet1")
+	if err != nil {
+		return nil, err
+	}
+	x := &xlsxWriter{out: w, file: f, sw: sw}
+	header := make([]any, len(Columns))
+	for i, c := range Columns {
+		header[i] = c
+	}
+	return x, x.Write(header)
+}
+
+func (x *xlsxWriter) Write(row []any) error {
+	x.row++
+	if x.row > xlsxMaxRows {
+		return fmt.Errorf("xlsx: больше %d строк, используйте csv или jsonl", xlsxMaxRows)
+	}
+	cells := make([]any, len(row))
+	for i, v := range row {
+		if t, ok := v.(time.Time); ok {
+			v = formatValue(t)
+		}
+		cells[i] = v
+	}
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.sw.SetRow(cell, cells)
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+	if err := x.sw.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.out)
+}
+
+func formatValue(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(t)
+	case time.Time:
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.DateOnly)
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,105 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"go.uber.org/zap"
+)
+
+// ExportHandler отдает выгрузки договоров
+type ExportHandler struct {
+	logger   *zap.Logger
+	exporter *Exporter
+}
+
+// NewExportHandler создает новый handler
+func NewExportHandler(
+	logger *zap.Logger,
+	exporter *Exporter,
+) *ExportHandler {
+	return &ExportHandler{
+		logger:   logger,
+		exporter: exporter,
+	}
+}
+
+// ExportAgreements godoc
+// @Summary Выгрузка договоров
+// @Description Потоково выгружает договоры с услугами и данными заказчика, строка на услугу. Фильтры как у /agreements
+// @Tags export
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string true "Формат: csv, jsonl, xlsx"
+// @Param signed_from query string false "Дата заключения с (YYYY-MM-DD)"
+// @Param signed_to query string false "Дата заключения по (YYYY-MM-DD)"
+// @Param price_min query number false "Минимальная цена"
+// @Param price_max query number false "Максимальная цена"
+// @Param status query string false "Статус"
+// @Param purchase_method query string false "Способ закупки"
+// @Param law query string false "Закон: 223 или 44"
+// @Param customer_id query string false "ID заказчика"
+// @Param supplier_id query string false "ID поставщика"
+// @Param okpd2 query string false "Префикс кода ОКПД2"
+// @Param subject query string false "Подстрока предмета договора"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Router /export/agreements [get]
+func (h *ExportHandler) ExportAgreements(c *fiber.Ctx) error {
+	format, err := ParseFormat(c.Query("format"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	search, err := agreement.ParseAgreementSearch(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	filter := search.Filter()
+	name := fmt.Sprintf("agreements_%s.%s", time.Now().Format("20060102_150405"), format)
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+name+`"`)
+
+	// Тело пишется после выхода из handler, статус уже отправлен,
+	// поэтому ошибку посреди выгрузки можно только залогировать.
+	// Если клиент отключился, запись в поток падает, и курсор монго закрывается
+	ctx, cancel := context.WithCancel(c.UserContext())
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		start := time.Now()
+		rows, err := h.exporter.Export(ctx, &cancelOnError{w: w, cancel: cancel}, format, filter)
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			h.logger.Error("Ошибка выгрузки договоров", zap.Int("rows", rows), zap.Error(err))
+			return
+		}
+		h.logger.Info("Выгрузка договоров завершена", zap.String("format", string(format)),
+			zap.Int("rows", rows), zap.Duration("took", time.Since(start)))
+	})
+	return nil
+}
+
+// cancelOnError отменяет выгрузку при первой ошибке записи в ответ
+type cancelOnError struct {
+	w      io.Writer
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnError) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	if err != nil {
+		c.cancel()
+	}
+	return n, err
+}
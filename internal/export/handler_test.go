@@ -0,0 +1,25 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type brokenWriter struct{}
+
+func (brokenWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("connection reset by peer")
+}
+
+// Клиент отключился: первая неудачная запись отменяет контекст выгрузки
+func TestCancelOnError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := &cancelOnError{w: brokenWriter{}, cancel: cancel}
+	_, err := w.Write([]byte("row"))
+	assert.Error(t, err)
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+}
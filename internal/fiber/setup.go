@@ -3,14 +3,33 @@ package fiber
 import (
 	"github.com/gofiber/contrib/swagger"
 	"github.com/gofiber/fiber/v2"
+	"github.com/tim8842/tender-data-loader/internal/admin"
 	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/export"
+	"github.com/tim8842/tender-data-loader/internal/health"
+	"github.com/tim8842/tender-data-loader/internal/notice"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	"github.com/tim8842/tender-data-loader/internal/task"
+	"github.com/tim8842/tender-data-loader/internal/webhook"
+	"github.com/tim8842/tender-data-loader/pkg/metrics"
 	"go.uber.org/zap"
 )
 
 func SetupFiberApp(
-	logger *zap.Logger, agreePero agreement.IAgreementRepo,
+	logger *zap.Logger, agreePero agreement.IAgreementRepo, versionRepo agreement.IAgreementVersionRepo,
+	custRepo customer.ICustomerRepo, suppRepo supplier.ISupplierRepo, noticeRepo notice.INoticeRepo,
+	subRepo webhook.ISubscriptionRepo, deadRepo webhook.IDeadLetterRepo,
+	runner admin.ITaskRunner, proxies admin.IProxyPool, monitor admin.IFillRateMonitor,
+	shards admin.IShardLister, checker *health.Checker,
 ) *fiber.App {
 	app := fiber.New()
+	app.Use(metrics.Middleware())
+	app.Get("/metrics", metrics.Handler())
+
+	healthHandler := health.NewHealthHandler(logger, checker)
+	app.Get("/healthz", healthHandler.Liveness)
+	app.Get("/readyz", healthHandler.Readiness)
 
 	app.Use(swagger.New(swagger.Config{
 		BasePath: "/",
@@ -19,8 +38,53 @@ func SetupFiberApp(
 		Title:    "Swagger API Docs",
 	}))
 
-	agreementHandler := agreement.NewAgreementHandler(logger, agreePero)
+	agreementHandler := agreement.NewAgreementHandler(logger, agreePero, versionRepo)
+	app.Get("/agreements", agreementHandler.SearchAgreements)
 	app.Get("/agreements/:id", agreementHandler.GetAgreementByID)
+	app.Get("/agreements/:id/history", agreementHandler.GetAgreementHistory)
+
+	noticeHandler := notice.NewNoticeHandler(logger, noticeRepo, agreePero)
+	app.Get("/agreements/:id/notice", noticeHandler.GetAgreementNotice)
+	app.Get("/notices/:id", noticeHandler.GetNoticeByID)
+	app.Get("/notices/:id/agreements", noticeHandler.GetNoticeAgreements)
+
+	customerHandler := customer.NewCustomerHandler(logger, custRepo)
+	app.Get("/customers", customerHandler.SearchCustomers)
+	app.Get("/customers/:id", customerHandler.GetCustomerByID)
+	app.Get("/customers/:id/agreements", agreementHandler.GetCustomerAgreements)
+
+	supplierHandler := supplier.NewSupplierHandler(logger, suppRepo)
+	app.Get("/suppliers", supplierHandler.SearchSuppliers)
+	app.Get("/suppliers/:id", supplierHandler.GetSupplierByID)
+	app.Get("/suppliers/:id/agreements", agreementHandler.GetSupplierAgreements)
+
+	webhookHandler := webhook.NewWebhookHandler(logger, subRepo, deadRepo)
+	app.Get("/webhooks", webhookHandler.ListSubscriptions)
+	app.Post("/webhooks", webhookHandler.CreateSubscription)
+	app.Get("/webhooks/dead-letters", webhookHandler.ListDeadLetters)
+	app.Get("/webhooks/:id", webhookHandler.GetSubscription)
+	app.Put("/webhooks/:id", webhookHandler.UpdateSubscription)
+	app.Delete("/webhooks/:id", webhookHandler.DeleteSubscription)
+
+	exportHandler := export.NewExportHandler(logger, export.NewExporter(agreePero, custRepo, export.DefaultBatchSize))
+	app.Get("/export/agreements", exportHandler.ExportAgreements)
+
+	taskHandler := admin.NewTaskHandler(logger, runner)
+	app.Get("/admin/tasks", taskHandler.ListTasks)
+	app.Get("/admin/tasks/:name", taskHandler.GetTask)
+	app.Post("/admin/tasks/:name/trigger", taskHandler.TriggerTask)
+	app.Post("/admin/tasks/:name/pause", taskHandler.PauseTask)
+	app.Post("/admin/tasks/:name/resume", taskHandler.ResumeTask)
+	app.Post("/admin/tasks/:name/cancel", taskHandler.CancelTask)
+
+	proxyHandler := admin.NewProxyHandler(logger, proxies)
+	app.Get("/admin/proxies", proxyHandler.GetProxies)
+
+	fillRateHandler := admin.NewFillRateHandler(logger, monitor)
+	app.Get("/admin/fill-rates", fillRateHandler.GetFillRates)
+
+	shardHandler := admin.NewShardHandler(logger, shards, task.BackToNowAgreementTaskName)
+	app.Get("/admin/shards", shardHandler.GetShards)
 
 	return app
 }
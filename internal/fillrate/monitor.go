@@ -0,0 +1,216 @@
+package fillrate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultThreshold = 0.5
+	DefaultMinBatch  = 10
+	DefaultHistory   = 100
+)
+
+// ErrLowFillRate заполненность критичного поля в пачке ниже порога: скорее всего
+// на zakupki поменялась разметка, и сохранять такие данные нельзя
+var ErrLowFillRate = errors.New("fill rate below threshold")
+
+type fieldCheck struct {
+	name   string
+	filled func(d *agreement.AgreementParesedData) bool
+}
+
+// fields поля договора, по которым считается заполненность. Имена — пути по json-тегам
+var fields = []fieldCheck{
+	{"number", func(d *agreement.AgreementParesedData) bool { return d.Number != "" }},
+	{"status", func(d *agreement.AgreementParesedData) bool { return d.Status != "" }},
+	{"price", func(d *agreement.AgreementParesedData) bool { return d.Price != 0 }},
+	{"signed_at", func(d *agreement.AgreementParesedData) bool { return !d.SignedAt.IsZero() }},
+	{"execution_start", func(d *agreement.AgreementParesedData) bool { return !d.ExecutionStart.IsZero() }},
+	{"execution_end", func(d *agreement.AgreementParesedData) bool { return !d.ExecutionEnd.IsZero() }},
+	{"published_at", func(d *agreement.AgreementParesedData) bool { return !d.PublishedAt.IsZero() }},
+	{"notice_id", func(d *agreement.AgreementParesedData) bool { return d.NoticeId != "" }},
+	{"purchase_method", func(d *agreement.AgreementParesedData) bool { return d.PurchaseMethod != "" }},
+	{"subject", func(d *agreement.AgreementParesedData) bool { return d.Subject != "" }},
+	{"customer.inn", func(d *agreement.AgreementParesedData) bool { return d.Customer != nil && d.Customer.INN != "" }},
+	{"customer.name", func(d *agreement.AgreementParesedData) bool { return d.Customer != nil && d.Customer.Name != "" }},
+	{"customer.location", func(d *agreement.AgreementParesedData) bool { return d.Customer != nil && d.Customer.Location != "" }},
+	{"suppliers", func(d *agreement.AgreementParesedData) bool { return len(d.Suppliers) > 0 }},
+	{"services", func(d *agreement.AgreementParesedData) bool { return len(d.Services) > 0 }},
+}
+
+// CriticalFields поля, без которых договор бесполезен. Если их заполненность
+// в пачке ниже порога, задача встает на паузу
+var CriticalFields = []string{"number", "price", "signed_at", "customer.inn"}
+
+// Batch заполненность полей в одной пачке договоров, доля от 0 до 1
+type Batch struct {
+	Task     string             `json:"task"`
+	At       time.Time          `json:"at"`
+	Size     int                `json:"size"`
+	Rates    map[string]float64 `json:"rates"`
+	Breached []string           `json:"breached,omitempty"` // критичные поля ниже порога
+}
+
+// TaskReport заполненность по последним пачкам задачи. Rates — средняя по пачкам,
+// взвешенная по их размеру
+type TaskReport struct {
+	Task    string             `json:"task"`
+	Batches int                `json:"batches"`
+	Size    int                `json:"size"`
+	Rates   map[string]float64 `json:"rates"`
+	Last    []*Batch           `json:"last"` // от новых к старым
+}
+
+type Report struct {
+	Threshold float64       `json:"threshold"`
+	MinBatch  int           `json:"min_batch"`
+	Critical  []string      `json:"critical"`
+	Tasks     []*TaskReport `json:"tasks"`
+}
+
+type IPauser interface {
+	Pause(taskName string) error
+}
+
+// Monitor считает заполненность полей по пачкам, которые задачи собираются сохранить,
+// и держит в памяти последние history пачек каждой задачи.
+// Nil-монитор ничего не делает, так задачи работают без контроля
+type Monitor struct {
+	logger    *zap.Logger
+	threshold float64
+	minBatch  int
+	history   int
+	mu        sync.Mutex
+	pauser    IPauser
+	batches   map[string][]*Batch
+}
+
+// NewMonitor создает монитор. Пачки меньше minBatch записываются в отчет,
+// но по ним задача не останавливается: на паре договоров доля ничего не говорит
+func NewMonitor(logger *zap.Logger, threshold float64, minBatch, history int) *Monitor {
+	if history < 1 {
+		history = DefaultHistory
+	}
+	return &Monitor{
+		logger: logger, threshold: threshold, minBatch: minBatch,
+		history: history, batches: make(map[string][]*Batch),
+	}
+}
+
+// SetPauser задает, кто ставит задачи на паузу. Раннер создается позже монитора
+func (m *Monitor) SetPauser(p IPauser) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pauser = p
+}
+
+// Observe записывает заполненность пачки задачи task. Если критичное поле ниже
+// порога, задача ставится на паузу и возвращается ErrLowFillRate
+func (m *Monitor) Observe(task string, data []*agreement.AgreementParesedData) error {
+	if m == nil || len(data) == 0 {
+		return nil
+	}
+	batch := &Batch{Task: task, At: time.Now(), Size: len(data), Rates: Rates(data)}
+	if batch.Size >= m.minBatch {
+		for _, name := range CriticalFields {
+			if batch.Rates[name] < m.threshold {
+				batch.Breached = append(batch.Breached, name)
+			}
+		}
+	}
+
+	m.mu.Lock()
+	list := append(m.batches[task], batch)
+	if len(list) > m.history {
+		list = list[len(list)-m.history:]
+	}
+	m.batches[task] = list
+	pauser := m.pauser
+	m.mu.Unlock()
+
+	m.logger.Info("Заполненность полей в пачке",
+		zap.String("task", task), zap.Int("size", batch.Size), zap.Any("rates", batch.Rates))
+	if len(batch.Breached) == 0 {
+		return nil
+	}
+	m.logger.Error("Заполненность критичных полей ниже порога, похоже изменилась разметка страниц",
+		zap.String("task", task), zap.Strings("fields", batch.Breached),
+		zap.Float64("threshold", m.threshold), zap.Any("rates", batch.Rates))
+	if pauser != nil {
+		if err := pauser.Pause(task); err != nil {
+			m.logger.Error("Ошибка постановки задачи на паузу", zap.String("task", task), zap.Error(err))
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrLowFillRate, strings.Join(batch.Breached, ", "))
+}
+
+// Report заполненность по last последним пачкам. Пустой task — по всем задачам
+func (m *Monitor) Report(task string, last int) Report {
+	if m == nil {
+		return Report{Critical: CriticalFields, Tasks: []*TaskReport{}}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	report := Report{Threshold: m.threshold, MinBatch: m.minBatch, Critical: CriticalFields, Tasks: []*TaskReport{}}
+	names := make([]string, 0, len(m.batches))
+	for name := range m.batches {
+		if task == "" || name == task {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		list := m.batches[name]
+		if last > 0 && len(list) > last {
+			list = list[len(list)-last:]
+		}
+		tr := &TaskReport{Task: name, Batches: len(list), Rates: make(map[string]float64)}
+		for i := len(list) - 1; i >= 0; i-- {
+			b := list[i]
+			tr.Last = append(tr.Last, b)
+			tr.Size += b.Size
+			for field, rate := range b.Rates {
+				tr.Rates[field] += rate * float64(b.Size)
+			}
+		}
+		for field, sum := range tr.Rates {
+			tr.Rates[field] = round(sum / float64(tr.Size))
+		}
+		report.Tasks = append(report.Tasks, tr)
+	}
+	return report
+}
+
+// Rates доля договоров с заполненным полем по каждому отслеживаемому полю
+func Rates(data []*agreement.AgreementParesedData) map[string]float64 {
+	rates := make(map[string]float64, len(fields))
+	if len(data) == 0 {
+		return rates
+	}
+	for _, f := range fields {
+		filled := 0
+		for _, d := range data {
+			if f.filled(d) {
+				filled++
+			}
+		}
+		rates[f.name] = round(float64(filled) / float64(len(data)))
+	}
+	return rates
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
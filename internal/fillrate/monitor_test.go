@@ -0,0 +1,109 @@
+package fillrate_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/fillrate"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"go.uber.org/zap"
+)
+
+func full() *agreement.AgreementParesedData {
+	return &agreement.AgreementParesedData{
+		Number: "1", Price: 100, SignedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Customer: &customer.Customer{INN: "7705013033"},
+	}
+}
+
+// batch пачка из size договоров, у empty из них нет цены
+func batch(size, empty int) []*agreement.AgreementParesedData {
+	data := make([]*agreement.AgreementParesedData, size)
+	for i := range data {
+		data[i] = full()
+		if i < empty {
+			data[i].Price = 0
+		}
+	}
+	return data
+}
+
+func TestRates(t *testing.T) {
+	data := batch(3, 1)
+	data[2].Customer = nil
+	rates := fillrate.Rates(data)
+	assert.Equal(t, 1.0, rates["number"])
+	assert.Equal(t, 0.667, rates["price"])
+	assert.Equal(t, 0.667, rates["customer.inn"])
+	assert.Equal(t, 0.0, rates["subject"])
+	assert.Empty(t, fillrate.Rates(nil))
+}
+
+func TestMonitor_Observe(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       []*agreement.AgreementParesedData
+		pauseErr   error
+		expectErr  bool
+		expectStop bool
+	}{
+		{name: "full batch", data: batch(10, 0)},
+		{name: "above threshold", data: batch(10, 5)},
+		{name: "small batch is not judged", data: batch(4, 4)},
+		{name: "price dropped", data: batch(10, 6), expectErr: true, expectStop: true},
+		{name: "pause failed", data: batch(10, 10), pauseErr: errors.New("task not found"), expectErr: true, expectStop: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := new(inmock.MockTaskRunner)
+			runner.On("Pause", "back_to_now_agreement").Return(tt.pauseErr)
+			monitor := fillrate.NewMonitor(zap.NewNop(), 0.5, 5, 10)
+			monitor.SetPauser(runner)
+			err := monitor.Observe("back_to_now_agreement", tt.data)
+			if tt.expectErr {
+				assert.ErrorIs(t, err, fillrate.ErrLowFillRate)
+				assert.ErrorContains(t, err, "price")
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.expectStop {
+				runner.AssertCalled(t, "Pause", "back_to_now_agreement")
+			} else {
+				runner.AssertNotCalled(t, "Pause", "back_to_now_agreement")
+			}
+		})
+	}
+}
+
+func TestMonitor_Report(t *testing.T) {
+	monitor := fillrate.NewMonitor(zap.NewNop(), 0.5, 100, 3)
+	for _, empty := range []int{0, 10, 5, 2} {
+		assert.NoError(t, monitor.Observe("recent_agreement", batch(10, empty)))
+	}
+	assert.NoError(t, monitor.Observe("back_to_now_contract44", batch(30, 0)))
+
+	report := monitor.Report("", 0)
+	assert.Equal(t, 0.5, report.Threshold)
+	assert.Equal(t, fillrate.CriticalFields, report.Critical)
+	assert.Len(t, report.Tasks, 2)
+	assert.Equal(t, "back_to_now_contract44", report.Tasks[0].Task)
+	// хранятся только 3 последние пачки
+	recent := report.Tasks[1]
+	assert.Equal(t, 3, recent.Batches)
+	assert.Equal(t, 30, recent.Size)
+	assert.Equal(t, 0.433, recent.Rates["price"])
+	assert.Equal(t, 0.8, recent.Last[0].Rates["price"])
+
+	report = monitor.Report("recent_agreement", 2)
+	assert.Len(t, report.Tasks, 1)
+	assert.Equal(t, 2, report.Tasks[0].Batches)
+	assert.Equal(t, 0.65, report.Tasks[0].Rates["price"])
+
+	var nilMonitor *fillrate.Monitor
+	assert.NoError(t, nilMonitor.Observe("recent_agreement", batch(10, 10)))
+	assert.Empty(t, nilMonitor.Report("", 10).Tasks)
+}
@@ -0,0 +1,132 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/pkg"
+	"go.uber.org/zap"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	DefaultTimeout = 3 * time.Second
+)
+
+// Check проверка одной зависимости. Ошибка — зависимость не готова
+type Check struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+// CheckResult результат проверки с ее временем выполнения
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report общий статус и результаты всех проверок. Статус ok, только если прошли все
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Checker выполняет проверки готовности параллельно, каждую со своим таймаутом
+type Checker struct {
+	timeout time.Duration
+	checks  []Check
+}
+
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{timeout: timeout, checks: checks}
+}
+
+func (c *Checker) Check(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: make([]CheckResult, len(c.checks))}
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+			start := time.Now()
+			err := run(checkCtx, check)
+			res := CheckResult{Name: check.Name, Status: StatusOK, LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				res.Status, res.Error = StatusFail, err.Error()
+			}
+			report.Checks[i] = res
+		}()
+	}
+	wg.Wait()
+	for _, res := range report.Checks {
+		if res.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// run не дает зависшей проверке держать ответ дольше таймаута
+func run(ctx context.Context, check Check) error {
+	done := make(chan error, 1)
+	go func() { done <- check.Fn(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("check timed out: %w", ctx.Err())
+	}
+}
+
+// Ping проверка через функцию-обертку, например mongo.PingFuncInWrapp
+func Ping(fn pkg.FuncInWrapp) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := fn.Process(ctx, zap.NewNop())
+		return err
+	}
+}
+
+type IWorkers interface {
+	Workers() (alive, total int)
+}
+
+// Workers проверяет, что все воркеры раннера задач запущены и не завершились
+func Workers(runner IWorkers) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		alive, total := runner.Workers()
+		if alive < total || total == 0 {
+			return fmt.Errorf("%d of %d workers alive", alive, total)
+		}
+		return nil
+	}
+}
+
+type IProgress interface {
+	LastProgress(taskName string) (time.Time, error)
+}
+
+// Progress проверяет, что задача task продвигалась не позже maxAge назад
+func Progress(runner IProgress, task string, maxAge time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		last, err := runner.LastProgress(task)
+		if err != nil {
+			return err
+		}
+		if age := time.Since(last); age > maxAge {
+			return fmt.Errorf("last batch %s ago at %s, limit %s",
+				age.Round(time.Second), last.Format(time.RFC3339), maxAge)
+		}
+		return nil
+	}
+}
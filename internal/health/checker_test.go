@@ -0,0 +1,105 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/internal/health"
+	"github.com/tim8842/tender-data-loader/pkg"
+	"go.uber.org/zap"
+)
+
+type fakeRunner struct {
+	alive, total int
+	last         time.Time
+	err          error
+}
+
+func (r fakeRunner) Workers() (int, int) { return r.alive, r.total }
+
+func (r fakeRunner) LastProgress(taskName string) (time.Time, error) { return r.last, r.err }
+
+type pingFunc struct{ err error }
+
+func (p pingFunc) Process(ctx context.Context, logger *zap.Logger) (any, error) { return nil, p.err }
+
+func TestChecks(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		fn        func(ctx context.Context) error
+		expectErr string
+	}{
+		{name: "ping ok", fn: health.Ping(pingFunc{})},
+		{name: "ping error", fn: health.Ping(pingFunc{errors.New("connection refused")}), expectErr: "connection refused"},
+		{name: "workers alive", fn: health.Workers(fakeRunner{alive: 5, total: 5})},
+		{name: "worker exited", fn: health.Workers(fakeRunner{alive: 4, total: 5}), expectErr: "4 of 5 workers alive"},
+		{name: "runner not started", fn: health.Workers(fakeRunner{}), expectErr: "0 of 0 workers alive"},
+		{name: "fresh batch", fn: health.Progress(fakeRunner{last: time.Now().Add(-time.Minute)}, "back_to_now_agreement", time.Hour)},
+		{name: "stale batch", fn: health.Progress(fakeRunner{last: time.Now().Add(-2 * time.Hour)}, "back_to_now_agreement", time.Hour), expectErr: "last batch 2h0m0s ago"},
+		{name: "unknown task", fn: health.Progress(fakeRunner{err: pkg.ErrTaskNotFound}, "back_to_now_agreement", time.Hour), expectErr: "task not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fn(ctx)
+			if tt.expectErr != "" {
+				assert.ErrorContains(t, err, tt.expectErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	ok := health.Check{Name: "mongo", Fn: func(ctx context.Context) error { return nil }}
+	failed := health.Check{Name: "task_workers", Fn: func(ctx context.Context) error { return errors.New("0 of 5 workers alive") }}
+	hung := health.Check{Name: "slow", Fn: func(ctx context.Context) error { time.Sleep(time.Second); return nil }}
+	tests := []struct {
+		name           string
+		url            string
+		checks         []health.Check
+		expectedStatus int
+		expected       map[string]string
+	}{
+		{name: "liveness ignores checks", url: "/healthz", checks: []health.Check{failed}, expectedStatus: fiber.StatusOK},
+		{name: "ready", url: "/readyz", checks: []health.Check{ok}, expectedStatus: fiber.StatusOK,
+			expected: map[string]string{"mongo": "ok"}},
+		{name: "not ready", url: "/readyz", checks: []health.Check{ok, failed}, expectedStatus: fiber.StatusServiceUnavailable,
+			expected: map[string]string{"mongo": "ok", "task_workers": "0 of 5 workers alive"}},
+		{name: "check timeout", url: "/readyz", checks: []health.Check{hung}, expectedStatus: fiber.StatusServiceUnavailable,
+			expected: map[string]string{"slow": "check timed out: context deadline exceeded"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := health.NewHealthHandler(zap.NewNop(), health.NewChecker(50*time.Millisecond, tt.checks...))
+			app := fiber.New()
+			app.Get("/healthz", handler.Liveness)
+			app.Get("/readyz", handler.Readiness)
+
+			resp, err := app.Test(httptest.NewRequest("GET", tt.url, nil))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.expected == nil {
+				return
+			}
+			var report health.Report
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+			assert.Len(t, report.Checks, len(tt.expected))
+			for _, c := range report.Checks {
+				if c.Status == health.StatusOK {
+					assert.Equal(t, tt.expected[c.Name], "ok")
+				} else {
+					assert.Equal(t, tt.expected[c.Name], c.Error)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package health
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// HealthHandler отдает пробы для оркестратора
+type HealthHandler struct {
+	logger  *zap.Logger
+	checker *Checker
+}
+
+// NewHealthHandler создает новый handler
+func NewHealthHandler(logger *zap.Logger, checker *Checker) *HealthHandler {
+	return &HealthHandler{logger: logger, checker: checker}
+}
+
+// Liveness godoc
+// @Summary Проба живости
+// @Description Отвечает, пока процесс жив и обрабатывает запросы. Зависимости не проверяются
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /healthz [get]
+func (h *HealthHandler) Liveness(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": StatusOK})
+}
+
+// Readiness godoc
+// @Summary Проба готовности
+// @Description Проверяет Mongo, воркеры раннера задач и давность последней пачки догрузки. Возвращает результат и время каждой проверки
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /readyz [get]
+func (h *HealthHandler) Readiness(c *fiber.Ctx) error {
+	report := h.checker.Check(c.UserContext())
+	if report.Status != StatusOK {
+		h.logger.Warn("Сервис не готов", zap.Any("checks", report.Checks))
+		return c.Status(fiber.StatusServiceUnavailable).JSON(report)
+	}
+	return c.JSON(report)
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/stretchr/testify/mock"
 	"github.com/tim8842/tender-data-loader/pkg/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type MockGenericRepository[T repository.BaseModel] struct {
@@ -29,3 +31,37 @@ func (m *MockGenericRepository[T]) Create(ctx context.Context, doc T) error {
 	args := m.Called(ctx, doc)
 	return args.Error(0)
 }
+
+func (m *MockGenericRepository[T]) List(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]T, error) {
+	args := m.Called(ctx, filter, opts)
+	res, _ := args.Get(0).([]T)
+	return res, args.Error(1)
+}
+
+func (m *MockGenericRepository[T]) CountDocuments(ctx context.Context, filter interface{}) (int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockGenericRepository[T]) Aggregate(ctx context.Context, pipeline interface{}) ([]bson.M, error) {
+	args := m.Called(ctx, pipeline)
+	res, _ := args.Get(0).([]bson.M)
+	return res, args.Error(1)
+}
+
+func (m *MockGenericRepository[T]) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// Stream отдает в fn документы, переданные первым аргументом Return
+func (m *MockGenericRepository[T]) Stream(ctx context.Context, filter interface{}, fn func(T) error, opts ...*options.FindOptions) error {
+	args := m.Called(ctx, filter, opts)
+	docs, _ := args.Get(0).([]T)
+	for _, doc := range docs {
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
@@ -0,0 +1,45 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/internal/shard"
+)
+
+type MockShardRepo struct {
+	mock.Mock
+}
+
+func (m *MockShardRepo) Ensure(ctx context.Context, shards []*shard.Shard) error {
+	args := m.Called(ctx, shards)
+	return args.Error(0)
+}
+
+func (m *MockShardRepo) Acquire(ctx context.Context, task, owner string, now time.Time, lease time.Duration) (*shard.Shard, error) {
+	args := m.Called(ctx, task, owner, now, lease)
+	res, _ := args.Get(0).(*shard.Shard)
+	return res, args.Error(1)
+}
+
+func (m *MockShardRepo) Heartbeat(ctx context.Context, id, owner string, until time.Time) error {
+	args := m.Called(ctx, id, owner, until)
+	return args.Error(0)
+}
+
+func (m *MockShardRepo) Save(ctx context.Context, s *shard.Shard, owner string, until time.Time) error {
+	args := m.Called(ctx, s, owner, until)
+	return args.Error(0)
+}
+
+func (m *MockShardRepo) Release(ctx context.Context, id, owner string) error {
+	args := m.Called(ctx, id, owner)
+	return args.Error(0)
+}
+
+func (m *MockShardRepo) List(ctx context.Context, task string) ([]*shard.Shard, error) {
+	args := m.Called(ctx, task)
+	res, _ := args.Get(0).([]*shard.Shard)
+	return res, args.Error(1)
+}
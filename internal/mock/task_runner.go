@@ -0,0 +1,36 @@
+package mock
+
+import (
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/pkg"
+)
+
+type MockTaskRunner struct {
+	mock.Mock
+}
+
+func (m *MockTaskRunner) Statuses() []pkg.TaskStatus {
+	args := m.Called()
+	return args.Get(0).([]pkg.TaskStatus)
+}
+
+func (m *MockTaskRunner) Status(taskName string) (pkg.TaskStatus, error) {
+	args := m.Called(taskName)
+	return args.Get(0).(pkg.TaskStatus), args.Error(1)
+}
+
+func (m *MockTaskRunner) Trigger(taskName string) error {
+	return m.Called(taskName).Error(0)
+}
+
+func (m *MockTaskRunner) Pause(taskName string) error {
+	return m.Called(taskName).Error(0)
+}
+
+func (m *MockTaskRunner) Resume(taskName string) error {
+	return m.Called(taskName).Error(0)
+}
+
+func (m *MockTaskRunner) Cancel(taskName string) error {
+	return m.Called(taskName).Error(0)
+}
@@ -0,0 +1,139 @@
+package notice
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// NoticeHandler обрабатывает запросы к извещениям о закупках
+type NoticeHandler struct {
+	logger     *zap.Logger
+	noticeRepo INoticeRepo
+	agreeRepo  agreement.IAgreementRepo
+}
+
+// NewNoticeHandler создает новый handler
+func NewNoticeHandler(
+	logger *zap.Logger,
+	noticeRepo INoticeRepo,
+	agreeRepo agreement.IAgreementRepo,
+) *NoticeHandler {
+	return &NoticeHandler{
+		logger:     logger,
+		noticeRepo: noticeRepo,
+		agreeRepo:  agreeRepo,
+	}
+}
+
+// GetNoticeByID godoc
+// @Summary Получить извещение по ID
+// @Description Возвращает извещение о закупке с лотами и документами
+// @Tags notices
+// @Accept json
+// @Produce json
+// @Param id path string true "ID извещения (notice_id договора)"
+// @Success 200 {object} model.Notice
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /notices/{id} [get]
+func (h *NoticeHandler) GetNoticeByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	h.logger.Info("Получение извещения", zap.String("id", id))
+	n, err := h.noticeRepo.GetByID(c.Context(), id)
+	if err != nil {
+		return h.noticeError(c, id, err)
+	}
+	return c.JSON(n)
+}
+
+// GetNoticeAgreements godoc
+// @Summary Договоры по извещению
+// @Description Возвращает извещение, заключенные по нему договоры и снижение цены договоров относительно НМЦ
+// @Tags notices
+// @Accept json
+// @Produce json
+// @Param id path string true "ID извещения"
+// @Success 200 {object} model.NoticeAgreements
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /notices/{id}/agreements [get]
+func (h *NoticeHandler) GetNoticeAgreements(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	n, err := h.noticeRepo.GetByID(c.Context(), id)
+	if err != nil {
+		return h.noticeError(c, id, err)
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "signed_at", Value: 1}, {Key: "_id", Value: 1}})
+	agreements, err := h.agreeRepo.List(c.Context(), bson.M{"notice_id": id}, opts)
+	if err != nil {
+		h.logger.Error("Ошибка при получении договоров извещения", zap.String("id", id), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "internal server error",
+		})
+	}
+	return c.JSON(BuildNoticeAgreements(n, agreements))
+}
+
+// GetAgreementNotice godoc
+// @Summary Извещение договора
+// @Description Возвращает извещение о закупке, по которому заключен договор
+// @Tags agreements
+// @Accept json
+// @Produce json
+// @Param id path string true "ID договора"
+// @Success 200 {object} model.Notice
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /agreements/{id}/notice [get]
+func (h *NoticeHandler) GetAgreementNotice(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	a, err := h.agreeRepo.GetByID(c.Context(), id)
+	if err != nil {
+		if notFound(err) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "agreement not found",
+			})
+		}
+		h.logger.Error("Ошибка при получении договора", zap.String("id", id), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "internal server error",
+		})
+	}
+	if a.NoticeId == "" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "notice not found",
+		})
+	}
+	n, err := h.noticeRepo.GetByID(c.Context(), a.NoticeId)
+	if err != nil {
+		return h.noticeError(c, a.NoticeId, err)
+	}
+	return c.JSON(n)
+}
+
+func (h *NoticeHandler) noticeError(c *fiber.Ctx, id string, err error) error {
+	if notFound(err) {
+		h.logger.Warn("Извещение не найдено", zap.String("id", id), zap.Error(err))
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "notice not found",
+		})
+	}
+	h.logger.Error("Ошибка при получении извещения", zap.String("id", id), zap.Error(err))
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "internal server error",
+	})
+}
+
+func notFound(err error) bool {
+	return errors.Is(err, mongo.ErrNoDocuments) || strings.Contains(err.Error(), "not found")
+}
@@ -0,0 +1,148 @@
+package notice_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/tim8842/tender-data-loader/internal/notice"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+func TestNoticeHandler_GetNoticeAgreements(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		noticeReturn   *notice.Notice
+		noticeError    error
+		listReturn     []*agreement.Agreement
+		listError      error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:         "discount by lots",
+			id:           "18342197",
+			noticeReturn: &notice.Notice{ID: "18342197", MaxPrice: 150000},
+			listReturn: []*agreement.Agreement{
+				{ID: "1", Number: "N-1", Price: 90000},
+				{ID: "2", Number: "N-2", Price: 45000.5},
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody: `{"contracts_price":135000.5,"discount":14999.5,"discount_percent":10,"agreements":[` +
+				`{"agreement_id":"1","number":"N-1","price":90000,"discount":60000,"discount_percent":40},` +
+				`{"agreement_id":"2","number":"N-2","price":45000.5,"discount":104999.5,"discount_percent":70}]}`,
+		},
+		{
+			name:           "without agreements",
+			id:             "18342197",
+			noticeReturn:   &notice.Notice{ID: "18342197", MaxPrice: 150000},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `{"contracts_price":0,"discount":0,"discount_percent":0,"agreements":[]}`,
+		},
+		{
+			name:           "notice not found",
+			id:             "1",
+			noticeError:    mongo.ErrNoDocuments,
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody:   `{"error":"notice not found"}`,
+		},
+		{
+			name:           "list error",
+			id:             "18342197",
+			noticeReturn:   &notice.Notice{ID: "18342197"},
+			listError:      errors.New("db error"),
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			noticeRepo := new(inmock.MockGenericRepository[*notice.Notice])
+			noticeRepo.On("GetByID", mock.Anything, tt.id).Return(tt.noticeReturn, tt.noticeError)
+			agreeRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+			agreeRepo.On("List", mock.Anything, bson.M{"notice_id": tt.id}, mock.Anything).Return(tt.listReturn, tt.listError)
+			h := notice.NewNoticeHandler(zap.NewNop(), noticeRepo, agreeRepo)
+			app.Get("/notices/:id/agreements", h.GetNoticeAgreements)
+			resp, err := app.Test(httptest.NewRequest("GET", "/notices/"+tt.id+"/agreements", nil))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			if tt.expectedStatus != fiber.StatusOK {
+				assert.JSONEq(t, tt.expectedBody, string(body))
+				return
+			}
+			// Само извещение здесь не проверяем, только расчет снижения
+			var got map[string]any
+			assert.NoError(t, json.Unmarshal(body, &got))
+			delete(got, "notice")
+			raw, _ := json.Marshal(got)
+			assert.JSONEq(t, tt.expectedBody, string(raw))
+		})
+	}
+}
+
+func TestNoticeHandler_GetAgreementNotice(t *testing.T) {
+	tests := []struct {
+		name           string
+		agreement      *agreement.Agreement
+		agreementError error
+		noticeError    error
+		expectedStatus int
+	}{
+		{
+			name:           "linked notice",
+			agreement:      &agreement.Agreement{ID: "1", NoticeId: "18342197"},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:           "agreement without notice",
+			agreement:      &agreement.Agreement{ID: "1"},
+			expectedStatus: fiber.StatusNotFound,
+		},
+		{
+			name:           "notice not loaded yet",
+			agreement:      &agreement.Agreement{ID: "1", NoticeId: "18342197"},
+			noticeError:    mongo.ErrNoDocuments,
+			expectedStatus: fiber.StatusNotFound,
+		},
+		{
+			name:           "agreement not found",
+			agreementError: mongo.ErrNoDocuments,
+			expectedStatus: fiber.StatusNotFound,
+		},
+		{
+			name:           "agreement error",
+			agreementError: errors.New("db error"),
+			expectedStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			agreeRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+			agreeRepo.On("GetByID", mock.Anything, "1").Return(tt.agreement, tt.agreementError)
+			noticeRepo := new(inmock.MockGenericRepository[*notice.Notice])
+			noticeRepo.On("GetByID", mock.Anything, "18342197").Return(&notice.Notice{ID: "18342197"}, tt.noticeError)
+			h := notice.NewNoticeHandler(zap.NewNop(), noticeRepo, agreeRepo)
+			app.Get("/agreements/:id/notice", h.GetAgreementNotice)
+			resp, err := app.Test(httptest.NewRequest("GET", "/agreements/1/notice", nil))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package notice
+
+import (
+	"math"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+)
+
+type Lot struct {
+	No       int     `bson:"no" json:"no"`               // номер лота
+	Subject  string  `bson:"subject" json:"subject"`     // предмет договора (лота)
+	MaxPrice float64 `bson:"max_price" json:"max_price"` // начальная (максимальная) цена лота
+	Currency string  `bson:"currency,omitempty" json:"currency,omitempty"`
+	OKPD2    string  `bson:"okpd2,omitempty" json:"okpd2,omitempty"`
+}
+
+type Document struct {
+	Name        string    `bson:"name" json:"name"`
+	Url         string    `bson:"url" json:"url"`
+	PublishedAt time.Time `bson:"published_at" json:"published_at"` // дата размещения, может быть пустой
+}
+
+// Notice извещение о закупке. ID совпадает с notice_id договоров, заключенных по нему
+type Notice struct {
+	ID             string    `bson:"_id,omitempty" json:"id"` // noticeInfoId
+	Law            string    `bson:"law" json:"law"`
+	Number         string    `bson:"number" json:"number"` // реестровый номер извещения
+	Status         string    `bson:"status,omitempty" json:"status"`
+	Subject        string    `bson:"subject" json:"subject"`                 // наименование закупки
+	PurchaseMethod string    `bson:"purchase_method" json:"purchase_method"` // способ закупки
+	MaxPrice       float64   `bson:"max_price" json:"max_price"`             // НМЦ, если на карточке нет — сумма по лотам
+	CustomerId     string    `bson:"customer_id,omitempty" json:"customer_id"`
+	PublishedAt    time.Time `bson:"published_at" json:"published_at"` // дата размещения извещения
+	BidDeadline    time.Time `bson:"bid_deadline" json:"bid_deadline"` // окончание подачи заявок, время без учета часового пояса
+
+	Lots      []*Lot      `bson:"lots" json:"lots"`
+	Documents []*Document `bson:"documents" json:"documents"`
+
+	AgreementIds []string  `bson:"agreement_ids" json:"agreement_ids"` // договоры, заключенные по извещению
+	LoadedAt     time.Time `bson:"loaded_at" json:"loaded_at"`
+}
+
+func (t Notice) GetID() any {
+	return t.ID
+}
+
+// AgreementDiscount снижение цены договора относительно НМЦ извещения
+type AgreementDiscount struct {
+	AgreementId     string  `json:"agreement_id"`
+	Number          string  `json:"number"`
+	Price           float64 `json:"price"`
+	Discount        float64 `json:"discount"`         // НМЦ минус цена договора
+	DiscountPercent float64 `json:"discount_percent"` // снижение в процентах от НМЦ
+}
+
+// NoticeAgreements извещение с договорами и итоговым снижением по всем договорам
+type NoticeAgreements struct {
+	Notice          *Notice              `json:"notice"`
+	Agreements      []*AgreementDiscount `json:"agreements"`
+	ContractsPrice  float64              `json:"contracts_price"` // сумма цен договоров
+	Discount        float64              `json:"discount"`
+	DiscountPercent float64              `json:"discount_percent"`
+}
+
+// BuildNoticeAgreements считает снижение цены по каждому договору и в сумме.
+// Без НМЦ проценты остаются нулевыми
+func BuildNoticeAgreements(n *Notice, agreements []*agreement.Agreement) *NoticeAgreements {
+	res := &NoticeAgreements{Notice: n, Agreements: []*AgreementDiscount{}}
+	for _, a := range agreements {
+		d := &AgreementDiscount{AgreementId: a.ID, Number: a.Number, Price: a.Price}
+		d.Discount, d.DiscountPercent = discount(n.MaxPrice, a.Price)
+		res.Agreements = append(res.Agreements, d)
+		res.ContractsPrice += a.Price
+	}
+	res.ContractsPrice = round2(res.ContractsPrice)
+	if len(agreements) > 0 {
+		res.Discount, res.DiscountPercent = discount(n.MaxPrice, res.ContractsPrice)
+	}
+	return res
+}
+
+func discount(maxPrice, price float64) (float64, float64) {
+	if maxPrice <= 0 {
+		return 0, 0
+	}
+	diff := maxPrice - price
+	return round2(diff), round2(diff / maxPrice * 100)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,30 @@
+package notice
+
+import (
+	"context"
+
+	"github.com/tim8842/tender-data-loader/pkg/repository"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type NoticeRepo struct {
+	*repository.GenericRepository[*Notice]
+}
+
+func (r *NoticeRepo) BulkMergeMany(ctx context.Context, docs []*Notice) error {
+	return r.BulkCreateOrUpdateMany(ctx, docs)
+}
+
+func (r *NoticeRepo) GetByID(ctx context.Context, id string) (*Notice, error) {
+	return r.GenericRepository.GetByID(ctx, id)
+}
+
+func (r *NoticeRepo) List(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*Notice, error) {
+	return r.GenericRepository.List(ctx, filter, opts...)
+}
+
+type INoticeRepo interface {
+	BulkMergeMany(ctx context.Context, docs []*Notice) error
+	GetByID(ctx context.Context, id string) (*Notice, error)
+	List(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*Notice, error)
+}
@@ -0,0 +1,147 @@
+package notice
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/pkg/parser"
+	"go.uber.org/zap"
+)
+
+// Парсеры извещения 223-ФЗ: общая информация, список лотов и документы
+
+var dateTimeRe = regexp.MustCompile(`(\d{2}\.\d{2}\.\d{4})(?:\s*в\s*(\d{1,2}:\d{2}))?`)
+
+// ParseNoticeFromMain разбирает общую информацию извещения. Для страницы без номера возвращает nil
+func ParseNoticeFromMain(ctx context.Context, logger *zap.Logger, body []byte) (any, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	n := &Notice{Law: agreement.LawFZ223}
+	doc.Find("span.cardMainInfo__purchaseLink a").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		n.Number = strings.Join(strings.Fields(strings.TrimPrefix(strings.TrimSpace(s.Text()), "№")), "")
+		return false
+	})
+	if n.Number == "" {
+		return nil, nil
+	}
+	n.Status = clean(doc.Find("span.cardMainInfo__state").First().Text())
+	if text, err := parser.GetFromHtmlByTitle(doc, "span", "Начальная (максимальная) цена договора"); err == nil {
+		n.MaxPrice, _ = parser.ParsePriceToFloat(text)
+	}
+	n.Subject = cardText(doc, "Наименование закупки")
+	n.PurchaseMethod = cardText(doc, "Способ осуществления закупки")
+	n.PublishedAt = parseDateTime(cardText(doc, "Дата размещения извещения"))
+	n.BidDeadline = parseDateTime(cardText(doc, "Дата и время окончания срока подачи заявок"))
+	if el, err := parser.GetElementFromHtmlByTitle(doc, "span", "Наименование организации"); err == nil {
+		if href, ok := el.Find("a").Attr("href"); ok {
+			n.CustomerId = parser.GetParamFromHref(href, "agencyId")
+		}
+	}
+	return n, nil
+}
+
+// ParseNoticeLots дополняет извещение лотами. Если НМЦ не было на карточке, она считается по лотам
+func ParseNoticeLots(ctx context.Context, logger *zap.Logger, body []byte, n *Notice) (any, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	n.Lots = nil
+	doc.Find("table").Each(func(i int, table *goquery.Selection) {
+		var columns []string
+		table.Find("th").Each(func(j int, cell *goquery.Selection) {
+			columns = append(columns, strings.ToLower(clean(cell.Text())))
+		})
+		table.Find("tbody tr").Each(func(j int, row *goquery.Selection) {
+			lot := &Lot{}
+			row.Find("td").Each(func(k int, cell *goquery.Selection) {
+				if k >= len(columns) {
+					return
+				}
+				v := clean(cell.Text())
+				switch column := columns[k]; {
+				case strings.HasPrefix(column, "№") || strings.HasPrefix(column, "номер"):
+					lot.No, _ = strconv.Atoi(v)
+				case strings.HasPrefix(column, "предмет") || strings.HasPrefix(column, "наименование"):
+					lot.Subject = v
+				case strings.Contains(column, "цена"):
+					lot.MaxPrice, _ = parser.ParsePriceToFloat(v)
+				case strings.HasPrefix(column, "валюта"):
+					lot.Currency = v
+				case strings.Contains(column, "окпд2"):
+					if v != "" {
+						lot.OKPD2 = "ОКПД2:" + v
+					}
+				}
+			})
+			if lot.Subject != "" {
+				n.Lots = append(n.Lots, lot)
+			}
+		})
+	})
+	if n.MaxPrice == 0 {
+		for _, lot := range n.Lots {
+			n.MaxPrice += lot.MaxPrice
+		}
+	}
+	return n, nil
+}
+
+// ParseNoticeDocuments дополняет извещение ссылками на документы закупки
+func ParseNoticeDocuments(ctx context.Context, logger *zap.Logger, body []byte, n *Notice) (any, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	n.Documents = nil
+	doc.Find(".attachment").Each(func(i int, s *goquery.Selection) {
+		link := s.Find("a[href]").First()
+		href, ok := link.Attr("href")
+		if !ok {
+			return
+		}
+		name, _ := link.Attr("title")
+		if name = clean(name); name == "" {
+			name = clean(link.Text())
+		}
+		n.Documents = append(n.Documents, &Document{
+			Name:        name,
+			Url:         strings.TrimSpace(href),
+			PublishedAt: parseDateTime(s.Find(".attachment__value").Text()),
+		})
+	})
+	return n, nil
+}
+
+// parseDateTime достает из текста дату и, если есть, время вида "16.06.2025 в 10:00"
+func parseDateTime(text string) time.Time {
+	m := dateTimeRe.FindStringSubmatch(text)
+	if m == nil {
+		return time.Time{}
+	}
+	if m[2] != "" {
+		if t, err := time.Parse("02.01.2006 15:04", m[1]+" "+m[2]); err == nil {
+			return t
+		}
+	}
+	t, _ := parser.ParseFromDateToTime(m[1])
+	return t
+}
+
+func cardText(doc *goquery.Document, title string) string {
+	text, _ := parser.GetFromHtmlByTitle(doc, "span", title)
+	return clean(text)
+}
+
+func clean(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,100 @@
+package notice_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/notice"
+	"github.com/tim8842/tender-data-loader/pkg/reader"
+	"go.uber.org/zap"
+)
+
+func getDate(layout, value string) time.Time {
+	t, _ := time.Parse(layout, value)
+	return t
+}
+
+func TestParseNoticeFromMain(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	dir := "../../assets/test/ParseNoticeFromMain"
+	tests := []struct {
+		name     string
+		file     string
+		expected *notice.Notice
+	}{
+		{
+			name: "correct",
+			file: "/correct.html",
+			expected: &notice.Notice{
+				Law: agreement.LawFZ223, Number: "32514850397", Status: "Размещение завершено",
+				Subject:        "Поставка канцелярских товаров",
+				PurchaseMethod: "Запрос котировок в электронной форме, участниками которого могут являться только субъекты малого и среднего предпринимательства",
+				MaxPrice:       150000, CustomerId: "492275",
+				PublishedAt: getDate("02.01.2006", "06.06.2025"),
+				BidDeadline: getDate("02.01.2006 15:04", "16.06.2025 10:00"),
+			},
+		},
+		{name: "error", file: "/error.html"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := notice.ParseNoticeFromMain(ctx, logger, reader.ReadHtmlFile(dir+tt.file))
+			assert.NoError(t, err)
+			if tt.expected == nil {
+				assert.Nil(t, result)
+				return
+			}
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestParseNoticeLots(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	body := reader.ReadHtmlFile("../../assets/test/ParseNoticeLots/correct.html")
+	lots := []*notice.Lot{
+		{No: 1, Subject: "Бумага офисная", MaxPrice: 100000, Currency: "Российский рубль", OKPD2: "ОКПД2:17.12.14.110 Бумага для печати"},
+		{No: 2, Subject: "Ручки шариковые", MaxPrice: 50000, Currency: "Российский рубль", OKPD2: "ОКПД2:32.99.12.110 Ручки шариковые"},
+	}
+	tests := []struct {
+		name          string
+		maxPrice      float64
+		expectedPrice float64
+	}{
+		{name: "price from card", maxPrice: 160000, expectedPrice: 160000},
+		{name: "price from lots", maxPrice: 0, expectedPrice: 150000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &notice.Notice{MaxPrice: tt.maxPrice}
+			_, err := notice.ParseNoticeLots(ctx, logger, body, n)
+			assert.NoError(t, err)
+			assert.Equal(t, lots, n.Lots)
+			assert.Equal(t, tt.expectedPrice, n.MaxPrice)
+		})
+	}
+}
+
+func TestParseNoticeDocuments(t *testing.T) {
+	n := &notice.Notice{}
+	_, err := notice.ParseNoticeDocuments(context.Background(), zap.NewNop(),
+		reader.ReadHtmlFile("../../assets/test/ParseNoticeDocuments/correct.html"), n)
+	assert.NoError(t, err)
+	assert.Equal(t, []*notice.Document{
+		{
+			Name:        "Извещение.docx",
+			Url:         "https://zakupki.gov.ru/223/purchase/public/download/download.html?id=98765001",
+			PublishedAt: getDate("02.01.2006", "06.06.2025"),
+		},
+		{
+			Name:        "Проект договора.pdf",
+			Url:         "https://zakupki.gov.ru/223/purchase/public/download/download.html?id=98765002",
+			PublishedAt: getDate("02.01.2006", "09.06.2025"),
+		},
+	}, n.Documents)
+}
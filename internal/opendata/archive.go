@@ -0,0 +1,70 @@
+package opendata
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"go.uber.org/zap"
+)
+
+// ErrBadArchive архив не открывается как zip: обрезан при скачивании или испорчен
+var ErrBadArchive = errors.New("bad zip archive")
+
+// ParseArchive разбирает xml-файлы zip-архива и отдает договоры в fn.
+// Битые файлы и записи архива пропускаются с предупреждением, ошибка fn прерывает разбор.
+// Возвращает число пропущенных файлов
+func ParseArchive(ctx context.Context, logger *zap.Logger, data []byte, fn func(*agreement.AgreementParesedData) error) (int, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrBadArchive, err)
+	}
+	failed := 0
+	for _, f := range zr.File {
+		if ctx.Err() != nil {
+			return failed, ctx.Err()
+		}
+		if f.FileInfo().IsDir() || !strings.EqualFold(pathExt(f.Name), ".xml") {
+			continue
+		}
+		body, err := readZipFile(f)
+		if err != nil {
+			logger.Warn("Пропущен битый файл архива", zap.String("file", f.Name), zap.Error(err))
+			failed++
+			continue
+		}
+		contracts, err := ParseContract(body)
+		if err != nil {
+			logger.Warn("Пропущен файл выгрузки", zap.String("file", f.Name), zap.Error(err))
+			failed++
+			continue
+		}
+		for _, c := range contracts {
+			if err := fn(c); err != nil {
+				return failed, err
+			}
+		}
+	}
+	return failed, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func pathExt(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i:]
+	}
+	return ""
+}
@@ -0,0 +1,196 @@
+package opendata
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	"github.com/tim8842/tender-data-loader/pkg/parser"
+)
+
+// Разметка сведений о договоре 223-ФЗ из выгрузок открытых данных zakupki.
+// Теги без пространства имен: encoding/xml сравнивает только локальные имена
+type contractDoc struct {
+	Items []contractData `xml:"body>item>contractData"`
+}
+
+type contractData struct {
+	GUID              string           `xml:"guid"`
+	CreateDateTime    string           `xml:"createDateTime"`
+	PublicationDate   string           `xml:"publicationDate"`
+	ModificationDate  string           `xml:"modificationDate"`
+	RegistrationNum   string           `xml:"registrationNumber"`
+	ContractRegNumber string           `xml:"contractRegNumber"`
+	Status            string           `xml:"status"`
+	Name              string           `xml:"name"`
+	ContractDate      string           `xml:"contractDate"`
+	StartExecution    string           `xml:"startExecutionDate"`
+	EndExecution      string           `xml:"endExecutionDate"`
+	Price             float64          `xml:"price"`
+	Currency          string           `xml:"currency>code"`
+	PurchaseMethod    string           `xml:"purchaseInfo>name"`
+	Customer          organization     `xml:"customer>mainInfo"`
+	Suppliers         []organization   `xml:"supplierInfo"`
+	Positions         []contractItemEl `xml:"contractPositions>contractPosition"`
+}
+
+type organization struct {
+	FullName string `xml:"fullName"`
+	Name     string `xml:"name"`
+	IKO      string `xml:"iko"`
+	INN      string `xml:"inn"`
+	KPP      string `xml:"kpp"`
+	OGRN     string `xml:"ogrn"`
+	OKOPF    string `xml:"okopfName"`
+	Address  string `xml:"legalAddress"`
+	Provider string `xml:"provider"` // признак МСП у поставщика: true/false
+}
+
+type contractItemEl struct {
+	Name       string  `xml:"name"`
+	TypeObject string  `xml:"type"`
+	OKPD2Code  string  `xml:"okpd2>code"`
+	OKPD2Name  string  `xml:"okpd2>name"`
+	Country    string  `xml:"country>name"`
+	Unit       string  `xml:"okei>name"`
+	Qty        float64 `xml:"qty"`
+	UnitPrice  float64 `xml:"unitPrice"`
+	Currency   string  `xml:"currency>code"`
+}
+
+// statusNames коды статуса договора из выгрузки в названия, как на сайте.
+// Неизвестные коды сохраняются как есть
+var statusNames = map[string]string{
+	"P": "Исполнение",
+	"E": "Исполнение завершено",
+	"T": "Исполнение прекращено",
+	"A": "Аннулирован",
+}
+
+var ErrNoContract = errors.New("no contract data")
+
+// ParseContract разбирает xml-файл со сведениями о договоре. ID договора —
+// реестровый номер, ID заказчика — ИНН; ResolveIDs заменяет их на ID с сайта, если договор
+// уже есть в базе, иначе договор откладывается в opendata_agreements
+func ParseContract(body []byte) ([]*agreement.AgreementParesedData, error) {
+	var doc contractDoc
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Items) == 0 {
+		return nil, ErrNoContract
+	}
+	res := make([]*agreement.AgreementParesedData, 0, len(doc.Items))
+	for _, item := range doc.Items {
+		number := strings.TrimSpace(item.ContractRegNumber)
+		if number == "" {
+			number = strings.TrimSpace(item.RegistrationNum)
+		}
+		if number == "" {
+			return nil, ErrNoContract
+		}
+		data := &agreement.AgreementParesedData{
+			ID:             number,
+			Number:         number,
+			Law:            agreement.LawFZ223,
+			Status:         item.Status,
+			Price:          item.Price,
+			SignedAt:       parseDate(item.ContractDate),
+			ExecutionStart: parseDate(item.StartExecution),
+			ExecutionEnd:   parseDate(item.EndExecution),
+			PublishedAt:    parseDate(item.PublicationDate),
+			UpdatedAt:      parseDate(item.ModificationDate),
+			PurchaseMethod: clean(item.PurchaseMethod),
+			Subject:        clean(item.Name),
+			Customer: &customer.Customer{
+				ID:       firstNonEmpty(item.Customer.INN, item.Customer.OGRN, item.Customer.IKO),
+				Code:     item.Customer.IKO,
+				Name:     clean(item.Customer.FullName),
+				INN:      item.Customer.INN,
+				OKOPF:    clean(item.Customer.OKOPF),
+				Location: clean(item.Customer.Address),
+			},
+		}
+		if data.Customer.ID == "" {
+			return nil, fmt.Errorf("%w: no customer in %s", ErrNoContract, number)
+		}
+		if name, ok := statusNames[item.Status]; ok {
+			data.Status = name
+		}
+		if data.PublishedAt.IsZero() {
+			data.PublishedAt = parseDate(item.CreateDateTime)
+		}
+		if data.UpdatedAt.IsZero() {
+			data.UpdatedAt = data.PublishedAt
+		}
+		for _, s := range item.Suppliers {
+			name := clean(s.Name)
+			if name == "" {
+				name = clean(s.FullName)
+			}
+			sup := &supplier.Supplier{
+				ID:      supplier.MakeID(s.INN, s.KPP, name),
+				Name:    name,
+				INN:     s.INN,
+				KPP:     s.KPP,
+				OGRN:    s.OGRN,
+				Address: clean(s.Address),
+				IsSME:   s.Provider == "true",
+			}
+			if sup.ID != "" {
+				data.Suppliers = append(data.Suppliers, sup)
+			}
+		}
+		for _, p := range item.Positions {
+			currency := p.Currency
+			if currency == "" {
+				currency = item.Currency
+			}
+			okpd2 := ""
+			if p.OKPD2Code != "" {
+				okpd2 = "ОКПД2:" + p.OKPD2Code + " " + clean(p.OKPD2Name)
+			}
+			data.Services = append(data.Services, &agreement.AgreementService{
+				Name:            clean(p.Name),
+				TypeObject:      clean(p.TypeObject),
+				Quantity:        p.Qty,
+				QuantityType:    clean(p.Unit),
+				UnitPrice:       p.UnitPrice,
+				Currency:        currency,
+				CountryOfOrigin: clean(p.Country),
+				OKPD2:           strings.TrimSpace(okpd2),
+			})
+		}
+		res = append(res, data)
+	}
+	return res, nil
+}
+
+// parseDate даты в выгрузке бывают с временем и зоной и без них
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", time.DateOnly, "2006-01-02Z07:00"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return parser.DateOnly(t)
+		}
+	}
+	return time.Time{}
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func clean(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
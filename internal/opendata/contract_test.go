@@ -0,0 +1,138 @@
+package opendata_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/opendata"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	"go.uber.org/zap"
+)
+
+const dir = "../../assets/test/ParseOpenData"
+
+const archiveName = "contract_Sverdlovskaja_obl_20250523_000000_20250523_235959_daily_001.xml.zip"
+
+func date(s string) time.Time {
+	t, _ := time.Parse(time.DateOnly, s)
+	return t
+}
+
+func readFile(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(dir + "/" + name)
+	assert.NoError(t, err)
+	return data
+}
+
+func TestParseContract(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		expectErr   bool
+		expectedLen int
+		expected    *agreement.AgreementParesedData
+	}{
+		{
+			name:        "correct",
+			file:        "contract.xml",
+			expectedLen: 1,
+			expected: &agreement.AgreementParesedData{
+				ID: "56680005928250002570000", Number: "56680005928250002570000",
+				Law:    agreement.LawFZ223,
+				Status: "Исполнение завершено", Price: 129307.50,
+				SignedAt: date("2025-05-23"), ExecutionStart: date("2025-05-23"),
+				ExecutionEnd: date("2025-06-19"), PublishedAt: date("2025-05-23"),
+				UpdatedAt:      date("2025-06-11"),
+				PurchaseMethod: "Закупка у единственного поставщика (подрядчика, исполнителя)",
+				Subject:        "Поставка ручного инструмента",
+				Customer: &customer.Customer{
+					ID: "6680005928", Code: "356680005928366801001", INN: "6680005928",
+					Name:     `МУНИЦИПАЛЬНОЕ АВТОНОМНОЕ УЧРЕЖДЕНИЕ "ЦЕНТР ОБЕСПЕЧЕНИЯ"`,
+					OKOPF:    "Муниципальные автономные учреждения",
+					Location: "623700, Свердловская обл, г Березовский, ул Ленина, д. 1",
+				},
+				Suppliers: []*supplier.Supplier{{
+					ID: "6670412345_667001001", Name: `ООО "ИНСТРУМЕНТ-УРАЛ"`,
+					INN: "6670412345", KPP: "667001001", OGRN: "1156670012345",
+					Address: "620014, г Екатеринбург, ул Малышева, д. 51", IsSME: true,
+				}},
+				Services: []*agreement.AgreementService{
+					{
+						Name: "Шуруповерт аккумуляторный", TypeObject: "Товар",
+						Quantity: 5, QuantityType: "Штука", UnitPrice: 12930.75, Currency: "RUB",
+						CountryOfOrigin: "Российская Федерация",
+						OKPD2:           "ОКПД2:28.24.12.190 Инструменты ручные прочие с механизированным приводом, не включенные в другие группировки",
+					},
+					{
+						Name: "Набор бит", TypeObject: "Товар",
+						Quantity: 10, QuantityType: "Набор", UnitPrice: 6465.375, Currency: "RUB",
+					},
+				},
+			},
+		},
+		{
+			name:        "without dates of publication",
+			file:        "second.xml",
+			expectedLen: 1,
+		},
+		{
+			name:      "broken xml",
+			file:      "error.xml",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := opendata.ParseContract(readFile(t, tt.file))
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, result, tt.expectedLen)
+			if tt.expected != nil {
+				assert.Equal(t, tt.expected, result[0])
+			}
+		})
+	}
+
+	t.Run("fallback dates", func(t *testing.T) {
+		result, err := opendata.ParseContract(readFile(t, "second.xml"))
+		assert.NoError(t, err)
+		assert.Equal(t, "Исполнение", result[0].Status)
+		assert.Equal(t, date("2025-05-24"), result[0].PublishedAt)
+		assert.Equal(t, date("2025-05-24"), result[0].UpdatedAt)
+	})
+
+	t.Run("no contract", func(t *testing.T) {
+		_, err := opendata.ParseContract([]byte(`<contract><body></body></contract>`))
+		assert.True(t, errors.Is(err, opendata.ErrNoContract))
+	})
+}
+
+func TestParseArchive(t *testing.T) {
+	var numbers []string
+	failed, err := opendata.ParseArchive(context.Background(), zap.NewNop(), readFile(t, archiveName), func(v *agreement.AgreementParesedData) error {
+		numbers = append(numbers, v.Number)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, []string{"56680005928250002570000", "57705013033250001110000"}, numbers)
+
+	stop := errors.New("stop")
+	_, err = opendata.ParseArchive(context.Background(), zap.NewNop(), readFile(t, archiveName), func(v *agreement.AgreementParesedData) error {
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+
+	_, err = opendata.ParseArchive(context.Background(), zap.NewNop(), []byte("not a zip"), nil)
+	assert.ErrorIs(t, err, opendata.ErrBadArchive)
+}
@@ -0,0 +1,76 @@
+package opendata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/pkg/repository"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ImportedArchive загруженный архив выгрузки. ID — хэш имени: в именах архивов
+// бывают точки и слэши, которые монго принял бы за путь к полю
+type ImportedArchive struct {
+	ID         string    `bson:"_id,omitempty" json:"id"`
+	Name       string    `bson:"name" json:"name"`
+	ImportedAt time.Time `bson:"imported_at" json:"imported_at"`
+	Agreements int       `bson:"agreements" json:"agreements"`           // сохранено в agreements
+	Staged     int       `bson:"staged" json:"staged"`                   // отложено в opendata_agreements
+	Failed     int       `bson:"failed" json:"failed"`                   // пропущено битых файлов
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"` // архив не открылся, договоры из него не загружены
+}
+
+func (t ImportedArchive) GetID() any {
+	return t.ID
+}
+
+// ArchiveID ключ архива в коллекции opendata_archives
+func ArchiveID(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:16])
+}
+
+// StagedAgreement договор из выгрузки, которого еще нет в базе. ID с сайта у него
+// появится только после загрузки с сайта, поэтому он лежит отдельно под реестровым
+// номером и не плодит дубли в agreements, customers
+type StagedAgreement struct {
+	agreement.AgreementParesedData `bson:",inline"`
+	Archive                        string    `bson:"archive" json:"archive"`
+	StagedAt                       time.Time `bson:"staged_at" json:"staged_at"`
+}
+
+func (t StagedAgreement) GetID() any {
+	return t.ID
+}
+
+type ArchiveRepo struct {
+	*repository.GenericRepository[*ImportedArchive]
+}
+
+func (r *ArchiveRepo) BulkMergeMany(ctx context.Context, docs []*ImportedArchive) error {
+	return r.BulkCreateOrUpdateMany(ctx, docs)
+}
+
+func (r *ArchiveRepo) List(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*ImportedArchive, error) {
+	return r.GenericRepository.List(ctx, filter, opts...)
+}
+
+type IArchiveRepo interface {
+	BulkMergeMany(ctx context.Context, docs []*ImportedArchive) error
+	List(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*ImportedArchive, error)
+}
+
+type StagingRepo struct {
+	*repository.GenericRepository[*StagedAgreement]
+}
+
+func (r *StagingRepo) BulkMergeMany(ctx context.Context, docs []*StagedAgreement) error {
+	return r.BulkCreateOrUpdateMany(ctx, docs)
+}
+
+type IStagingRepo interface {
+	BulkMergeMany(ctx context.Context, docs []*StagedAgreement) error
+}
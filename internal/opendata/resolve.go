@@ -0,0 +1,126 @@
+package opendata
+
+import (
+	"context"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ResolveIDs связывает договоры из выгрузки с уже загруженными с сайта.
+// Договор ищется по реестровому номеру и получает ID с сайта, заказчик — ID из
+// сохраненного договора. Поля, которых нет в выгрузке, переносятся из базы, чтобы
+// BulkMergeMany их не затер. Договоры, которых в базе нет, возвращаются в staged:
+// их ID с сайта неизвестен, и сохранять их в agreements нельзя
+func ResolveIDs(
+	ctx context.Context, agreeRepo agreement.IAgreementRepo, custRepo customer.ICustomerRepo,
+	suppRepo supplier.ISupplierRepo, batch []*agreement.AgreementParesedData,
+) (resolved, staged []*agreement.AgreementParesedData, err error) {
+	numbers := make([]string, 0, len(batch))
+	for _, v := range batch {
+		numbers = append(numbers, v.Number)
+	}
+	stored, err := agreeRepo.List(ctx, bson.M{"number": bson.M{"$in": numbers}})
+	if err != nil {
+		return nil, nil, err
+	}
+	byNumber := make(map[string]*agreement.Agreement, len(stored))
+	for _, a := range stored {
+		byNumber[a.Number] = a
+	}
+
+	var custIds, suppIds []string
+	for _, v := range batch {
+		a, ok := byNumber[v.Number]
+		// Без заказчика с сайта договор не связать: ИНН как ID дал бы дубль заказчика
+		if !ok || a.CustomerId == "" {
+			staged = append(staged, v)
+			continue
+		}
+		resolved = append(resolved, v)
+		custIds = append(custIds, a.CustomerId)
+		if len(v.Suppliers) == 0 {
+			suppIds = append(suppIds, a.SupplierIds...)
+		}
+	}
+	if len(resolved) == 0 {
+		return nil, staged, nil
+	}
+	customers, err := custRepo.List(ctx, bson.M{"_id": bson.M{"$in": custIds}})
+	if err != nil {
+		return nil, nil, err
+	}
+	byCustId := make(map[string]*customer.Customer, len(customers))
+	for _, c := range customers {
+		byCustId[c.ID] = c
+	}
+	bySuppId := map[string]*supplier.Supplier{}
+	if len(suppIds) > 0 {
+		suppliers, err := suppRepo.List(ctx, bson.M{"_id": bson.M{"$in": suppIds}})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, s := range suppliers {
+			bySuppId[s.ID] = s
+		}
+	}
+
+	for _, v := range resolved {
+		a := byNumber[v.Number]
+		mergeStored(v, a)
+		v.Customer.ID = a.CustomerId
+		if c, ok := byCustId[a.CustomerId]; ok {
+			fillEmpty(&v.Customer.Code, c.Code)
+			fillEmpty(&v.Customer.Name, c.Name)
+			fillEmpty(&v.Customer.INN, c.INN)
+			fillEmpty(&v.Customer.OKOPF, c.OKOPF)
+			fillEmpty(&v.Customer.MainWork, c.MainWork)
+			fillEmpty(&v.Customer.Location, c.Location)
+		}
+		if len(v.Suppliers) == 0 {
+			for _, id := range a.SupplierIds {
+				if s, ok := bySuppId[id]; ok {
+					v.Suppliers = append(v.Suppliers, s)
+				}
+			}
+		}
+	}
+	return resolved, staged, nil
+}
+
+// mergeStored переносит в договор из выгрузки ID с сайта и поля, которых в выгрузке нет
+func mergeStored(v *agreement.AgreementParesedData, a *agreement.Agreement) {
+	v.ID = a.ID
+	fillEmpty(&v.Law, a.Law)
+	fillEmpty(&v.Status, a.Status)
+	fillEmpty(&v.Pfid, a.Pfid)
+	fillEmpty(&v.NoticeId, a.NoticeId)
+	fillEmpty(&v.PurchaseMethod, a.PurchaseMethod)
+	fillEmpty(&v.Subject, a.Subject)
+	if v.Price == 0 {
+		v.Price = a.Price
+	}
+	fillZero(&v.SignedAt, a.SignedAt)
+	fillZero(&v.ExecutionStart, a.ExecutionStart)
+	fillZero(&v.ExecutionEnd, a.ExecutionEnd)
+	fillZero(&v.PublishedAt, a.PublishedAt)
+	fillZero(&v.UpdatedAt, a.UpdatedAt)
+	if len(v.Services) == 0 {
+		v.Services = a.Services
+	}
+}
+
+func fillEmpty(dst *string, src string) {
+	if *dst == "" {
+		*dst = src
+	}
+}
+
+func fillZero(dst *time.Time, src time.Time) {
+	if dst.IsZero() {
+		*dst = src
+	}
+}
@@ -0,0 +1,81 @@
+package opendata_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/tim8842/tender-data-loader/internal/opendata"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestResolveIDs(t *testing.T) {
+	ctx := context.Background()
+	signed := time.Date(2025, 5, 20, 0, 0, 0, 0, time.UTC)
+	batch := []*agreement.AgreementParesedData{
+		{ID: "N1", Number: "N1", Law: agreement.LawFZ223, Price: 100,
+			Customer: &customer.Customer{ID: "7705013033", INN: "7705013033", Name: "Из выгрузки"}},
+		{ID: "N2", Number: "N2", Status: "Исполнение", Customer: &customer.Customer{ID: "6680005928", INN: "6680005928"}},
+		{ID: "N3", Number: "N3", Customer: &customer.Customer{ID: "6680005928", INN: "6680005928"}},
+	}
+	services := []*agreement.AgreementService{{Name: "Услуга"}}
+	agreeRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+	agreeRepo.On("List", mock.Anything, bson.M{"number": bson.M{"$in": []string{"N1", "N2", "N3"}}}, mock.Anything).
+		Return([]*agreement.Agreement{
+			{ID: "site-1", Number: "N1", Pfid: "pf", NoticeId: "77", Status: "Исполнение завершено", PurchaseMethod: "Аукцион",
+				Subject: "Поставка", Price: 90, SignedAt: signed, CustomerId: "492275", SupplierIds: []string{"s1", "s2"}, Services: services},
+			// Договор без заказчика с сайта не связать
+			{ID: "site-3", Number: "N3"},
+		}, nil)
+	custRepo := new(inmock.MockGenericRepository[*customer.Customer])
+	custRepo.On("List", mock.Anything, bson.M{"_id": bson.M{"$in": []string{"492275"}}}, mock.Anything).
+		Return([]*customer.Customer{{ID: "492275", INN: "7705013033", Name: "С сайта", MainWork: "36.00"}}, nil)
+	suppRepo := new(inmock.MockGenericRepository[*supplier.Supplier])
+	suppRepo.On("List", mock.Anything, bson.M{"_id": bson.M{"$in": []string{"s1", "s2"}}}, mock.Anything).
+		Return([]*supplier.Supplier{{ID: "s1", Name: "Поставщик"}}, nil)
+
+	resolved, staged, err := opendata.ResolveIDs(ctx, agreeRepo, custRepo, suppRepo, batch)
+	assert.NoError(t, err)
+
+	// Найденный договор получает ID с сайта и поля, которых нет в выгрузке
+	assert.Len(t, resolved, 1)
+	a := resolved[0]
+	assert.Equal(t, "site-1", a.ID)
+	assert.Equal(t, "pf", a.Pfid)
+	assert.Equal(t, "77", a.NoticeId)
+	assert.Equal(t, "Исполнение завершено", a.Status)
+	assert.Equal(t, "Аукцион", a.PurchaseMethod)
+	assert.Equal(t, "Поставка", a.Subject)
+	assert.Equal(t, 100.0, a.Price)
+	assert.Equal(t, signed, a.SignedAt)
+	assert.Equal(t, services, a.Services)
+	assert.Equal(t, []*supplier.Supplier{{ID: "s1", Name: "Поставщик"}}, a.Suppliers)
+	assert.Equal(t, &customer.Customer{ID: "492275", INN: "7705013033", Name: "Из выгрузки", MainWork: "36.00"}, a.Customer)
+	// Остальные откладываются с реестровым номером и ИНН
+	assert.Len(t, staged, 2)
+	assert.Equal(t, "N2", staged[0].ID)
+	assert.Equal(t, "6680005928", staged[0].Customer.ID)
+	assert.Equal(t, "N3", staged[1].ID)
+	custRepo.AssertExpectations(t)
+	suppRepo.AssertExpectations(t)
+
+	failRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+	failRepo.On("List", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+	_, _, err = opendata.ResolveIDs(ctx, failRepo, custRepo, suppRepo, batch)
+	assert.Error(t, err)
+}
+
+func TestArchiveID(t *testing.T) {
+	id := opendata.ArchiveID("Moskva/contract_Moskva_20250523_000000_20250523_235959_daily_001.xml.zip")
+	assert.Len(t, id, 32)
+	assert.NotContains(t, id, ".")
+	assert.Equal(t, id, opendata.ArchiveID("Moskva/contract_Moskva_20250523_000000_20250523_235959_daily_001.xml.zip"))
+	assert.NotEqual(t, id, opendata.ArchiveID("Moskva/contract_Moskva_20250524_000000_20250524_235959_daily_001.xml.zip"))
+}
@@ -0,0 +1,120 @@
+package reparse
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tim8842/tender-data-loader/pkg/archive"
+)
+
+var errStoreRootFound = errors.New("store root found")
+
+// OpenStore открывает архив страниц: каталог в формате archive.DiskStore
+// или tar (.tar, .tar.gz, .tgz) с таким каталогом. cleanup удаляет распакованные файлы
+func OpenStore(path string) (store archive.Store, cleanup func(), err error) {
+	cleanup = func() {}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, cleanup, err
+	}
+	dir := path
+	if !info.IsDir() {
+		dir, err = os.MkdirTemp("", "reparse-*")
+		if err != nil {
+			return nil, cleanup, err
+		}
+		cleanup = func() { os.RemoveAll(dir) }
+		if err := extractTar(path, dir); err != nil {
+			cleanup()
+			return nil, func() {}, err
+		}
+	}
+	root, err := findStoreRoot(dir)
+	if err != nil {
+		cleanup()
+		return nil, func() {}, err
+	}
+	store, err = archive.NewDiskStore(root)
+	if err != nil {
+		cleanup()
+		return nil, func() {}, err
+	}
+	return store, cleanup, nil
+}
+
+// findStoreRoot в tar каталог архива может лежать не в корне: ищем первый файл метаданных,
+// корень — на два уровня выше (<root>/<xx>/<key>.json)
+func findStoreRoot(dir string) (string, error) {
+	var root string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".json") {
+			root = filepath.Dir(filepath.Dir(path))
+			return errStoreRootFound
+		}
+		return nil
+	})
+	if errors.Is(err, errStoreRootFound) {
+		return root, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no archived pages in %s", dir)
+}
+
+func extractTar(path string, dir string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") || strings.HasSuffix(path, ".tgz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.Clean("/"+hdr.Name))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			out, err := os.Create(target)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(out, tr)
+			out.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
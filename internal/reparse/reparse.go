@@ -0,0 +1,237 @@
+package reparse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/config"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	"github.com/tim8842/tender-data-loader/pkg/archive"
+	"github.com/tim8842/tender-data-loader/pkg/diff"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+const DefaultBatchSize = 50
+
+// errIncomplete в архиве нет печатной формы или карточки заказчика.
+// Такие договоры не сохраняем: BulkMergeMany заменяет документ целиком и затер бы поля
+var errIncomplete = errors.New("incomplete archive")
+
+// errForeignURL адрес карточки не начинается с адреса из конфигурации (другой
+// протокол, хост или путь): ID из него не выделить, а целый адрес в _id дал бы дубль
+var errForeignURL = errors.New("contract url does not match UrlZakupkiAgreementGetAgreegmentWeb")
+
+// Summary итог перепарсинга
+type Summary struct {
+	Parsed     int            // договоров разобрано
+	Skipped    int            // карточек без договора (парсер вернул nil)
+	Incomplete int            // не хватило страниц в архиве
+	Failed     int            // ошибки парсинга
+	New        int            // договоров нет в базе
+	Unchanged  int            // совпали с базой
+	Changed    int            // отличаются от базы
+	Fields     map[string]int // сколько раз изменилось поле, "customer." — поля заказчика
+}
+
+func (s *Summary) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "parsed: %d, skipped: %d, incomplete: %d, failed: %d\n", s.Parsed, s.Skipped, s.Incomplete, s.Failed)
+	fmt.Fprintf(&b, "new: %d, changed: %d, unchanged: %d\n", s.New, s.Changed, s.Unchanged)
+	fields := make([]string, 0, len(s.Fields))
+	for f := range s.Fields {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	for _, f := range fields {
+		fmt.Fprintf(&b, "  %-24s %d\n", f, s.Fields[f])
+	}
+	return b.String()
+}
+
+// Reparser прогоняет сохраненные страницы через текущие парсеры, в сеть не ходит
+type Reparser struct {
+	cfg       *config.Config
+	store     archive.Store
+	agreeRepo agreement.IAgreementRepo
+	custRepo  customer.ICustomerRepo
+	suppRepo  supplier.ISupplierRepo
+	batchSize int
+	dryRun    bool
+}
+
+func NewReparser(
+	cfg *config.Config, store archive.Store,
+	agreeRepo agreement.IAgreementRepo, custRepo customer.ICustomerRepo,
+	suppRepo supplier.ISupplierRepo, batchSize int, dryRun bool,
+) *Reparser {
+	if batchSize < 1 {
+		batchSize = DefaultBatchSize
+	}
+	return &Reparser{
+		cfg: cfg, store: store, agreeRepo: agreeRepo, custRepo: custRepo,
+		suppRepo: suppRepo, batchSize: batchSize, dryRun: dryRun,
+	}
+}
+
+// Run обходит карточки договоров в архиве. В dry-run только сравнивает с базой,
+// иначе сохраняет пачками через BulkMergeMany
+func (r *Reparser) Run(ctx context.Context, logger *zap.Logger) (*Summary, error) {
+	summary := &Summary{Fields: map[string]int{}}
+	var batch []*agreement.AgreementParesedData
+	err := r.store.Walk(ctx, archive.KindContract, func(page *archive.Page) error {
+		data, err := r.parseContract(ctx, logger, page)
+		switch {
+		case errors.Is(err, errIncomplete):
+			logger.Warn("Не хватает страниц в архиве", zap.String("url", page.URL), zap.Error(err))
+			summary.Incomplete++
+			return nil
+		case err != nil:
+			logger.Error("Ошибка парсинга", zap.String("url", page.URL), zap.Error(err))
+			summary.Failed++
+			return nil
+		case data == nil:
+			summary.Skipped++
+			return nil
+		}
+		summary.Parsed++
+		batch = append(batch, data)
+		if len(batch) >= r.batchSize {
+			if err := r.flush(ctx, batch, summary); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+		return nil
+	})
+	if err != nil {
+		return summary, err
+	}
+	return summary, r.flush(ctx, batch, summary)
+}
+
+// parseContract собирает договор из карточки, печатной формы и карточки заказчика
+func (r *Reparser) parseContract(ctx context.Context, logger *zap.Logger, page *archive.Page) (*agreement.AgreementParesedData, error) {
+	id, ok := strings.CutPrefix(page.URL, r.cfg.UrlZakupkiAgreementGetAgreegmentWeb)
+	if !ok || id == "" {
+		return nil, fmt.Errorf("%w: %s", errForeignURL, page.URL)
+	}
+	tmp, err := agreement.ParseAgreementFromMain(ctx, logger, page.Body)
+	if err != nil || tmp == nil {
+		return nil, err
+	}
+	data, ok := tmp.(*agreement.AgreementParesedData)
+	if !ok {
+		return nil, errors.New("parse error model.AgreementParesedData")
+	}
+	data.ID = id
+	if data.Pfid != "" {
+		show, err := r.load(ctx, r.cfg.UrlZakupkiAgreementGetAgreegmentShowHtml+data.Pfid)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := agreement.ParseAgreementFromHtml(ctx, logger, show.Body, data); err != nil {
+			return nil, err
+		}
+	}
+	cust, err := r.load(ctx, r.cfg.UrlZakupkiAgreementGetCustomerWeb+data.Customer.ID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := agreement.ParseCustomerFromMain(ctx, logger, cust.Body, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (r *Reparser) load(ctx context.Context, url string) (*archive.Page, error) {
+	page, err := r.store.Load(ctx, url)
+	if errors.Is(err, archive.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", errIncomplete, url)
+	}
+	return page, err
+}
+
+func (r *Reparser) flush(ctx context.Context, batch []*agreement.AgreementParesedData, summary *Summary) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	agreements := make([]*agreement.Agreement, 0, len(batch))
+	customers := make([]*customer.Customer, 0, len(batch))
+	var suppliers []*supplier.Supplier
+	for _, v := range batch {
+		a, c, s := agreement.ParseAgreementDataToModels(v)
+		agreements = append(agreements, a)
+		customers = append(customers, c)
+		suppliers = supplier.Merge(suppliers, s...)
+	}
+	if err := r.compare(ctx, agreements, customers, summary); err != nil {
+		return err
+	}
+	if r.dryRun {
+		return nil
+	}
+	if err := r.agreeRepo.BulkMergeMany(ctx, agreements); err != nil {
+		return err
+	}
+	if err := r.custRepo.BulkMergeMany(ctx, customers); err != nil {
+		return err
+	}
+	return r.suppRepo.BulkMergeMany(ctx, suppliers)
+}
+
+// compare считает, какие поля поменяются относительно базы
+func (r *Reparser) compare(ctx context.Context, agreements []*agreement.Agreement, customers []*customer.Customer, summary *Summary) error {
+	agreeIds := make([]string, len(agreements))
+	for i, a := range agreements {
+		agreeIds[i] = a.ID
+	}
+	oldAgreements, err := r.agreeRepo.List(ctx, bson.M{"_id": bson.M{"$in": agreeIds}})
+	if err != nil {
+		return err
+	}
+	oldAgreeById := make(map[string]*agreement.Agreement, len(oldAgreements))
+	for _, a := range oldAgreements {
+		oldAgreeById[a.ID] = a
+	}
+	custIds := make([]string, len(customers))
+	for i, c := range customers {
+		custIds[i] = c.ID
+	}
+	oldCustomers, err := r.custRepo.List(ctx, bson.M{"_id": bson.M{"$in": custIds}})
+	if err != nil {
+		return err
+	}
+	oldCustById := make(map[string]*customer.Customer, len(oldCustomers))
+	for _, c := range oldCustomers {
+		oldCustById[c.ID] = c
+	}
+
+	for i, a := range agreements {
+		old, ok := oldAgreeById[a.ID]
+		if !ok {
+			summary.New++
+			continue
+		}
+		fields := diff.Fields(old, a)
+		if oldCust, ok := oldCustById[customers[i].ID]; ok {
+			for _, f := range diff.Fields(oldCust, customers[i]) {
+				fields = append(fields, "customer."+f)
+			}
+		}
+		if len(fields) == 0 {
+			summary.Unchanged++
+			continue
+		}
+		summary.Changed++
+		for _, f := range fields {
+			summary.Fields[f]++
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package reparse_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/config"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/tim8842/tender-data-loader/internal/reparse"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	"github.com/tim8842/tender-data-loader/pkg/archive"
+	"github.com/tim8842/tender-data-loader/pkg/reader"
+	"go.uber.org/zap"
+)
+
+const assets = "../../assets/test"
+
+var testCfg = &config.Config{
+	UrlZakupkiAgreementGetAgreegmentWeb:      "web/",
+	UrlZakupkiAgreementGetAgreegmentShowHtml: "show/",
+	UrlZakupkiAgreementGetCustomerWeb:        "cust/",
+}
+
+// newArchive складывает в архив договор 1 со всеми страницами и карточку 3 без извещения
+func newArchive(t *testing.T, dir string) archive.Store {
+	ctx := context.Background()
+	store, err := archive.NewDiskStore(dir)
+	assert.NoError(t, err)
+	now := time.Now()
+	pages := []*archive.Page{
+		archive.NewPage("web/1", archive.KindContract, reader.ReadHtmlFile(assets+"/ParseAgreementFromMain/correct.html"), now),
+		archive.NewPage("show/67051964", archive.KindPrintForm, reader.ReadHtmlFile(assets+"/ParseAgreementFromHtml/correctNew2024.html"), now),
+		archive.NewPage("cust/492275", archive.KindCustomer, reader.ReadHtmlFile(assets+"/ParseCustomerFromMain/correctTwoMainWork.html"), now),
+		archive.NewPage("web/3", archive.KindContract, reader.ReadHtmlFile(assets+"/ParseAgreementFromMain/error.html"), now),
+	}
+	for _, p := range pages {
+		assert.NoError(t, store.Save(ctx, p))
+	}
+	return store
+}
+
+func TestReparser_Run(t *testing.T) {
+	store := newArchive(t, t.TempDir())
+	ctx := context.Background()
+
+	t.Run("dry run", func(t *testing.T) {
+		agreeRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+		custRepo := new(inmock.MockGenericRepository[*customer.Customer])
+		suppRepo := new(inmock.MockGenericRepository[*supplier.Supplier])
+		old := &agreement.Agreement{ID: "1", Status: "Исполнение", Price: 1, CustomerId: "492275"}
+		agreeRepo.On("List", mock.Anything, mock.Anything, mock.Anything).Return([]*agreement.Agreement{old}, nil)
+		custRepo.On("List", mock.Anything, mock.Anything, mock.Anything).Return([]*customer.Customer{{ID: "492275"}}, nil)
+
+		summary, err := reparse.NewReparser(testCfg, store, agreeRepo, custRepo, suppRepo, 10, true).Run(ctx, zap.NewNop())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, summary.Parsed)
+		assert.Equal(t, 1, summary.Skipped)
+		assert.Equal(t, 1, summary.Changed)
+		assert.Equal(t, 1, summary.Fields["status"])
+		assert.Equal(t, 1, summary.Fields["price"])
+		assert.Equal(t, 1, summary.Fields["customer.name"])
+		assert.NotContains(t, summary.Fields, "customer_id")
+		agreeRepo.AssertNotCalled(t, "BulkMergeMany", mock.Anything, mock.Anything)
+		custRepo.AssertNotCalled(t, "BulkMergeMany", mock.Anything, mock.Anything)
+		suppRepo.AssertNotCalled(t, "BulkMergeMany", mock.Anything, mock.Anything)
+	})
+
+	t.Run("save", func(t *testing.T) {
+		agreeRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+		custRepo := new(inmock.MockGenericRepository[*customer.Customer])
+		suppRepo := new(inmock.MockGenericRepository[*supplier.Supplier])
+		agreeRepo.On("List", mock.Anything, mock.Anything, mock.Anything).Return([]*agreement.Agreement{}, nil)
+		custRepo.On("List", mock.Anything, mock.Anything, mock.Anything).Return([]*customer.Customer{}, nil)
+		agreeRepo.On("BulkMergeMany", mock.Anything, mock.MatchedBy(func(docs []*agreement.Agreement) bool {
+			return len(docs) == 1 && docs[0].ID == "1" && docs[0].CustomerId == "492275"
+		})).Return(nil).Once()
+		custRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil).Once()
+		suppRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil).Once()
+
+		summary, err := reparse.NewReparser(testCfg, store, agreeRepo, custRepo, suppRepo, 10, false).Run(ctx, zap.NewNop())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, summary.New)
+		agreeRepo.AssertExpectations(t)
+		custRepo.AssertExpectations(t)
+		suppRepo.AssertExpectations(t)
+	})
+}
+
+// Карточка с адресом не из конфигурации не сохраняется с адресом вместо ID
+func TestReparser_ForeignURL(t *testing.T) {
+	ctx := context.Background()
+	store, err := archive.NewDiskStore(t.TempDir())
+	assert.NoError(t, err)
+	main := reader.ReadHtmlFile(assets + "/ParseAgreementFromMain/correct.html")
+	assert.NoError(t, store.Save(ctx, archive.NewPage("http://old-host/web/1", archive.KindContract, main, time.Now())))
+
+	agreeRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+	custRepo := new(inmock.MockGenericRepository[*customer.Customer])
+	suppRepo := new(inmock.MockGenericRepository[*supplier.Supplier])
+	summary, err := reparse.NewReparser(testCfg, store, agreeRepo, custRepo, suppRepo, 10, false).Run(ctx, zap.NewNop())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.Failed)
+	assert.Equal(t, 0, summary.Parsed)
+	agreeRepo.AssertNotCalled(t, "BulkMergeMany", mock.Anything, mock.Anything)
+}
+
+func TestReparser_Incomplete(t *testing.T) {
+	ctx := context.Background()
+	store, err := archive.NewDiskStore(t.TempDir())
+	assert.NoError(t, err)
+	main := reader.ReadHtmlFile(assets + "/ParseAgreementFromMain/correct.html")
+	assert.NoError(t, store.Save(ctx, archive.NewPage("web/1", archive.KindContract, main, time.Now())))
+
+	agreeRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+	custRepo := new(inmock.MockGenericRepository[*customer.Customer])
+	suppRepo := new(inmock.MockGenericRepository[*supplier.Supplier])
+	summary, err := reparse.NewReparser(testCfg, store, agreeRepo, custRepo, suppRepo, 10, false).Run(ctx, zap.NewNop())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.Incomplete)
+	assert.Equal(t, 0, summary.Parsed)
+	agreeRepo.AssertNotCalled(t, "BulkMergeMany", mock.Anything, mock.Anything)
+}
+
+func TestOpenStore_Tar(t *testing.T) {
+	src := t.TempDir()
+	newArchive(t, src)
+
+	tarPath := filepath.Join(t.TempDir(), "pages.tar.gz")
+	f, err := os.Create(tarPath)
+	assert.NoError(t, err)
+	zw := gzip.NewWriter(f)
+	tw := tar.NewWriter(zw)
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		// каталог архива внутри tar лежит в подкаталоге
+		hdr := &tar.Header{Name: "archive/" + rel, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, f.Close())
+
+	store, cleanup, err := reparse.OpenStore(tarPath)
+	assert.NoError(t, err)
+	defer cleanup()
+	page, err := store.Load(context.Background(), "cust/492275")
+	assert.NoError(t, err)
+	assert.Equal(t, archive.KindCustomer, page.Kind)
+}
@@ -0,0 +1,112 @@
+package search
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/pkg/parser"
+	"github.com/tim8842/tender-data-loader/pkg/urltpl"
+)
+
+// Подстановки, которые можно использовать в шаблонах адресов поиска
+const (
+	From      = "from"      // начало периода, дд.мм.гггг
+	To        = "to"        // конец периода
+	DateField = "dateField" // префикс параметров периода на zakupki: contractDate, publishDate...
+	Statuses  = "statuses"  // этапы исполнения, параметр повторяется для каждого
+	Sort      = "sort"      // поле сортировки
+	Asc       = "asc"       // true — по возрастанию
+	PerPage   = "perPage"   // записей на странице
+	Regions   = "regions"   // коды регионов заказчика, параметр повторяется для каждого
+	PriceFrom = "priceFrom" // цена от, рубли
+	PriceTo   = "priceTo"   // цена до
+	Page      = "page"      // номер страницы с 1
+)
+
+var placeholders = []string{From, To, DateField, Statuses, Sort, Asc, PerPage, Regions, PriceFrom, PriceTo, Page}
+
+// Поля даты, по которым фильтруется период поиска
+const (
+	DateSigned    = "contractDate" // дата заключения
+	DatePublished = "publishDate"  // дата размещения
+	DateUpdated   = "updateDate"   // дата обновления
+)
+
+// Сортировка результатов поиска. Постранично обходить выдачу можно только по полю,
+// которое не меняется, пока идет обход, иначе записи переезжают между страницами
+const (
+	SortUpdateDate = "BY_UPDATE_DATE"
+	SortSignedDate = "BY_CONTRACT_DATE"
+	SortPrice      = "PRICE"
+)
+
+// Params фильтры поиска. Нулевые значения не попадают в адрес: параметр шаблона выкидывается
+type Params struct {
+	From      time.Time
+	To        time.Time
+	DateField string
+	Statuses  []string
+	Sort      string
+	Asc       bool
+	PerPage   int
+	Regions   []string
+	PriceFrom float64
+	PriceTo   float64
+	Page      int
+}
+
+// Day поиск по одному дню
+func (p Params) Day(day time.Time, page int) Params {
+	p.From, p.To, p.Page = day, day, page
+	return p
+}
+
+// Values значения подстановок шаблона
+func (p Params) Values() map[string][]string {
+	values := map[string][]string{
+		DateField: {p.DateField},
+		Statuses:  p.Statuses,
+		Sort:      {p.Sort},
+		Asc:       {strconv.FormatBool(p.Asc)},
+		Regions:   p.Regions,
+	}
+	if !p.From.IsZero() {
+		values[From] = []string{parser.FromTimeToDate(p.From)}
+	}
+	if !p.To.IsZero() {
+		values[To] = []string{parser.FromTimeToDate(p.To)}
+	}
+	if p.PerPage > 0 {
+		values[PerPage] = []string{strconv.Itoa(p.PerPage)}
+	}
+	if p.PriceFrom > 0 {
+		values[PriceFrom] = []string{strconv.FormatFloat(p.PriceFrom, 'f', -1, 64)}
+	}
+	if p.PriceTo > 0 {
+		values[PriceTo] = []string{strconv.FormatFloat(p.PriceTo, 'f', -1, 64)}
+	}
+	if p.Page > 0 {
+		values[Page] = []string{strconv.Itoa(p.Page)}
+	}
+	return values
+}
+
+// Parse разбирает шаблон адреса поиска, допускаются только подстановки этого пакета
+func Parse(raw string) (*urltpl.Template, error) {
+	return urltpl.Parse(raw, placeholders...)
+}
+
+// URL адрес страницы поиска по шаблону
+func URL(raw string, p Params) (string, error) {
+	tpl, err := Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	return tpl.Expand(p.Values())
+}
+
+// FromFragments шаблон из старых фрагментов адреса, между которыми подставлялись
+// даты и номер страницы. Адреса получаются те же, что и при склейке
+func FromFragments(first, second, third, fourth string) string {
+	return first + "{{" + From + "}}" + second + "{{" + To + "}}" + third + "{{" + Page + "}}" + fourth
+}
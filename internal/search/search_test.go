@@ -0,0 +1,65 @@
+package search_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/internal/search"
+)
+
+const template = "https://zakupki.gov.ru/epz/contract/search/results.html?fz223=on" +
+	"&contractStageList={{statuses}}&{{dateField}}From={{from}}&{{dateField}}To={{to}}" +
+	"&sortBy={{sort}}&sortDirection={{asc}}&pageNumber={{page}}&recordsPerPage=_{{perPage}}" +
+	"&priceFromGeneral={{priceFrom}}&priceToGeneral={{priceTo}}&customerPlace={{regions}}"
+
+func TestURL(t *testing.T) {
+	day := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		params   search.Params
+		expected string
+	}{
+		{
+			name:   "day with filters",
+			params: search.Params{DateField: search.DateSigned, Statuses: []string{"0", "1"}, Sort: search.SortUpdateDate, PerPage: 50, Regions: []string{"5277335"}, PriceFrom: 1000.5}.Day(day, 2),
+			expected: "https://zakupki.gov.ru/epz/contract/search/results.html?fz223=on" +
+				"&contractStageList=0&contractStageList=1&contractDateFrom=01.02.2024&contractDateTo=01.02.2024" +
+				"&sortBy=BY_UPDATE_DATE&sortDirection=false&pageNumber=2&recordsPerPage=_50" +
+				"&priceFromGeneral=1000.5&customerPlace=5277335",
+		},
+		{
+			name:   "no period",
+			params: search.Params{DateField: search.DateSigned, Sort: search.SortPrice, Asc: true, Page: 1},
+			expected: "https://zakupki.gov.ru/epz/contract/search/results.html?fz223=on" +
+				"&sortBy=PRICE&sortDirection=true&pageNumber=1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, err := search.URL(template, tt.params)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, url)
+		})
+	}
+}
+
+// Шаблон из фрагментов дает тот же адрес, что и склейка: по нему ищутся страницы в архиве
+func TestFromFragments(t *testing.T) {
+	first := "https://zakupki.gov.ru/epz/contract/search/results.html?fz223=on&contractDateFrom="
+	second := "&contractDateTo="
+	third := "&sortBy=BY_UPDATE_DATE&pageNumber="
+	fourth := "&sortDirection=false&recordsPerPage=_50"
+	day := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	url, err := search.URL(search.FromFragments(first, second, third, fourth), search.Params{}.Day(day, 7))
+	assert.NoError(t, err)
+	assert.Equal(t, first+"01.02.2024"+second+"01.02.2024"+third+"7"+fourth, url)
+}
+
+func TestParse(t *testing.T) {
+	_, err := search.Parse("https://example.com/?page={{pageNumber}}")
+	assert.ErrorContains(t, err, "unknown placeholder")
+	_, err = search.Parse(template)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,105 @@
+package shard
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/pkg/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ShardRepo шарды в коллекции variables. Аренда берется и продлевается атомарными
+// обновлениями с условием на владельца, поэтому шард не грузят два воркера сразу
+type ShardRepo struct {
+	*repository.GenericRepository[*Shard]
+}
+
+type IShardRepo interface {
+	Ensure(ctx context.Context, shards []*Shard) error
+	Acquire(ctx context.Context, task, owner string, now time.Time, lease time.Duration) (*Shard, error)
+	Heartbeat(ctx context.Context, id, owner string, until time.Time) error
+	Save(ctx context.Context, s *Shard, owner string, until time.Time) error
+	Release(ctx context.Context, id, owner string) error
+	List(ctx context.Context, task string) ([]*Shard, error)
+}
+
+// Ensure создает недостающие шарды, уже существующие не меняются
+func (r *ShardRepo) Ensure(ctx context.Context, shards []*Shard) error {
+	if len(shards) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(shards))
+	for _, s := range shards {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": s.ID}).
+			SetUpdate(bson.M{"$setOnInsert": bson.M{"vars": s.Vars}}).
+			SetUpsert(true))
+	}
+	_, err := r.ReturnCollection().BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// Acquire берет в аренду самый ранний незагруженный шард задачи, у которого аренда
+// истекла или уже принадлежит owner. Шард, догнавший сегодняшний день, не выдается
+func (r *ShardRepo) Acquire(ctx context.Context, task, owner string, now time.Time, lease time.Duration) (*Shard, error) {
+	filter := bson.M{
+		"vars.task":      task,
+		"vars.done":      false,
+		"vars.signed_at": bson.M{"$lt": day(now)},
+		"$or": bson.A{
+			bson.M{"vars.lease_until": bson.M{"$lt": now}},
+			bson.M{"vars.owner": owner},
+		},
+	}
+	update := bson.M{"$set": bson.M{"vars.owner": owner, "vars.lease_until": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "vars.from", Value: 1}}).SetReturnDocument(options.After)
+	var s Shard
+	err := r.ReturnCollection().FindOneAndUpdate(ctx, filter, update, opts).Decode(&s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNoShard
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Heartbeat продлевает аренду до until
+func (r *ShardRepo) Heartbeat(ctx context.Context, id, owner string, until time.Time) error {
+	return r.updateOwned(ctx, id, owner, bson.M{"vars.lease_until": until})
+}
+
+// Save сохраняет курсор шарда и продлевает аренду
+func (r *ShardRepo) Save(ctx context.Context, s *Shard, owner string, until time.Time) error {
+	return r.updateOwned(ctx, s.ID, owner, bson.M{
+		"vars.signed_at":   s.Vars.SignedAt,
+		"vars.page":        s.Vars.Page,
+		"vars.done":        s.Vars.Done,
+		"vars.updated_at":  s.Vars.UpdatedAt,
+		"vars.lease_until": until,
+	})
+}
+
+// Release отдает шард, чтобы его мог взять другой воркер не дожидаясь конца аренды
+func (r *ShardRepo) Release(ctx context.Context, id, owner string) error {
+	return r.updateOwned(ctx, id, owner, bson.M{"vars.owner": "", "vars.lease_until": time.Time{}})
+}
+
+func (r *ShardRepo) updateOwned(ctx context.Context, id, owner string, set bson.M) error {
+	res, err := r.ReturnCollection().UpdateOne(ctx, bson.M{"_id": id, "vars.owner": owner}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// List шарды задачи по порядку дат
+func (r *ShardRepo) List(ctx context.Context, task string) ([]*Shard, error) {
+	return r.GenericRepository.List(ctx, bson.M{"vars.task": task}, options.Find().SetSort(bson.D{{Key: "vars.from", Value: 1}}))
+}
@@ -0,0 +1,163 @@
+package shard
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+var (
+	// ErrNoShard свободных шардов нет: все загружены, догнали сегодняшний день или в аренде
+	ErrNoShard = errors.New("no free shard")
+	// ErrLeaseLost аренду шарда забрал другой воркер, курсор сохранять нельзя
+	ErrLeaseLost = errors.New("shard lease lost")
+)
+
+// Shard диапазон дат догрузки со своим курсором. Хранится в variables рядом с курсорами
+// задач, _id — задача:гггг-мм
+type Shard struct {
+	ID   string `bson:"_id" json:"id"`
+	Vars Vars   `bson:"vars" json:"vars"`
+}
+
+type Vars struct {
+	Task       string    `bson:"task" json:"task"`
+	From       time.Time `bson:"from" json:"from"`
+	To         time.Time `bson:"to" json:"to"`               // последний день шарда включительно
+	SignedAt   time.Time `bson:"signed_at" json:"signed_at"` // день, который сейчас загружается
+	Page       int       `bson:"page" json:"page"`
+	Done       bool      `bson:"done" json:"done"`
+	Owner      string    `bson:"owner" json:"owner"` // воркер, который держит аренду
+	LeaseUntil time.Time `bson:"lease_until" json:"lease_until"`
+	UpdatedAt  time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+func (s *Shard) GetID() any {
+	return s.ID
+}
+
+// Plan делит период from..to на шарды по календарным месяцам. Курсор каждого шарда
+// стоит на его первом дне, кроме шардов, которые уже загружены до cursor
+// последовательной догрузкой: они начинаются с cursor или сразу помечены загруженными
+func Plan(task string, from, to, cursor time.Time) []*Shard {
+	from, to, cursor = day(from), day(to), day(cursor)
+	var shards []*Shard
+	for start := from; !start.After(to); {
+		end := time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+		s := &Shard{
+			ID:   fmt.Sprintf("%s:%s", task, start.Format("2006-01")),
+			Vars: Vars{Task: task, From: start, To: end, SignedAt: start, Page: 1},
+		}
+		if cursor.After(start) {
+			s.Vars.SignedAt = cursor
+			s.Vars.Done = cursor.After(end)
+			if s.Vars.Done {
+				s.Vars.SignedAt = end.AddDate(0, 0, 1)
+			}
+		}
+		shards = append(shards, s)
+		start = end.AddDate(0, 0, 1)
+	}
+	return shards
+}
+
+// Cursor курсор последовательной догрузки по шардам: день и страница самого раннего
+// незагруженного шарда, а если загружены все — день после последнего. Дни до курсора
+// загружены во всех шардах. shards должны идти по порядку дат, как их отдает List
+func Cursor(shards []*Shard) (Vars, bool) {
+	if len(shards) == 0 {
+		return Vars{}, false
+	}
+	for _, s := range shards {
+		if !s.Vars.Done {
+			return s.Vars, true
+		}
+	}
+	return shards[len(shards)-1].Vars, true
+}
+
+// Progress доля загруженных дней шарда
+func (v Vars) Progress() float64 {
+	if v.Done {
+		return 1
+	}
+	total := v.To.Sub(v.From).Hours()/24 + 1
+	loaded := v.SignedAt.Sub(v.From).Hours() / 24
+	return round(math.Max(0, math.Min(1, loaded/total)))
+}
+
+// Leased держит ли шард кто-то на момент now
+func (v Vars) Leased(now time.Time) bool {
+	return v.Owner != "" && v.LeaseUntil.After(now)
+}
+
+// Status состояние шарда для отчета
+type Status struct {
+	ID         string     `json:"id"`
+	From       time.Time  `json:"from"`
+	To         time.Time  `json:"to"`
+	SignedAt   time.Time  `json:"signed_at"`
+	Page       int        `json:"page"`
+	Done       bool       `json:"done"`
+	Progress   float64    `json:"progress"`
+	Owner      string     `json:"owner,omitempty"` // только у действующей аренды
+	LeaseUntil *time.Time `json:"lease_until,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Report сводка по шардам задачи. Progress — доля загруженных дней по всем шардам
+type Report struct {
+	Task     string    `json:"task"`
+	Total    int       `json:"total"`
+	Done     int       `json:"done"`
+	Leased   int       `json:"leased"`
+	Progress float64   `json:"progress"`
+	Shards   []*Status `json:"shards"`
+}
+
+// NewReport собирает отчет по шардам, now нужен, чтобы отличить истекшую аренду
+func NewReport(task string, shards []*Shard, now time.Time) Report {
+	report := Report{Task: task, Total: len(shards), Shards: make([]*Status, 0, len(shards))}
+	var days, loaded float64
+	for _, s := range shards {
+		st := &Status{
+			ID: s.ID, From: s.Vars.From, To: s.Vars.To, SignedAt: s.Vars.SignedAt, Page: s.Vars.Page,
+			Done: s.Vars.Done, Progress: s.Vars.Progress(), UpdatedAt: s.Vars.UpdatedAt,
+		}
+		if s.Vars.Done {
+			report.Done++
+		}
+		if s.Vars.Leased(now) {
+			report.Leased++
+			until := s.Vars.LeaseUntil
+			st.Owner, st.LeaseUntil = s.Vars.Owner, &until
+		}
+		total := s.Vars.To.Sub(s.Vars.From).Hours()/24 + 1
+		days += total
+		loaded += total * st.Progress
+		report.Shards = append(report.Shards, st)
+	}
+	if days > 0 {
+		report.Progress = round(loaded / days)
+	}
+	return report
+}
+
+// Owner имя воркера для аренды: хост, процесс и номер воркера в процессе
+func Owner(worker int) string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%d", host, os.Getpid(), worker)
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,98 @@
+package shard_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/internal/shard"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		cursor   time.Time
+		expected []shard.Vars
+	}{
+		{
+			name: "no cursor",
+			expected: []shard.Vars{
+				{From: date(2024, 1, 15), To: date(2024, 1, 31), SignedAt: date(2024, 1, 15)},
+				{From: date(2024, 2, 1), To: date(2024, 2, 29), SignedAt: date(2024, 2, 1)},
+				{From: date(2024, 3, 1), To: date(2024, 3, 31), SignedAt: date(2024, 3, 1)},
+			},
+		},
+		{
+			name:   "cursor inside second month",
+			cursor: date(2024, 2, 10),
+			expected: []shard.Vars{
+				{From: date(2024, 1, 15), To: date(2024, 1, 31), SignedAt: date(2024, 2, 1), Done: true},
+				{From: date(2024, 2, 1), To: date(2024, 2, 29), SignedAt: date(2024, 2, 10)},
+				{From: date(2024, 3, 1), To: date(2024, 3, 31), SignedAt: date(2024, 3, 1)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shards := shard.Plan("task", date(2024, 1, 15), time.Date(2024, 3, 5, 13, 0, 0, 0, time.UTC), tt.cursor)
+			assert.Len(t, shards, len(tt.expected))
+			for i, s := range shards {
+				assert.Equal(t, "task", s.Vars.Task)
+				assert.Equal(t, 1, s.Vars.Page)
+				assert.Equal(t, tt.expected[i].From, s.Vars.From)
+				assert.Equal(t, tt.expected[i].To, s.Vars.To)
+				assert.Equal(t, tt.expected[i].SignedAt, s.Vars.SignedAt)
+				assert.Equal(t, tt.expected[i].Done, s.Vars.Done)
+			}
+			assert.Equal(t, "task:2024-02", shards[1].ID)
+		})
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	now := date(2024, 3, 5)
+	shards := []*shard.Shard{
+		{ID: "a", Vars: shard.Vars{From: date(2024, 1, 1), To: date(2024, 1, 10), SignedAt: date(2024, 1, 11), Done: true,
+			Owner: "old", LeaseUntil: now.Add(-time.Minute)}},
+		{ID: "b", Vars: shard.Vars{From: date(2024, 2, 1), To: date(2024, 2, 10), SignedAt: date(2024, 2, 6),
+			Owner: "host-1-0", LeaseUntil: now.Add(time.Minute)}},
+	}
+	report := shard.NewReport("task", shards, now)
+	assert.Equal(t, 2, report.Total)
+	assert.Equal(t, 1, report.Done)
+	assert.Equal(t, 1, report.Leased)
+	assert.Equal(t, 0.75, report.Progress)
+	assert.Equal(t, 1.0, report.Shards[0].Progress)
+	assert.Empty(t, report.Shards[0].Owner)
+	assert.Nil(t, report.Shards[0].LeaseUntil)
+	assert.Equal(t, 0.5, report.Shards[1].Progress)
+	assert.Equal(t, "host-1-0", report.Shards[1].Owner)
+}
+
+func TestCursor(t *testing.T) {
+	shards := shard.Plan("task", date(2024, 1, 15), date(2024, 3, 5), date(2024, 2, 10))
+	tests := []struct {
+		name     string
+		shards   []*shard.Shard
+		expected time.Time
+		ok       bool
+	}{
+		{name: "no shards"},
+		{name: "earliest not done", shards: shards, expected: date(2024, 2, 10), ok: true},
+		{name: "later shard ahead", shards: []*shard.Shard{shards[0], shards[1],
+			{Vars: shard.Vars{SignedAt: date(2024, 3, 4)}}}, expected: date(2024, 2, 10), ok: true},
+		{name: "all done", shards: []*shard.Shard{shards[0],
+			{Vars: shard.Vars{SignedAt: date(2024, 3, 1), Done: true}}}, expected: date(2024, 3, 1), ok: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars, ok := shard.Cursor(tt.shards)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, vars.SignedAt)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package supplier
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// SupplierHandler обрабатывает запросы к поставщикам
+type SupplierHandler struct {
+	logger   *zap.Logger
+	suppRepo ISupplierRepo
+}
+
+// NewSupplierHandler создает новый handler
+func NewSupplierHandler(
+	logger *zap.Logger,
+	suppRepo ISupplierRepo,
+) *SupplierHandler {
+	return &SupplierHandler{
+		logger:   logger,
+		suppRepo: suppRepo,
+	}
+}
+
+// GetSupplierByID godoc
+// @Summary Получить поставщика по ID
+// @Description Возвращает поставщика по его ID (ИНН_КПП или ИНН)
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param id path string true "ID поставщика"
+// @Success 200 {object} model.Supplier
+// @Failure 404 {object} map[string]string
+// @Router /suppliers/{id} [get]
+func (h *SupplierHandler) GetSupplierByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	h.logger.Info("Получение поставщика", zap.String("id", id))
+	supplier, err := h.suppRepo.GetByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) || strings.Contains(err.Error(), "not found") {
+			h.logger.Warn("Поставщик не найден", zap.String("id", id), zap.Error(err))
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "supplier not found",
+			})
+		}
+
+		h.logger.Error("Ошибка при получении поставщика", zap.String("id", id), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "internal server error",
+		})
+	}
+
+	return c.JSON(supplier)
+}
+
+// SearchSuppliers godoc
+// @Summary Поиск поставщиков
+// @Description Возвращает поставщиков по ИНН, ОГРН, наименованию и статусу МСП
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param inn query string false "ИНН"
+// @Param ogrn query string false "ОГРН"
+// @Param name query string false "Подстрока наименования"
+// @Param sme query bool false "Субъект МСП"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param limit query int false "Размер страницы (по умолчанию 50, максимум 500)"
+// @Success 200 {object} model.SupplierPage
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /suppliers [get]
+func (h *SupplierHandler) SearchSuppliers(c *fiber.Ctx) error {
+	search := &SupplierSearch{
+		INN:    c.Query("inn"),
+		OGRN:   c.Query("ogrn"),
+		Name:   c.Query("name"),
+		Cursor: c.Query("cursor"),
+		Limit:  c.QueryInt("limit", DefaultSearchLimit),
+	}
+	if raw := c.Query("sme"); raw != "" {
+		sme, err := strconv.ParseBool(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "sme must be true or false",
+			})
+		}
+		search.SME = &sme
+	}
+	page, err := SearchSuppliers(c.Context(), h.suppRepo, search)
+	if err != nil {
+		h.logger.Error("Ошибка при поиске поставщиков", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "internal server error",
+		})
+	}
+	return c.JSON(page)
+}
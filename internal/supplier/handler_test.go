@@ -0,0 +1,131 @@
+package supplier_test
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+func TestSupplierHandler_GetSupplierByID(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		mockReturn     *supplier.Supplier
+		mockError      error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "successful get",
+			id:             "5110001111_511001001",
+			mockReturn:     &supplier.Supplier{ID: "5110001111_511001001", INN: "5110001111", KPP: "511001001", IsSME: true},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `{"id":"5110001111_511001001","name":"","inn":"5110001111","kpp":"511001001","is_sme":true}`,
+		},
+		{
+			name:           "not found",
+			id:             "1",
+			mockError:      mongo.ErrNoDocuments,
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody:   `{"error":"supplier not found"}`,
+		},
+		{
+			name:           "internal error",
+			id:             "2",
+			mockError:      errors.New("internal error"),
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			mockRepo := new(inmock.MockGenericRepository[*supplier.Supplier])
+			mockRepo.On("GetByID", mock.Anything, tt.id).Return(tt.mockReturn, tt.mockError)
+			h := supplier.NewSupplierHandler(zap.NewNop(), mockRepo)
+			app.Get("/suppliers/:id", h.GetSupplierByID)
+			resp, err := app.Test(httptest.NewRequest("GET", "/suppliers/"+tt.id, nil))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			assert.JSONEq(t, tt.expectedBody, string(body))
+		})
+	}
+}
+
+func TestSupplierHandler_SearchSuppliers(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockReturn     []*supplier.Supplier
+		mockError      error
+		expectedFilter bson.M
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "search by inn and sme",
+			query: "?inn=5110001111&sme=true&limit=1",
+			mockReturn: []*supplier.Supplier{
+				{ID: "1"}, {ID: "2"},
+			},
+			expectedFilter: bson.M{"inn": "5110001111", "is_sme": true},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `{"items":[{"id":"1","name":"","inn":"","is_sme":false}],"next_cursor":"1"}`,
+		},
+		{
+			name:           "search by name with cursor",
+			query:          "?name=ветер&cursor=10",
+			expectedFilter: bson.M{"name": bson.M{"$regex": "ветер", "$options": "i"}, "_id": bson.M{"$gt": "10"}},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `{"items":[]}`,
+		},
+		{
+			name:           "internal error",
+			query:          "",
+			mockError:      errors.New("db error"),
+			expectedFilter: bson.M{},
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			mockRepo := new(inmock.MockGenericRepository[*supplier.Supplier])
+			mockRepo.On("List", mock.Anything, tt.expectedFilter, mock.Anything).Return(tt.mockReturn, tt.mockError)
+			h := supplier.NewSupplierHandler(zap.NewNop(), mockRepo)
+			app.Get("/suppliers", h.SearchSuppliers)
+			resp, err := app.Test(httptest.NewRequest("GET", "/suppliers"+tt.query, nil))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			assert.JSONEq(t, tt.expectedBody, string(body))
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSupplierHandler_SearchSuppliers_BadSME(t *testing.T) {
+	app := fiber.New()
+	h := supplier.NewSupplierHandler(zap.NewNop(), new(inmock.MockGenericRepository[*supplier.Supplier]))
+	app.Get("/suppliers", h.SearchSuppliers)
+	resp, err := app.Test(httptest.NewRequest("GET", "/suppliers?sme=maybe", nil))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,63 @@
+package supplier
+
+import "strings"
+
+type Supplier struct {
+	ID      string `bson:"_id,omitempty" json:"id"` // ИНН_КПП, ИНН или наименование, если ИНН нет
+	Name    string `bson:"name" json:"name"`        // Наименование поставщика
+	INN     string `bson:"inn" json:"inn"`          // ИНН
+	KPP     string `bson:"kpp,omitempty" json:"kpp,omitempty"`
+	OGRN    string `bson:"ogrn,omitempty" json:"ogrn,omitempty"`
+	Address string `bson:"address,omitempty" json:"address,omitempty"` // Место нахождения
+	IsSME   bool   `bson:"is_sme" json:"is_sme"`                       // Субъект МСП
+}
+
+func (t Supplier) GetID() any {
+	return t.ID
+}
+
+// MakeID у филиалов один ИНН и разные КПП, поэтому ключ — ИНН_КПП
+func MakeID(inn, kpp, name string) string {
+	switch {
+	case inn != "" && kpp != "":
+		return inn + "_" + kpp
+	case inn != "":
+		return inn
+	default:
+		return strings.ToUpper(strings.TrimSpace(name))
+	}
+}
+
+// Merge добавляет поставщиков в список, совпадающих по ID дополняет непустыми полями
+func Merge(dst []*Supplier, src ...*Supplier) []*Supplier {
+outer:
+	for _, s := range src {
+		if s == nil {
+			continue
+		}
+		if s.ID == "" {
+			s.ID = MakeID(s.INN, s.KPP, s.Name)
+		}
+		if s.ID == "" {
+			continue
+		}
+		for _, d := range dst {
+			if d.ID != s.ID {
+				continue
+			}
+			if d.Name == "" {
+				d.Name = s.Name
+			}
+			if d.OGRN == "" {
+				d.OGRN = s.OGRN
+			}
+			if d.Address == "" {
+				d.Address = s.Address
+			}
+			d.IsSME = d.IsSME || s.IsSME
+			continue outer
+		}
+		dst = append(dst, s)
+	}
+	return dst
+}
@@ -0,0 +1,30 @@
+package supplier
+
+import (
+	"context"
+
+	"github.com/tim8842/tender-data-loader/pkg/repository"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SupplierRepo struct {
+	*repository.GenericRepository[*Supplier]
+}
+
+func (r *SupplierRepo) BulkMergeMany(ctx context.Context, docs []*Supplier) error {
+	return r.BulkCreateOrUpdateMany(ctx, docs)
+}
+
+func (r *SupplierRepo) GetByID(ctx context.Context, id string) (*Supplier, error) {
+	return r.GenericRepository.GetByID(ctx, id)
+}
+
+func (r *SupplierRepo) List(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*Supplier, error) {
+	return r.GenericRepository.List(ctx, filter, opts...)
+}
+
+type ISupplierRepo interface {
+	BulkMergeMany(ctx context.Context, docs []*Supplier) error
+	GetByID(ctx context.Context, id string) (*Supplier, error)
+	List(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*Supplier, error)
+}
@@ -0,0 +1,78 @@
+package supplier
+
+import (
+	"context"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 500
+)
+
+// SupplierSearch параметры поиска поставщиков
+type SupplierSearch struct {
+	INN  string
+	OGRN string
+	Name string // подстрока наименования
+	SME  *bool  // только субъекты МСП / только не МСП
+
+	Cursor string // _id последнего поставщика предыдущей страницы
+	Limit  int
+}
+
+// SupplierPage страница результатов поиска
+type SupplierPage struct {
+	Items      []*Supplier `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// Filter строит фильтр mongo
+func (s *SupplierSearch) Filter() bson.M {
+	filter := bson.M{}
+	if s.INN != "" {
+		filter["inn"] = s.INN
+	}
+	if s.OGRN != "" {
+		filter["ogrn"] = s.OGRN
+	}
+	if s.Name != "" {
+		filter["name"] = bson.M{"$regex": regexp.QuoteMeta(s.Name), "$options": "i"}
+	}
+	if s.SME != nil {
+		filter["is_sme"] = *s.SME
+	}
+	if s.Cursor != "" {
+		filter["_id"] = bson.M{"$gt": s.Cursor}
+	}
+	return filter
+}
+
+// SearchSuppliers ищет поставщиков, сортировка по _id
+func SearchSuppliers(ctx context.Context, repo ISupplierRepo, s *SupplierSearch) (*SupplierPage, error) {
+	if s.Limit <= 0 {
+		s.Limit = DefaultSearchLimit
+	}
+	if s.Limit > MaxSearchLimit {
+		s.Limit = MaxSearchLimit
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(s.Limit + 1))
+	items, err := repo.List(ctx, s.Filter(), opts)
+	if err != nil {
+		return nil, err
+	}
+	page := &SupplierPage{Items: items}
+	if len(items) > s.Limit {
+		page.Items = items[:s.Limit]
+		page.NextCursor = page.Items[s.Limit-1].ID
+	}
+	if page.Items == nil {
+		page.Items = []*Supplier{}
+	}
+	return page, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/pkg/metrics"
 	"go.uber.org/zap"
 )
 
@@ -19,6 +20,7 @@ func NewParseData(data []byte, parseFunc func(ctx context.Context, logger *zap.L
 func (t ParseData) Process(ctx context.Context, logger *zap.Logger) (any, error) {
 	data, ok := t.parseFunc(ctx, logger, t.data)
 	if ok != nil {
+		metrics.ParseFailures.WithLabelValues(metrics.FuncName(t.parseFunc)).Inc()
 		return nil, ok
 	}
 	return data, ok
@@ -44,6 +46,7 @@ func NewParseDataInAgreementParesedData(data []byte, parseFunc func(
 func (t ParseDataInAgreementParesedData) Process(ctx context.Context, logger *zap.Logger) (any, error) {
 	data, ok := t.parseFunc(ctx, logger, t.data, t.agreementParesedData)
 	if ok != nil {
+		metrics.ParseFailures.WithLabelValues(metrics.FuncName(t.parseFunc)).Inc()
 		return nil, ok
 	}
 	return data, ok
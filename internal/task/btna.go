@@ -3,32 +3,49 @@ package task
 import (
 	"context"
 	"errors"
-	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/tim8842/tender-data-loader/internal/agreement"
 	"github.com/tim8842/tender-data-loader/internal/config"
 	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/fillrate"
+	"github.com/tim8842/tender-data-loader/internal/search"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
 	agreementt "github.com/tim8842/tender-data-loader/internal/task/agreement"
 	uagentt "github.com/tim8842/tender-data-loader/internal/task/uagent"
 	variablet "github.com/tim8842/tender-data-loader/internal/task/variable"
 	"github.com/tim8842/tender-data-loader/internal/uagent"
 	"github.com/tim8842/tender-data-loader/internal/variable"
+	"github.com/tim8842/tender-data-loader/internal/webhook"
 	"github.com/tim8842/tender-data-loader/pkg"
+	"github.com/tim8842/tender-data-loader/pkg/archive"
 	"github.com/tim8842/tender-data-loader/pkg/parser"
+	"github.com/tim8842/tender-data-loader/pkg/request"
 	"go.uber.org/zap"
 )
 
 type BackToNowAgreementTask struct {
-	cfg         *config.Config
-	agreeRepo   agreement.IAgreementRepo
-	varRepo     variable.IVariableRepo
-	custRepo    customer.ICustomerRepo
-	staticProxy bool
+	cfg       *config.Config
+	agreeRepo agreement.IAgreementRepo
+	varRepo   variable.IVariableRepo
+	custRepo  customer.ICustomerRepo
+	suppRepo  supplier.ISupplierRepo
+	proxies   *uagent.ProxyPool
+	requester request.IRequester
+	notifier  *webhook.Dispatcher
+	monitor   *fillrate.Monitor
+
+	lastProgress atomic.Int64 // время последней сохраненной пачки, unix nano
 }
 
-var funcWrapper = pkg.FuncWrapper
+const backToNowAgreementVarID = "back_to_now_agreement"
+
+// BackToNowAgreementTaskName имя задачи догрузки 223-ФЗ в раннере
+const BackToNowAgreementTaskName = backToNowAgreementVarID
+
+var funcWrapper = pkg.RetryWithPolicy
 var Now = func() time.Time {
 	return time.Now()
 }
@@ -36,17 +53,32 @@ var Now = func() time.Time {
 func NewBackToNowAgreementTask(
 	cfg *config.Config,
 	agreeRepo agreement.IAgreementRepo, varRepo variable.IVariableRepo,
-	custRepo customer.ICustomerRepo,
-	staticProxy bool,
+	custRepo customer.ICustomerRepo, suppRepo supplier.ISupplierRepo,
+	proxies *uagent.ProxyPool, requester request.IRequester, notifier *webhook.Dispatcher,
+	monitor *fillrate.Monitor,
 ) *BackToNowAgreementTask {
-	return &BackToNowAgreementTask{
+	t := &BackToNowAgreementTask{
 		cfg: cfg, agreeRepo: agreeRepo, varRepo: varRepo,
-		custRepo: custRepo, staticProxy: staticProxy,
+		custRepo: custRepo, suppRepo: suppRepo, proxies: proxies,
+		requester: requester, notifier: notifier, monitor: monitor,
 	}
+	// До первой пачки отсчитываем от старта, иначе сервис не готов сразу после запуска
+	t.markProgress()
+	return t
+}
+
+// LastProgress время последней сохраненной пачки или того, как догрузка дошла до сегодня
+func (t *BackToNowAgreementTask) LastProgress() time.Time {
+	return time.Unix(0, t.lastProgress.Load())
+}
+
+func (t *BackToNowAgreementTask) markProgress() {
+	t.lastProgress.Store(Now().UnixNano())
 }
 
 func (t *BackToNowAgreementTask) Process(ctx context.Context, logger *zap.Logger) error {
 	var mainErr error = nil
+	failures := 0 // неудачных шагов подряд
 outer:
 	for {
 		select {
@@ -54,14 +86,31 @@ outer:
 			logger.Info("BackToNowAgreementTask: Context cancelled, exiting.")
 			return ctx.Err()
 		default:
+			// После неудачного шага ждем, чтобы не долбить базу и zakupki по кругу,
+			// а после loopPolicy.MaxRetries неудач подряд отдаем ошибку раннеру
+			if failures > loopPolicy.MaxRetries {
+				logger.Error("BackToNowAgreementTask: too many failures in a row, exiting.", zap.Int("failures", failures))
+				break outer
+			}
+			if failures > 0 {
+				if err := loopPolicy.Wait(ctx, failures); err != nil {
+					return err
+				}
+			}
+			// Раннер останавливается: предыдущая пачка сохранена, новую не начинаем
+			if pkg.Stopping(ctx) {
+				logger.Info("BackToNowAgreementTask: runner stopping, exiting.")
+				break outer
+			}
 			var tmp any
 			var ok bool
 			var err error
 			var tmpByte []byte
 			// Считываем данные для того чтобы хранить стейт в бд
-			tmp, err = funcWrapper(ctx, logger, 3, 5*time.Second, variablet.NewGetVariableBackToNowAgreementById(t.varRepo, "back_to_now_agreement"))
+			tmp, err = funcWrapper(ctx, logger, dbPolicy, variablet.NewGetVariableBackToNowAgreementById(t.varRepo, backToNowAgreementVarID))
 			if err != nil {
 				mainErr = err
+				failures++
 				continue outer
 			}
 
@@ -71,18 +120,19 @@ outer:
 				mainErr = errors.New("parse error *model.VariableBackToNowAgreement")
 				break outer
 			}
-			dbDate := parser.FromTimeToDate(varData.Vars.SignedAt)
+			observeCursor(backToNowAgreementVarID, varData.Vars)
 			if parser.DateOnly(Now()) == parser.DateOnly(varData.Vars.SignedAt) {
+				t.markProgress()
 				break outer
 			}
-			userAgentResponse := &uagent.UserAgentResponse{UserAgent: map[string]any{"agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/96.0.4664.110 Safari/537.36"}, Proxy: map[string]any{"url": nil}} // Если часто таймаут на серваке
-			urlProx := t.cfg.UrlGetProxy
+			userAgentResponse := defaultUserAgentResponse()
 			// Получаем прокси
-			if !t.staticProxy {
-				tmp, err = funcWrapper(ctx, logger, 3, 5*time.Second, uagentt.NewGetRequest(urlProx))
+			if t.proxies != nil {
+				tmp, err = funcWrapper(ctx, logger, requestPolicy, uagentt.NewAcquireProxy(t.proxies))
 				if err != nil {
 					logger.Error("Get proxy error ", zap.Error(err))
 					mainErr = err
+					failures++
 					continue outer
 				}
 				userAgentResponse, ok = tmp.(*uagent.UserAgentResponse)
@@ -92,18 +142,18 @@ outer:
 					break outer
 				}
 			}
-			urlNumbersPage := t.cfg.UrlZakupkiAgreementGetNumbersFirst +
-				dbDate +
-				t.cfg.UrlZakupkiAgreementGetNumbersSecond +
-				dbDate +
-				t.cfg.UrlZakupkiAgreementGetNumbersThird +
-				fmt.Sprintf("%d", varData.Vars.Page) +
-				t.cfg.UrlZakupkiAgreementGetNumbersForth
+			urlNumbersPage, err := search.URL(t.cfg.UrlZakupkiAgreementSearch, t.cfg.SearchParams(search.SortSignedDate).Day(varData.Vars.SignedAt, varData.Vars.Page))
+			if err != nil {
+				logger.Error("Build search url error ", zap.Error(err))
+				mainErr = err
+				break outer
+			}
 			// // Получаем страницу с номерами
-			tmp, err = funcWrapper(ctx, logger, 3, 5*time.Second, uagentt.NewGetPage(urlNumbersPage, userAgentResponse))
+			tmp, err = funcWrapper(ctx, logger, requestPolicy, uagentt.NewGetPage(urlNumbersPage, archive.KindSearch, userAgentResponse, t.proxies, t.requester))
 			if err != nil {
 				logger.Error("Get numbers page error ", zap.Error(err))
 				mainErr = err
+				failures++
 				continue outer
 			}
 			tmpByte, ok = tmp.([]byte)
@@ -113,7 +163,7 @@ outer:
 				break outer
 			}
 			// Парсим страницу с номерами
-			tmp, err = funcWrapper(ctx, logger, 3, 5*time.Second, agreementt.NewParseData(tmpByte, agreement.ParseAgreementIds))
+			tmp, err = funcWrapper(ctx, logger, parsePolicy, agreementt.NewParseData(tmpByte, agreement.ParseAgreementIds))
 			if err != nil {
 				logger.Error("ParseAgreementIds error ", zap.Error(err))
 				mainErr = err
@@ -138,31 +188,38 @@ outer:
 				if err != nil {
 					logger.Error("Update data SignedAt agreement error ", zap.Error(err))
 					mainErr = err
+					failures++
 					continue outer
 				}
+				failures = 0
 				continue outer
 			}
 			// Запускаем подзадачу, которая делает параллельные 50 запросов и парсит данные
-			tmp, err = funcWrapper(ctx, logger, 0, 0*time.Second, NewBtnaManyRequests(t.cfg, ids, true))
+			tmp, err = funcWrapper(ctx, logger, noRetryPolicy, NewBtnaManyRequests(t.cfg, ids, t.proxies, t.requester))
 			if err != nil {
 				logger.Error("Error subtasks.NewBtnaManyRequests", zap.Error(err))
-				mainErr = err
-				if strings.Contains(err.Error(), "no correct data, empty") {
-					varData.Vars.SignedAt = varData.Vars.SignedAt.Add(24 * time.Hour)
-					varData.Vars.Page = 1
-					vData, err := varData.ConvertToVariable()
-					if err != nil {
-						logger.Error("Error ConvertToVariable ", zap.Error(err))
-						mainErr = err
-						break outer
-					}
-					err = t.varRepo.Update(ctx, varData.ID, &vData)
-					if err != nil {
-						logger.Error("Update data SignedAt agreement error ", zap.Error(err))
-						mainErr = err
-						continue outer
-					}
+				if !strings.Contains(err.Error(), "no correct data, empty") {
+					mainErr = err
+					failures++
+					continue outer
 				}
+				// Ни одного корректного договора на странице: день пропускаем
+				varData.Vars.SignedAt = varData.Vars.SignedAt.Add(24 * time.Hour)
+				varData.Vars.Page = 1
+				vData, err := varData.ConvertToVariable()
+				if err != nil {
+					logger.Error("Error ConvertToVariable ", zap.Error(err))
+					mainErr = err
+					break outer
+				}
+				err = t.varRepo.Update(ctx, varData.ID, &vData)
+				if err != nil {
+					logger.Error("Update data SignedAt agreement error ", zap.Error(err))
+					mainErr = err
+					failures++
+					continue outer
+				}
+				failures = 0
 				continue outer
 			}
 			arrData, ok := tmp.([]*agreement.AgreementParesedData)
@@ -171,26 +228,19 @@ outer:
 				mainErr = errors.New("error parse []*model.AgreementParesedData")
 				break outer
 			}
-			var customers []*customer.Customer
-			var agreements []*agreement.Agreement
-			for _, v := range arrData {
-				a, c := agreement.ParseAgreementDataToModels(v)
-				customers = append(customers, c)
-				agreements = append(agreements, a)
-			}
-			err = t.agreeRepo.BulkMergeMany(ctx, agreements)
-			if err != nil {
-				logger.Error("Error create many ", zap.Error(err))
+			// Пачку с пустыми критичными полями не сохраняем, задача встает на паузу
+			if err = t.monitor.Observe(backToNowAgreementVarID, arrData); err != nil {
 				mainErr = err
-				continue outer
+				break outer
 			}
-
-			err = t.custRepo.BulkMergeMany(ctx, customers)
+			err = storeAgreements(ctx, logger, t.agreeRepo, t.custRepo, t.suppRepo, t.notifier, arrData)
 			if err != nil {
 				logger.Error("Error create many ", zap.Error(err))
 				mainErr = err
+				failures++
 				continue outer
 			}
+			t.markProgress()
 			if varData.Vars.Page < 100 {
 				varData.Vars.Page = varData.Vars.Page + 1
 			} else {
@@ -208,8 +258,10 @@ outer:
 			if err != nil {
 				logger.Error("Update data Page agreement error ", zap.Error(err))
 				mainErr = err
+				failures++
 				continue outer
 			}
+			failures = 0
 		}
 	}
 	return mainErr
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"sync"
-	"time"
 
 	"github.com/tim8842/tender-data-loader/internal/agreement"
 	"github.com/tim8842/tender-data-loader/internal/config"
@@ -13,28 +12,34 @@ import (
 	agreementt "github.com/tim8842/tender-data-loader/internal/task/agreement"
 	uagentt "github.com/tim8842/tender-data-loader/internal/task/uagent"
 	"github.com/tim8842/tender-data-loader/internal/uagent"
+	"github.com/tim8842/tender-data-loader/pkg/archive"
+	"github.com/tim8842/tender-data-loader/pkg/request"
 	"go.uber.org/zap"
 )
 
 type SBtnaManyRequests struct {
-	cfg         *config.Config
-	ids         []string
-	staticProxy bool
+	cfg       *config.Config
+	ids       []string
+	proxies   *uagent.ProxyPool
+	requester request.IRequester
 }
 
-func NewBtnaManyRequests(cfg *config.Config, ids []string, staticProxy bool) *SBtnaManyRequests {
-	return &SBtnaManyRequests{cfg: cfg, ids: ids, staticProxy: staticProxy}
+func NewBtnaManyRequests(cfg *config.Config, ids []string, proxies *uagent.ProxyPool, requester request.IRequester) *SBtnaManyRequests {
+	return &SBtnaManyRequests{cfg: cfg, ids: ids, proxies: proxies, requester: requester}
 }
 
 func (t SBtnaManyRequests) Process(ctx context.Context, logger *zap.Logger) (any, error) {
-	data, ok := BtnaManyRequests(ctx, logger, t.cfg, t.ids, t.staticProxy)
+	data, ok := BtnaManyRequests(ctx, logger, t.cfg, t.ids, t.proxies, t.requester)
 	if ok != nil {
 		return nil, ok
 	}
 	return data, ok
 }
 
-func BtnaManyRequests(ctx context.Context, logger *zap.Logger, cfg *config.Config, ids []string, staticProxy bool) (any, error) {
+func BtnaManyRequests(
+	ctx context.Context, logger *zap.Logger, cfg *config.Config, ids []string,
+	proxies *uagent.ProxyPool, requester request.IRequester,
+) (any, error) {
 	lenNums := len(ids)
 	results := make(chan *agreement.AgreementParesedData, lenNums)
 	var res []*agreement.AgreementParesedData
@@ -42,6 +47,12 @@ func BtnaManyRequests(ctx context.Context, logger *zap.Logger, cfg *config.Confi
 	ctx, cancel := context.WithCancel(ctx)
 	var mainErr error = nil
 	defer cancel()
+	// Ограничиваем число одновременно загружаемых договоров
+	workers := cfg.FetchWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
 	for i := 0; i < lenNums; i++ {
 		wg.Add(1)
 		go func() {
@@ -50,16 +61,16 @@ func BtnaManyRequests(ctx context.Context, logger *zap.Logger, cfg *config.Confi
 			case <-ctx.Done():
 				logger.Info(fmt.Sprintf("Request № %d: Context cancelled, exiting.", i))
 				return
-			default:
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
 				var tmp any
 				var ok bool
 				var err error
 				var tmpByte []byte
-				userAgentResponse := &uagent.UserAgentResponse{UserAgent: map[string]any{"agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/96.0.4664.110 Safari/537.36"}, Proxy: map[string]any{"url": nil}} // Если часто таймаут на серваке
-				urlProx := cfg.UrlGetProxy
-				if !staticProxy {
+				userAgentResponse := defaultUserAgentResponse()
+				if proxies != nil {
 					// Получаем прокси
-					tmp, err = funcWrapper(ctx, logger, 3, 5*time.Second, uagentt.NewGetRequest(urlProx))
+					tmp, err = funcWrapper(ctx, logger, requestPolicy, uagentt.NewAcquireProxy(proxies))
 					if err != nil {
 						mainErr = err
 						return
@@ -73,7 +84,7 @@ func BtnaManyRequests(ctx context.Context, logger *zap.Logger, cfg *config.Confi
 
 				// Делаем запрос по каждому id
 				urlWebPage := cfg.UrlZakupkiAgreementGetAgreegmentWeb + ids[i]
-				tmp, err = funcWrapper(ctx, logger, 3, 5*time.Second, uagentt.NewGetPage(urlWebPage, userAgentResponse))
+				tmp, err = funcWrapper(ctx, logger, requestPolicy, uagentt.NewGetPage(urlWebPage, archive.KindContract, userAgentResponse, proxies, requester))
 				if err != nil {
 					logger.Error("Agreement web get err ", zap.Error(err))
 					return
@@ -84,7 +95,7 @@ func BtnaManyRequests(ctx context.Context, logger *zap.Logger, cfg *config.Confi
 					return
 				}
 				// Парсим эти страницы
-				tmp, _ = funcWrapper(ctx, logger, 1, 5*time.Second, agreementt.NewParseData(tmpByte, agreement.ParseAgreementFromMain))
+				tmp, _ = funcWrapper(ctx, logger, parsePolicy, agreementt.NewParseData(tmpByte, agreement.ParseAgreementFromMain))
 				if tmp == nil {
 					logger.Error("Parse error no noticed id")
 					return
@@ -96,8 +107,8 @@ func BtnaManyRequests(ctx context.Context, logger *zap.Logger, cfg *config.Confi
 				}
 				data.ID = ids[i]
 				// Получаем прокси
-				if !staticProxy {
-					tmp, err = funcWrapper(ctx, logger, 3, 5*time.Second, uagentt.NewGetRequest(urlProx))
+				if proxies != nil {
+					tmp, err = funcWrapper(ctx, logger, requestPolicy, uagentt.NewAcquireProxy(proxies))
 					if err != nil {
 						mainErr = err
 						return
@@ -111,7 +122,7 @@ func BtnaManyRequests(ctx context.Context, logger *zap.Logger, cfg *config.Confi
 				// Получаем html show
 				if data.Pfid != "" {
 					urlShowHtml := cfg.UrlZakupkiAgreementGetAgreegmentShowHtml + data.Pfid
-					tmp, err = funcWrapper(ctx, logger, 3, 5*time.Second, uagentt.NewGetPage(urlShowHtml, userAgentResponse))
+					tmp, err = funcWrapper(ctx, logger, requestPolicy, uagentt.NewGetPage(urlShowHtml, archive.KindPrintForm, userAgentResponse, proxies, requester))
 					if err != nil {
 						logger.Error("Agreement html show get err ", zap.Error(err))
 						return
@@ -122,7 +133,7 @@ func BtnaManyRequests(ctx context.Context, logger *zap.Logger, cfg *config.Confi
 						return
 					}
 					// Парсим show
-					_, err = funcWrapper(ctx, logger, 1, 5*time.Second, agreementt.NewParseDataInAgreementParesedData(tmpByte, agreement.ParseAgreementFromHtml, data))
+					_, err = funcWrapper(ctx, logger, parsePolicy, agreementt.NewParseDataInAgreementParesedData(tmpByte, agreement.ParseAgreementFromHtml, data))
 					if err != nil {
 						mainErr = err
 						return
@@ -135,8 +146,8 @@ func BtnaManyRequests(ctx context.Context, logger *zap.Logger, cfg *config.Confi
 				// 	return
 				// }
 				// Получаем прокси
-				if !staticProxy {
-					tmp, err = funcWrapper(ctx, logger, 3, 5*time.Second, uagentt.NewGetRequest(urlProx))
+				if proxies != nil {
+					tmp, err = funcWrapper(ctx, logger, requestPolicy, uagentt.NewAcquireProxy(proxies))
 					if err != nil {
 						mainErr = err
 						return
@@ -149,7 +160,7 @@ func BtnaManyRequests(ctx context.Context, logger *zap.Logger, cfg *config.Confi
 				}
 				// Получаем страницу customer
 				urlCustomerWeb := cfg.UrlZakupkiAgreementGetCustomerWeb + data.Customer.ID
-				tmp, err = funcWrapper(ctx, logger, 3, 5*time.Second, uagentt.NewGetPage(urlCustomerWeb, userAgentResponse))
+				tmp, err = funcWrapper(ctx, logger, requestPolicy, uagentt.NewGetPage(urlCustomerWeb, archive.KindCustomer, userAgentResponse, proxies, requester))
 				if err != nil {
 					logger.Error("Customer get err ", zap.Error(err))
 					return
@@ -160,7 +171,7 @@ func BtnaManyRequests(ctx context.Context, logger *zap.Logger, cfg *config.Confi
 					return
 				}
 				// Парсим Customer
-				_, err = funcWrapper(ctx, logger, 1, 5*time.Second, agreementt.NewParseDataInAgreementParesedData(tmpByte, agreement.ParseCustomerFromMain, data))
+				_, err = funcWrapper(ctx, logger, parsePolicy, agreementt.NewParseDataInAgreementParesedData(tmpByte, agreement.ParseCustomerFromMain, data))
 				if err != nil {
 					mainErr = err
 					return
@@ -171,6 +182,11 @@ func BtnaManyRequests(ctx context.Context, logger *zap.Logger, cfg *config.Confi
 	}
 	wg.Wait()
 	close(results)
+	// Загрузку прервали: недокачанную пачку не отдаем, иначе пустой результат
+	// приняли бы за пустой день и сдвинули курсор дальше несохраненных договоров
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("batch discarded: %w", err)
+	}
 	for msg := range results {
 		res = append(res, msg)
 	}
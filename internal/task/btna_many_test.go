@@ -3,6 +3,7 @@ package task
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -36,7 +37,7 @@ func TestBtnaManyRequests(t *testing.T) {
 			staticProxy: false,
 			setupMock: func() {
 				call := 0
-				funcWrapper = func(ctx context.Context, logger *zap.Logger, maxRetries int, delay time.Duration, fn pkg.FuncInWrapp) (any, error) {
+				funcWrapper = func(ctx context.Context, logger *zap.Logger, policy pkg.RetryPolicy, fn pkg.FuncInWrapp) (any, error) {
 					call++
 					switch call {
 					case 1, 4, 7:
@@ -78,7 +79,7 @@ func TestBtnaManyRequests(t *testing.T) {
 			staticProxy: true,
 			setupMock: func() {
 				call := 0
-				funcWrapper = func(ctx context.Context, logger *zap.Logger, maxRetries int, delay time.Duration, fn pkg.FuncInWrapp) (any, error) {
+				funcWrapper = func(ctx context.Context, logger *zap.Logger, policy pkg.RetryPolicy, fn pkg.FuncInWrapp) (any, error) {
 					call++
 					switch call {
 					case 1:
@@ -117,7 +118,7 @@ func TestBtnaManyRequests(t *testing.T) {
 			staticProxy: false,
 			ids:         []string{"id1"},
 			setupMock: func() {
-				funcWrapper = func(ctx context.Context, logger *zap.Logger, maxRetries int, delay time.Duration, fn pkg.FuncInWrapp) (any, error) {
+				funcWrapper = func(ctx context.Context, logger *zap.Logger, policy pkg.RetryPolicy, fn pkg.FuncInWrapp) (any, error) {
 					return nil, errors.New("proxy error")
 				}
 			},
@@ -130,7 +131,7 @@ func TestBtnaManyRequests(t *testing.T) {
 			staticProxy: false,
 			setupMock: func() {
 				call := 0
-				funcWrapper = func(ctx context.Context, logger *zap.Logger, maxRetries int, delay time.Duration, fn pkg.FuncInWrapp) (any, error) {
+				funcWrapper = func(ctx context.Context, logger *zap.Logger, policy pkg.RetryPolicy, fn pkg.FuncInWrapp) (any, error) {
 					call++
 					switch call {
 					case 1:
@@ -166,7 +167,7 @@ func TestBtnaManyRequests(t *testing.T) {
 				UrlZakupkiAgreementGetCustomerWeb:        "cust/",
 			}
 
-			result, err := BtnaManyRequests(ctx, logger, cfg, tt.ids, tt.staticProxy)
+			result, err := BtnaManyRequests(ctx, logger, cfg, tt.ids, testProxies(tt.staticProxy), nil)
 
 			if tt.expectedErr {
 				assert.Error(t, err)
@@ -178,3 +179,29 @@ func TestBtnaManyRequests(t *testing.T) {
 		})
 	}
 }
+
+func TestBtnaManyRequests_Concurrency(t *testing.T) {
+	originalFuncWrapper := funcWrapper
+	defer func() { funcWrapper = originalFuncWrapper }()
+
+	var active, maxActive int32
+	funcWrapper = func(ctx context.Context, logger *zap.Logger, policy pkg.RetryPolicy, fn pkg.FuncInWrapp) (any, error) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			m := atomic.LoadInt32(&maxActive)
+			if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return nil, errors.New("page error")
+	}
+
+	cfg := &config.Config{FetchWorkers: 2}
+	ids := []string{"1", "2", "3", "4", "5", "6"}
+	_, err := BtnaManyRequests(context.Background(), zap.NewNop(), cfg, ids, nil, nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxActive))
+}
@@ -0,0 +1,262 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/config"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/fillrate"
+	"github.com/tim8842/tender-data-loader/internal/search"
+	"github.com/tim8842/tender-data-loader/internal/shard"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	variablet "github.com/tim8842/tender-data-loader/internal/task/variable"
+	"github.com/tim8842/tender-data-loader/internal/uagent"
+	"github.com/tim8842/tender-data-loader/internal/variable"
+	"github.com/tim8842/tender-data-loader/internal/webhook"
+	"github.com/tim8842/tender-data-loader/pkg"
+	"github.com/tim8842/tender-data-loader/pkg/parser"
+	"github.com/tim8842/tender-data-loader/pkg/request"
+	"go.uber.org/zap"
+)
+
+// BackToNowAgreementShardsTask догрузка 223-ФЗ, разбитая на шарды по месяцам.
+// Воркеры берут шарды в аренду и продлевают ее, пока грузят, поэтому догрузку можно
+// запускать в нескольких процессах сразу без двойной работы
+type BackToNowAgreementShardsTask struct {
+	cfg       *config.Config
+	agreeRepo agreement.IAgreementRepo
+	varRepo   variable.IVariableRepo
+	custRepo  customer.ICustomerRepo
+	suppRepo  supplier.ISupplierRepo
+	shardRepo shard.IShardRepo
+	proxies   *uagent.ProxyPool
+	requester request.IRequester
+	notifier  *webhook.Dispatcher
+	monitor   *fillrate.Monitor
+
+	lastProgress atomic.Int64 // время последней сохраненной пачки, unix nano
+	cursorMu     sync.Mutex   // курсор пишут все воркеры, запись по очереди не откатывает его назад
+}
+
+func NewBackToNowAgreementShardsTask(
+	cfg *config.Config,
+	agreeRepo agreement.IAgreementRepo, varRepo variable.IVariableRepo,
+	custRepo customer.ICustomerRepo, suppRepo supplier.ISupplierRepo, shardRepo shard.IShardRepo,
+	proxies *uagent.ProxyPool, requester request.IRequester, notifier *webhook.Dispatcher,
+	monitor *fillrate.Monitor,
+) *BackToNowAgreementShardsTask {
+	t := &BackToNowAgreementShardsTask{
+		cfg: cfg, agreeRepo: agreeRepo, varRepo: varRepo,
+		custRepo: custRepo, suppRepo: suppRepo, shardRepo: shardRepo, proxies: proxies,
+		requester: requester, notifier: notifier, monitor: monitor,
+	}
+	t.markProgress()
+	return t
+}
+
+// LastProgress время последней сохраненной пачки или того, как все шарды догнали сегодня
+func (t *BackToNowAgreementShardsTask) LastProgress() time.Time {
+	return time.Unix(0, t.lastProgress.Load())
+}
+
+func (t *BackToNowAgreementShardsTask) markProgress() {
+	t.lastProgress.Store(Now().UnixNano())
+}
+
+func (t *BackToNowAgreementShardsTask) Process(ctx context.Context, logger *zap.Logger) error {
+	if err := t.plan(ctx, logger); err != nil {
+		return err
+	}
+	t.syncCursor(ctx, logger)
+	workers := t.cfg.BackfillShardWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			owner := shard.Owner(i)
+			errs[i] = t.work(ctx, logger.With(zap.String("owner", owner)), owner)
+		}(i)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	// Свободных шардов нет: все загружены или их грузят другие процессы
+	t.markProgress()
+	return nil
+}
+
+// plan создает шарды до текущего месяца. Новые шарды начинаются с курсора
+// последовательной догрузки, чтобы не грузить уже загруженные дни заново
+func (t *BackToNowAgreementShardsTask) plan(ctx context.Context, logger *zap.Logger) error {
+	var cursor time.Time
+	tmp, err := funcWrapper(ctx, logger, dbPolicy, variablet.NewGetVariableBackToNowAgreementById(t.varRepo, backToNowAgreementVarID))
+	if err != nil {
+		logger.Warn("BackToNowAgreementShardsTask: serial cursor not found, planning from start", zap.Error(err))
+	} else if varData, ok := tmp.(*variable.VariableBackToNowAgreement); ok {
+		cursor = varData.Vars.SignedAt
+	}
+	return t.shardRepo.Ensure(ctx, shard.Plan(BackToNowAgreementTaskName, t.cfg.BackfillFrom, Now(), cursor))
+}
+
+// work берет шарды в аренду и грузит их, пока свободные не кончатся
+func (t *BackToNowAgreementShardsTask) work(ctx context.Context, logger *zap.Logger, owner string) error {
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if pkg.Stopping(ctx) {
+			return nil
+		}
+		s, err := t.shardRepo.Acquire(ctx, BackToNowAgreementTaskName, owner, Now(), t.cfg.BackfillShardLease)
+		if errors.Is(err, shard.ErrNoShard) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		logger.Info("BackToNowAgreementShardsTask: shard acquired", zap.String("shard", s.ID), zap.Time("signed_at", s.Vars.SignedAt))
+		err = t.loadShard(ctx, logger, s, owner)
+		if errors.Is(err, shard.ErrLeaseLost) {
+			logger.Warn("BackToNowAgreementShardsTask: shard lease lost", zap.String("shard", s.ID))
+			continue
+		}
+		if releaseErr := t.shardRepo.Release(context.WithoutCancel(ctx), s.ID, owner); releaseErr != nil && !errors.Is(releaseErr, shard.ErrLeaseLost) {
+			logger.Warn("BackToNowAgreementShardsTask: shard release error", zap.String("shard", s.ID), zap.Error(releaseErr))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// loadShard грузит шард страница за страницей до его последнего дня или до сегодня.
+// Пока шард грузится, аренда продлевается в фоне; если ее забрали, загрузка прерывается
+func (t *BackToNowAgreementShardsTask) loadShard(ctx context.Context, logger *zap.Logger, s *shard.Shard, owner string) error {
+	leaseCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go t.heartbeat(leaseCtx, cancel, logger, s.ID, owner)
+
+	today := parser.DateOnly(Now())
+	for !s.Vars.Done && s.Vars.SignedAt.Before(today) {
+		if pkg.Stopping(ctx) {
+			return nil
+		}
+		if leaseCtx.Err() != nil {
+			return context.Cause(leaseCtx)
+		}
+		vars, err := t.loadPage(leaseCtx, logger, variable.VarsBackToNowAgreement{SignedAt: s.Vars.SignedAt, Page: s.Vars.Page})
+		if err != nil {
+			if cause := context.Cause(leaseCtx); errors.Is(cause, shard.ErrLeaseLost) {
+				return cause
+			}
+			return err
+		}
+		s.Vars.SignedAt, s.Vars.Page = vars.SignedAt, vars.Page
+		s.Vars.Done = s.Vars.SignedAt.After(s.Vars.To)
+		s.Vars.UpdatedAt = Now()
+		if err := t.shardRepo.Save(ctx, s, owner, Now().Add(t.cfg.BackfillShardLease)); err != nil {
+			return err
+		}
+		t.markProgress()
+		t.syncCursor(ctx, logger)
+	}
+	return nil
+}
+
+// syncCursor переносит в курсор последовательной догрузки день самого раннего
+// незагруженного шарда. По нему строятся метрики курсора, и с него продолжит
+// последовательная догрузка, если шарды выключить. Ошибка не прерывает загрузку:
+// курсор догонит следующее сохранение
+func (t *BackToNowAgreementShardsTask) syncCursor(ctx context.Context, logger *zap.Logger) {
+	t.cursorMu.Lock()
+	defer t.cursorMu.Unlock()
+	shards, err := t.shardRepo.List(ctx, BackToNowAgreementTaskName)
+	if err != nil {
+		logger.Warn("BackToNowAgreementShardsTask: serial cursor not updated", zap.Error(err))
+		return
+	}
+	vars, ok := shard.Cursor(shards)
+	if !ok {
+		return
+	}
+	varData := &variable.VariableBackToNowAgreement{
+		ID:   backToNowAgreementVarID,
+		Vars: variable.VarsBackToNowAgreement{SignedAt: vars.SignedAt, Page: vars.Page},
+	}
+	observeCursor(backToNowAgreementVarID, varData.Vars)
+	if err = saveVariable(ctx, t.varRepo, varData.ID, varData); err != nil {
+		logger.Warn("BackToNowAgreementShardsTask: serial cursor not updated", zap.Error(err))
+	}
+}
+
+func (t *BackToNowAgreementShardsTask) heartbeat(ctx context.Context, cancel context.CancelCauseFunc, logger *zap.Logger, id, owner string) {
+	ticker := time.NewTicker(t.cfg.BackfillShardLease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := t.shardRepo.Heartbeat(ctx, id, owner, Now().Add(t.cfg.BackfillShardLease))
+			if errors.Is(err, shard.ErrLeaseLost) {
+				cancel(err)
+				return
+			}
+			if err != nil {
+				// Аренду продлит следующий тик или сохранение курсора
+				logger.Warn("BackToNowAgreementShardsTask: heartbeat error", zap.String("shard", id), zap.Error(err))
+			}
+		}
+	}
+}
+
+// loadPage загружает страницу поиска за день курсора и возвращает следующий курсор.
+// Пустая страница или страница без корректных договоров переводит курсор на следующий день
+func (t *BackToNowAgreementShardsTask) loadPage(ctx context.Context, logger *zap.Logger, vars variable.VarsBackToNowAgreement) (variable.VarsBackToNowAgreement, error) {
+	nextDay := variable.VarsBackToNowAgreement{SignedAt: vars.SignedAt.Add(24 * time.Hour), Page: 1}
+	url, err := search.URL(t.cfg.UrlZakupkiAgreementSearch, t.cfg.SearchParams(search.SortSignedDate).Day(vars.SignedAt, vars.Page))
+	if err != nil {
+		return vars, err
+	}
+	ids, err := fetchAgreementIds(ctx, logger, t.proxies, t.requester, url, agreement.ParseAgreementIds)
+	if err != nil {
+		return vars, err
+	}
+	if len(ids) == 0 {
+		return nextDay, nil
+	}
+	tmp, err := funcWrapper(ctx, logger, noRetryPolicy, NewBtnaManyRequests(t.cfg, ids, t.proxies, t.requester))
+	if err != nil {
+		if strings.Contains(err.Error(), "no correct data, empty") {
+			return nextDay, nil
+		}
+		return vars, err
+	}
+	arrData, ok := tmp.([]*agreement.AgreementParesedData)
+	if !ok {
+		return vars, errors.New("error parse []*model.AgreementParesedData")
+	}
+	if err = t.monitor.Observe(backToNowAgreementVarID, arrData); err != nil {
+		return vars, err
+	}
+	if err = storeAgreements(ctx, logger, t.agreeRepo, t.custRepo, t.suppRepo, t.notifier, arrData); err != nil {
+		return vars, err
+	}
+	if vars.Page >= maxSearchPages {
+		return nextDay, nil
+	}
+	vars.Page++
+	return vars, nil
+}
@@ -0,0 +1,148 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/config"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/tim8842/tender-data-loader/internal/shard"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	"github.com/tim8842/tender-data-loader/internal/variable"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestBackToNowAgreementShardsTask_Process(t *testing.T) {
+	now := time.Date(2025, 6, 11, 12, 0, 0, 0, time.UTC)
+	from := time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	data := []*agreement.AgreementParesedData{
+		{ID: "1", Customer: &customer.Customer{ID: "1"}},
+		{ID: "2", Customer: &customer.Customer{ID: "2"}},
+	}
+	tests := []struct {
+		name       string
+		results    map[string]RetErr
+		acquireErr error
+		saveErr    error
+		saves      int
+		released   bool
+		expected   shard.Vars // курсор после последнего сохранения
+		needErr    bool
+	}{
+		{
+			name: "empty days finish shard",
+			results: map[string]RetErr{
+				"GetVariable": {&variable.VariableBackToNowAgreement{ID: backToNowAgreementVarID}, nil},
+				"GetPage":     {[]byte("page"), nil}, "ParseIDs": {[]string{}, nil},
+			},
+			saves:    2,
+			released: true,
+			expected: shard.Vars{SignedAt: to.AddDate(0, 0, 1), Page: 1, Done: true},
+		},
+		{
+			name: "lease lost on save",
+			results: map[string]RetErr{
+				"GetVariable": {nil, errors.New("not found")},
+				"GetPage":     {[]byte("page"), nil}, "ParseIDs": {[]string{"1", "2"}, nil},
+				"Btna": {data, nil},
+			},
+			saveErr:  shard.ErrLeaseLost,
+			saves:    1,
+			expected: shard.Vars{SignedAt: from, Page: 2},
+		},
+		{
+			name: "page error keeps cursor",
+			results: map[string]RetErr{
+				"GetVariable": {&variable.VariableBackToNowAgreement{ID: backToNowAgreementVarID}, nil},
+				"GetPage":     {nil, errors.New("timeout")},
+			},
+			released: true,
+			needErr:  true,
+		},
+		{
+			name: "acquire error",
+			results: map[string]RetErr{
+				"GetVariable": {&variable.VariableBackToNowAgreement{ID: backToNowAgreementVarID}, nil},
+			},
+			acquireErr: errors.New("db down"),
+			needErr:    true,
+		},
+	}
+	oldFuncWrapper := funcWrapper
+	oldNow := Now
+	defer func() {
+		funcWrapper = oldFuncWrapper
+		Now = oldNow
+	}()
+	cfg := &config.Config{
+		FetchWorkers:              1,
+		UrlZakupkiAgreementSearch: "https://zakupki.gov.ru/epz/contract/search/results.html?contractDateFrom={{from}}&pageNumber={{page}}",
+		BackfillShardWorkers:      1,
+		BackfillShardLease:        time.Minute,
+	}
+	logger := zaptest.NewLogger(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Now = func() time.Time { return now }
+			funcWrapper = mockFuncWrapperFactory(tt.results)
+			s := &shard.Shard{ID: "back_to_now_agreement:2025-06", Vars: shard.Vars{
+				Task: BackToNowAgreementTaskName, From: from, To: to, SignedAt: from, Page: 1,
+			}}
+			shardRepo := new(inmock.MockShardRepo)
+			shardRepo.On("Ensure", mock.Anything, mock.Anything).Return(nil)
+			if tt.acquireErr != nil {
+				shardRepo.On("Acquire", mock.Anything, BackToNowAgreementTaskName, mock.Anything, now, time.Minute).Return(nil, tt.acquireErr)
+			} else {
+				shardRepo.On("Acquire", mock.Anything, BackToNowAgreementTaskName, mock.Anything, now, time.Minute).Return(s, nil).Once()
+				shardRepo.On("Acquire", mock.Anything, BackToNowAgreementTaskName, mock.Anything, now, time.Minute).Return(nil, shard.ErrNoShard)
+			}
+			shardRepo.On("Save", mock.Anything, s, mock.Anything, now.Add(time.Minute)).Return(tt.saveErr)
+			shardRepo.On("Release", mock.Anything, s.ID, mock.Anything).Return(nil)
+			shardRepo.On("List", mock.Anything, BackToNowAgreementTaskName).Return([]*shard.Shard{s}, nil)
+			varRepo := new(inmock.MockGenericRepository[*variable.Variable])
+			varRepo.On("Update", mock.Anything, backToNowAgreementVarID, mock.Anything).Return(nil)
+			agRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+			agRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+			cuRepo := new(inmock.MockGenericRepository[*customer.Customer])
+			cuRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+			suRepo := new(inmock.MockGenericRepository[*supplier.Supplier])
+			suRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+
+			back := NewBackToNowAgreementShardsTask(
+				cfg, agRepo, varRepo, cuRepo, suRepo, shardRepo,
+				nil, nil, nil, nil)
+			err := back.Process(context.Background(), logger)
+			if tt.needErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			shardRepo.AssertNumberOfCalls(t, "Save", tt.saves)
+			if tt.released {
+				shardRepo.AssertCalled(t, "Release", mock.Anything, s.ID, mock.Anything)
+			} else {
+				shardRepo.AssertNotCalled(t, "Release", mock.Anything, s.ID, mock.Anything)
+			}
+			if tt.saves > 0 {
+				assert.Equal(t, tt.expected.SignedAt, s.Vars.SignedAt)
+				assert.Equal(t, tt.expected.Page, s.Vars.Page)
+				assert.Equal(t, tt.expected.Done, s.Vars.Done)
+			}
+			// Последовательный курсор идет за шардом: после планирования и каждого сохранения
+			syncs := tt.saves + 1
+			if tt.saveErr != nil {
+				syncs--
+			}
+			varRepo.AssertNumberOfCalls(t, "Update", syncs)
+			last := varRepo.Calls[len(varRepo.Calls)-1].Arguments.Get(2).(*variable.Variable)
+			assert.Equal(t, s.Vars.SignedAt.Format(time.RFC3339), last.Vars["signed_at"])
+		})
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"path/filepath"
 	"testing"
 	"time"
 
@@ -14,6 +13,7 @@ import (
 	"github.com/tim8842/tender-data-loader/internal/config"
 	"github.com/tim8842/tender-data-loader/internal/customer"
 	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
 	agreementt "github.com/tim8842/tender-data-loader/internal/task/agreement"
 	uagentt "github.com/tim8842/tender-data-loader/internal/task/uagent"
 	variablet "github.com/tim8842/tender-data-loader/internal/task/variable"
@@ -30,13 +30,16 @@ type RetErr struct {
 	Err    error
 }
 
-func mockFuncWrapperFactory(results map[string]RetErr) func(ctx context.Context, logger *zap.Logger, maxRetries int, delay time.Duration, fn pkg.FuncInWrapp) (any, error) {
-	return func(ctx context.Context, logger *zap.Logger, maxRetries int, delay time.Duration, fn pkg.FuncInWrapp) (any, error) {
+func mockFuncWrapperFactory(results map[string]RetErr) func(ctx context.Context, logger *zap.Logger, policy pkg.RetryPolicy, fn pkg.FuncInWrapp) (any, error) {
+	return func(ctx context.Context, logger *zap.Logger, policy pkg.RetryPolicy, fn pkg.FuncInWrapp) (any, error) {
 		switch fn.(type) {
 		case *variablet.GetVariableBackToNowAgreementById:
 			r := results["GetVariable"]
 			return r.Return, r.Err
-		case *uagentt.GetRequest:
+		case *variablet.GetVariableById[variable.VariableRecentAgreement]:
+			r := results["GetVariable"]
+			return r.Return, r.Err
+		case *uagentt.AcquireProxy:
 			r := results["GetProxy"]
 			return r.Return, r.Err
 		case *uagentt.GetPage:
@@ -53,6 +56,15 @@ func mockFuncWrapperFactory(results map[string]RetErr) func(ctx context.Context,
 		}
 	}
 }
+
+// testProxies вызовы пула перехватывает funcWrapper, нужен только сам факт пула
+func testProxies(staticProxy bool) *uagent.ProxyPool {
+	if staticProxy {
+		return nil
+	}
+	return &uagent.ProxyPool{}
+}
+
 func TestBackToNowAgreementTask_Process(t *testing.T) {
 	date, err := parser.ParseFromDateToTime("11.06.2025")
 	date2, err2 := parser.ParseFromDateToTime("10.06.2025")
@@ -335,14 +347,17 @@ func TestBackToNowAgreementTask_Process(t *testing.T) {
 			now:     func() time.Time { return date },
 		},
 	}
-	oldFuncWrapper := funcWrapper
-	oldNow := Now
-	envPath, err := filepath.Abs("../../configs/.env.test")
-	if err != nil {
-		panic(err)
-	}
+	oldFuncWrapper, oldNow, oldLoopPolicy := funcWrapper, Now, loopPolicy
+	defer func() {
+		funcWrapper, Now, loopPolicy = oldFuncWrapper, oldNow, oldLoopPolicy
+	}()
+	// Ошибочные шаги не ждут между попытками
+	loopPolicy = pkg.RetryPolicy{MaxRetries: 2}
 	logger := zaptest.NewLogger(t)
-	cfg, _ := config.LoadConfig(envPath)
+	cfg := &config.Config{
+		FetchWorkers:              1,
+		UrlZakupkiAgreementSearch: "https://zakupki.gov.ru/epz/contract/search/results.html?contractDateFrom={{from}}&pageNumber={{page}}",
+	}
 	for _, tt := range tests {
 		ctx, cancelled := context.WithTimeout(context.Background(), 20*time.Microsecond)
 		Now = tt.now
@@ -352,9 +367,11 @@ func TestBackToNowAgreementTask_Process(t *testing.T) {
 		mockAgRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(tt.mockAgRE)
 		mockVaRepo.On("Update", mock.Anything, "1", mock.Anything).Return(tt.mockVaU1Re)
 		mockCuRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(tt.mockCuRE)
+		mockSuRepo := new(inmock.MockGenericRepository[*supplier.Supplier])
+		mockSuRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
 		mockVaRepo.On("Update", mock.Anything, "back_to_now_agreement", mock.Anything).Return(tt.mockVaU2Re)
 		back := NewBackToNowAgreementTask(
-			cfg, mockAgRepo, mockVaRepo, mockCuRepo, tt.staticProxy)
+			cfg, mockAgRepo, mockVaRepo, mockCuRepo, mockSuRepo, testProxies(tt.staticProxy), nil, nil, nil)
 		funcWrapper = mockFuncWrapperFactory(tt.results)
 		err = back.Process(ctx, logger)
 		if tt.needErr {
@@ -362,11 +379,71 @@ func TestBackToNowAgreementTask_Process(t *testing.T) {
 		} else {
 			assert.NoError(t, err)
 		}
-		defer func() {
-			funcWrapper = oldFuncWrapper
-			Now = oldNow
-			cancelled()
-		}()
+		cancelled()
+	}
+}
 
+// Неудачный шаг не крутится по кругу: после loopPolicy.MaxRetries неудач подряд запуск завершается с ошибкой
+func TestBackToNowAgreementTask_ProcessFailuresBounded(t *testing.T) {
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		results map[string]RetErr
+		proxies *uagent.ProxyPool
+		step    string // шаг, который падает
+	}{
+		{
+			name:    "get variable error",
+			results: map[string]RetErr{"GetVariable": {nil, errors.New("db down")}},
+			step:    "*variable.GetVariableBackToNowAgreementById",
+		},
+		{
+			name: "get proxy error",
+			results: map[string]RetErr{
+				"GetVariable": {&variable.VariableBackToNowAgreement{ID: backToNowAgreementVarID,
+					Vars: variable.VarsBackToNowAgreement{Page: 1, SignedAt: date},
+				}, nil},
+				"GetProxy": {nil, errors.New("non-retryable error: empty pool")},
+			},
+			proxies: testProxies(false),
+			step:    "*uagent.AcquireProxy",
+		},
+		{
+			name: "get page error",
+			results: map[string]RetErr{
+				"GetVariable": {&variable.VariableBackToNowAgreement{ID: backToNowAgreementVarID,
+					Vars: variable.VarsBackToNowAgreement{Page: 1, SignedAt: date},
+				}, nil},
+				"GetPage": {nil, errors.New("non-retryable error: 404")},
+			},
+			step: "*uagent.GetPage",
+		},
+	}
+	oldFuncWrapper, oldNow, oldLoopPolicy := funcWrapper, Now, loopPolicy
+	defer func() {
+		funcWrapper, Now, loopPolicy = oldFuncWrapper, oldNow, oldLoopPolicy
+	}()
+	loopPolicy = pkg.RetryPolicy{MaxRetries: 2}
+	Now = func() time.Time { return date.Add(24 * time.Hour) }
+	cfg := &config.Config{
+		UrlZakupkiAgreementSearch: "https://zakupki.gov.ru/epz/contract/search/results.html?contractDateFrom={{from}}&pageNumber={{page}}",
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := map[string]int{}
+			wrapper := mockFuncWrapperFactory(tt.results)
+			funcWrapper = func(ctx context.Context, logger *zap.Logger, policy pkg.RetryPolicy, fn pkg.FuncInWrapp) (any, error) {
+				calls[fmt.Sprintf("%T", fn)]++
+				return wrapper(ctx, logger, policy, fn)
+			}
+			back := NewBackToNowAgreementTask(
+				cfg, nil, new(inmock.MockGenericRepository[*variable.Variable]), nil, nil, tt.proxies, nil, nil, nil)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err := back.Process(ctx, zaptest.NewLogger(t))
+			assert.Error(t, err)
+			assert.NoError(t, ctx.Err())
+			assert.Equal(t, loopPolicy.MaxRetries+1, calls[tt.step])
+		})
 	}
 }
@@ -0,0 +1,204 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/config"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/fillrate"
+	"github.com/tim8842/tender-data-loader/internal/search"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	agreementt "github.com/tim8842/tender-data-loader/internal/task/agreement"
+	variablet "github.com/tim8842/tender-data-loader/internal/task/variable"
+	"github.com/tim8842/tender-data-loader/internal/uagent"
+	"github.com/tim8842/tender-data-loader/internal/variable"
+	"github.com/tim8842/tender-data-loader/internal/webhook"
+	"github.com/tim8842/tender-data-loader/pkg"
+	"github.com/tim8842/tender-data-loader/pkg/archive"
+	"github.com/tim8842/tender-data-loader/pkg/parser"
+	"github.com/tim8842/tender-data-loader/pkg/request"
+	"go.uber.org/zap"
+)
+
+const backToNowContract44VarID = "back_to_now_contract44"
+
+var errNoContracts44 = errors.New("no correct data, empty")
+
+// BackToNowContract44Task догружает реестр контрактов 44-ФЗ по дням заключения,
+// как BackToNowAgreementTask для 223-ФЗ, но со своим курсором back_to_now_contract44
+type BackToNowContract44Task struct {
+	cfg       *config.Config
+	agreeRepo agreement.IAgreementRepo
+	varRepo   variable.IVariableRepo
+	custRepo  customer.ICustomerRepo
+	suppRepo  supplier.ISupplierRepo
+	proxies   *uagent.ProxyPool
+	requester request.IRequester
+	notifier  *webhook.Dispatcher
+	monitor   *fillrate.Monitor
+}
+
+func NewBackToNowContract44Task(
+	cfg *config.Config,
+	agreeRepo agreement.IAgreementRepo, varRepo variable.IVariableRepo,
+	custRepo customer.ICustomerRepo, suppRepo supplier.ISupplierRepo,
+	proxies *uagent.ProxyPool, requester request.IRequester, notifier *webhook.Dispatcher,
+	monitor *fillrate.Monitor,
+) *BackToNowContract44Task {
+	return &BackToNowContract44Task{
+		cfg: cfg, agreeRepo: agreeRepo, varRepo: varRepo,
+		custRepo: custRepo, suppRepo: suppRepo, proxies: proxies,
+		requester: requester, notifier: notifier, monitor: monitor,
+	}
+}
+
+// Process идет по страницам поиска за день заключения, пока не дойдет до сегодняшнего дня.
+// Курсор сохраняется после каждой страницы
+func (t *BackToNowContract44Task) Process(ctx context.Context, logger *zap.Logger) error {
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if pkg.Stopping(ctx) {
+			return nil
+		}
+		tmp, err := funcWrapper(ctx, logger, dbPolicy, variablet.NewGetVariableById[variable.VariableBackToNowAgreement](t.varRepo, backToNowContract44VarID))
+		if err != nil {
+			return err
+		}
+		varData, ok := tmp.(*variable.VariableBackToNowAgreement)
+		if !ok {
+			return errors.New("parse error *variable.VariableBackToNowAgreement")
+		}
+		varData.ID = backToNowContract44VarID
+		if varData.Vars.Page < 1 {
+			varData.Vars.Page = 1
+		}
+		observeCursor(backToNowContract44VarID, varData.Vars)
+		if !parser.DateOnly(varData.Vars.SignedAt).Before(parser.DateOnly(Now())) {
+			return nil
+		}
+		url, err := t.searchUrl(varData.Vars)
+		if err != nil {
+			return err
+		}
+		ids, err := fetchAgreementIds(ctx, logger, t.proxies, t.requester, url, agreement.ParseContract44Ids)
+		if err != nil {
+			return err
+		}
+		nextDay := len(ids) == 0
+		if !nextDay {
+			arrData, err := fetchContracts44(ctx, logger, t.cfg, ids, t.proxies, t.requester)
+			switch {
+			case errors.Is(err, errNoContracts44):
+				nextDay = true
+			case err != nil:
+				return err
+			default:
+				if err = t.monitor.Observe(backToNowContract44VarID, arrData); err != nil {
+					return err
+				}
+				if err = storeAgreements(ctx, logger, t.agreeRepo, t.custRepo, t.suppRepo, t.notifier, arrData); err != nil {
+					return err
+				}
+				nextDay = varData.Vars.Page >= maxSearchPages
+			}
+		}
+		if nextDay {
+			varData.Vars.SignedAt = varData.Vars.SignedAt.Add(24 * time.Hour)
+			varData.Vars.Page = 1
+		} else {
+			varData.Vars.Page++
+		}
+		if err = saveVariable(ctx, t.varRepo, varData.ID, varData); err != nil {
+			return err
+		}
+	}
+}
+
+// searchUrl адрес страницы поиска контрактов, заключенных в день курсора
+func (t *BackToNowContract44Task) searchUrl(vars variable.VarsBackToNowAgreement) (string, error) {
+	return search.URL(t.cfg.UrlZakupkiContract44Search, t.cfg.SearchParams(search.SortSignedDate).Day(vars.SignedAt, vars.Page))
+}
+
+// fetchContracts44 загружает карточки и объекты закупки контрактов не более чем
+// в FetchWorkers потоков. Контракты с ошибкой загрузки пропускаются
+func fetchContracts44(
+	ctx context.Context, logger *zap.Logger, cfg *config.Config, ids []string,
+	proxies *uagent.ProxyPool, requester request.IRequester,
+) ([]*agreement.AgreementParesedData, error) {
+	workers := cfg.FetchWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+	results := make([]*agreement.AgreementParesedData, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case <-ctx.Done():
+				return
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			}
+			data, err := fetchContract44(ctx, logger, cfg, id, proxies, requester)
+			if err != nil {
+				logger.Error("Contract44 get err", zap.String("id", id), zap.Error(err))
+				return
+			}
+			results[i] = data
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	var res []*agreement.AgreementParesedData
+	for _, data := range results {
+		if data != nil {
+			res = append(res, data)
+		}
+	}
+	if len(res) == 0 {
+		return nil, errNoContracts44
+	}
+	return res, nil
+}
+
+func fetchContract44(
+	ctx context.Context, logger *zap.Logger, cfg *config.Config, id string,
+	proxies *uagent.ProxyPool, requester request.IRequester,
+) (*agreement.AgreementParesedData, error) {
+	body, err := fetchPage(ctx, logger, proxies, requester, cfg.UrlZakupkiContract44GetCardWeb+id, archive.KindContract44)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := funcWrapper(ctx, logger, parsePolicy, agreementt.NewParseData(body, agreement.ParseContract44FromMain))
+	if err != nil {
+		return nil, err
+	}
+	if tmp == nil {
+		return nil, fmt.Errorf("no contract on card %s", id)
+	}
+	data, ok := tmp.(*agreement.AgreementParesedData)
+	if !ok {
+		return nil, errors.New("parse error model.AgreementParesedData")
+	}
+	data.ID = id
+	body, err = fetchPage(ctx, logger, proxies, requester, cfg.UrlZakupkiContract44GetObjectsWeb+id, archive.KindContract44Objects)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = funcWrapper(ctx, logger, parsePolicy, agreementt.NewParseDataInAgreementParesedData(body, agreement.ParseContract44Objects, data)); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,160 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/config"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	agreementt "github.com/tim8842/tender-data-loader/internal/task/agreement"
+	uagentt "github.com/tim8842/tender-data-loader/internal/task/uagent"
+	variablet "github.com/tim8842/tender-data-loader/internal/task/variable"
+	"github.com/tim8842/tender-data-loader/internal/variable"
+	"github.com/tim8842/tender-data-loader/pkg"
+	"github.com/tim8842/tender-data-loader/pkg/parser"
+	"go.uber.org/zap"
+)
+
+// mockContract44Wrapper результаты ParseData отдает по очереди: сначала номера
+// со страницы поиска, затем карточки контрактов
+func mockContract44Wrapper(varData *variable.VariableBackToNowAgreement, parsed []RetErr) func(ctx context.Context, logger *zap.Logger, policy pkg.RetryPolicy, fn pkg.FuncInWrapp) (any, error) {
+	return func(ctx context.Context, logger *zap.Logger, policy pkg.RetryPolicy, fn pkg.FuncInWrapp) (any, error) {
+		switch fn.(type) {
+		case *variablet.GetVariableById[variable.VariableBackToNowAgreement]:
+			return varData, nil
+		case *uagentt.GetPage:
+			return []byte{10}, nil
+		case *agreementt.ParseData:
+			if len(parsed) == 0 {
+				return nil, errors.New("no more parse results")
+			}
+			r := parsed[0]
+			parsed = parsed[1:]
+			return r.Return, r.Err
+		case *agreementt.ParseDataInAgreementParesedData:
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("unexpected subtask type: %T", fn)
+		}
+	}
+}
+
+func TestBackToNowContract44Task_Process(t *testing.T) {
+	now, _ := parser.ParseFromDateToTime("20.06.2025")
+	yesterday := now.AddDate(0, 0, -1)
+	contract := func() *agreement.AgreementParesedData {
+		return &agreement.AgreementParesedData{Law: agreement.LawFZ44, Customer: &customer.Customer{ID: "1"}}
+	}
+	tests := []struct {
+		name          string
+		vars          variable.VarsBackToNowAgreement
+		parsed        []RetErr
+		mockAgRE      error
+		needErr       bool
+		expectUpdates int
+		expectPage    float64
+	}{
+		{
+			name:          "cursor reached today",
+			vars:          variable.VarsBackToNowAgreement{Page: 1, SignedAt: now},
+			expectUpdates: 0,
+		},
+		{
+			name:          "empty search page moves to next day",
+			vars:          variable.VarsBackToNowAgreement{Page: 3, SignedAt: yesterday},
+			parsed:        []RetErr{{[]string{}, nil}},
+			expectUpdates: 1,
+			expectPage:    1,
+		},
+		{
+			name: "all cards failed moves to next day",
+			vars: variable.VarsBackToNowAgreement{Page: 1, SignedAt: yesterday},
+			parsed: []RetErr{
+				{[]string{"1"}, nil},
+				{nil, nil},
+			},
+			expectUpdates: 1,
+			expectPage:    1,
+		},
+		{
+			name: "stored page moves to next page",
+			vars: variable.VarsBackToNowAgreement{Page: 1, SignedAt: yesterday},
+			parsed: []RetErr{
+				{[]string{"1"}, nil},
+				{contract(), nil},
+				{[]string{}, nil},
+			},
+			expectUpdates: 2,
+			expectPage:    1,
+		},
+		{
+			name: "store error keeps cursor",
+			vars: variable.VarsBackToNowAgreement{Page: 1, SignedAt: yesterday},
+			parsed: []RetErr{
+				{[]string{"1"}, nil},
+				{contract(), nil},
+			},
+			mockAgRE:      errors.New("db error"),
+			needErr:       true,
+			expectUpdates: 0,
+		},
+	}
+
+	oldFuncWrapper := funcWrapper
+	oldNow := Now
+	defer func() {
+		funcWrapper = oldFuncWrapper
+		Now = oldNow
+	}()
+	Now = func() time.Time { return now }
+	cfg := &config.Config{FetchWorkers: 1}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			varData := &variable.VariableBackToNowAgreement{ID: backToNowContract44VarID, Vars: tt.vars}
+			funcWrapper = mockContract44Wrapper(varData, tt.parsed)
+			mockAgRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+			mockVaRepo := new(inmock.MockGenericRepository[*variable.Variable])
+			mockCuRepo := new(inmock.MockGenericRepository[*customer.Customer])
+			mockSuRepo := new(inmock.MockGenericRepository[*supplier.Supplier])
+			mockAgRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(tt.mockAgRE)
+			mockCuRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+			mockSuRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+			mockVaRepo.On("Update", mock.Anything, backToNowContract44VarID, mock.Anything).Return(nil)
+
+			task := NewBackToNowContract44Task(cfg, mockAgRepo, mockVaRepo, mockCuRepo, mockSuRepo, nil, nil, nil, nil)
+			err := task.Process(context.Background(), zap.NewNop())
+			if tt.needErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockVaRepo.AssertNumberOfCalls(t, "Update", tt.expectUpdates)
+			if tt.expectUpdates > 0 {
+				last := mockVaRepo.Calls[len(mockVaRepo.Calls)-1].Arguments.Get(2).(*variable.Variable)
+				assert.Equal(t, tt.expectPage, last.Vars["page"])
+			}
+		})
+	}
+}
+
+func TestBackToNowContract44Task_searchUrl(t *testing.T) {
+	day, _ := parser.ParseFromDateToTime("01.02.2024")
+	cfg := &config.Config{
+		UrlZakupkiContract44Search: "https://example.com/search?fz44=on&contractDateFrom={{from}}&contractDateTo={{to}}" +
+			"&priceFromGeneral={{priceFrom}}&sortBy={{sort}}&pageNumber={{page}}",
+		SearchPriceFrom: 100000,
+	}
+	task := &BackToNowContract44Task{cfg: cfg}
+	url, err := task.searchUrl(variable.VarsBackToNowAgreement{SignedAt: day, Page: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/search?fz44=on&contractDateFrom=01.02.2024&contractDateTo=01.02.2024&priceFromGeneral=100000&sortBy=BY_CONTRACT_DATE&pageNumber=2", url)
+}
@@ -0,0 +1,33 @@
+package notice
+
+import (
+	"context"
+
+	"github.com/tim8842/tender-data-loader/internal/notice"
+	"github.com/tim8842/tender-data-loader/pkg/metrics"
+	"go.uber.org/zap"
+)
+
+// Дополняет извещение данными со вспомогательной страницы (лоты, документы)
+
+type ParseDataInNotice struct {
+	data      []byte
+	parseFunc func(ctx context.Context, logger *zap.Logger, data []byte, n *notice.Notice) (any, error)
+	notice    *notice.Notice
+}
+
+func NewParseDataInNotice(
+	data []byte,
+	parseFunc func(ctx context.Context, logger *zap.Logger, data []byte, n *notice.Notice) (any, error),
+	n *notice.Notice,
+) *ParseDataInNotice {
+	return &ParseDataInNotice{data: data, parseFunc: parseFunc, notice: n}
+}
+
+func (t ParseDataInNotice) Process(ctx context.Context, logger *zap.Logger) (any, error) {
+	res, err := t.parseFunc(ctx, logger, t.data, t.notice)
+	if err != nil {
+		metrics.ParseFailures.WithLabelValues(metrics.FuncName(t.parseFunc)).Inc()
+	}
+	return res, err
+}
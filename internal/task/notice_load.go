@@ -0,0 +1,249 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/config"
+	"github.com/tim8842/tender-data-loader/internal/notice"
+	agreementt "github.com/tim8842/tender-data-loader/internal/task/agreement"
+	noticet "github.com/tim8842/tender-data-loader/internal/task/notice"
+	"github.com/tim8842/tender-data-loader/internal/uagent"
+	"github.com/tim8842/tender-data-loader/pkg"
+	"github.com/tim8842/tender-data-loader/pkg/archive"
+	"github.com/tim8842/tender-data-loader/pkg/request"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+const (
+	noticeTaskName = "notice"
+	// noticeBatchSize сколько извещений загружаем и сохраняем за раз
+	noticeBatchSize = 50
+	// noticeScanSize сколько договоров просматриваем за раз в поисках извещений
+	noticeScanSize = 500
+)
+
+// noticeRef извещение, которое нужно загрузить, и договоры, которые на него ссылаются
+type noticeRef struct {
+	ID           string
+	AgreementIds []string
+}
+
+// NoticeTask загружает извещения 223-ФЗ, на которые ссылаются договоры через notice_id.
+// Каждый проход идет по договорам по возрастанию notice_id и берет извещения, которых
+// нет в коллекции notices или в которых не хватает ссылок на договоры
+type NoticeTask struct {
+	cfg        *config.Config
+	agreeRepo  agreement.IAgreementRepo
+	noticeRepo notice.INoticeRepo
+	proxies    *uagent.ProxyPool
+	requester  request.IRequester
+}
+
+func NewNoticeTask(
+	cfg *config.Config, agreeRepo agreement.IAgreementRepo, noticeRepo notice.INoticeRepo,
+	proxies *uagent.ProxyPool, requester request.IRequester,
+) *NoticeTask {
+	return &NoticeTask{
+		cfg: cfg, agreeRepo: agreeRepo, noticeRepo: noticeRepo,
+		proxies: proxies, requester: requester,
+	}
+}
+
+func (t *NoticeTask) Process(ctx context.Context, logger *zap.Logger) error {
+	// Не загрузившиеся в этом проходе извещения повторим при следующем запуске
+	failed := 0
+	after := ""
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if pkg.Stopping(ctx) {
+			return nil
+		}
+		refs, next, err := t.pendingNotices(ctx, after)
+		if err != nil {
+			return err
+		}
+		for len(refs) > 0 {
+			if pkg.Stopping(ctx) {
+				return nil
+			}
+			batch := refs[:min(noticeBatchSize, len(refs))]
+			refs = refs[len(batch):]
+			notices, bad := fetchNotices(ctx, logger, t.cfg, batch, t.proxies, t.requester)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failed += len(bad)
+			if len(notices) == 0 {
+				continue
+			}
+			if err = t.noticeRepo.BulkMergeMany(ctx, notices); err != nil {
+				return err
+			}
+			logger.Info("Извещения сохранены", zap.Int("count", len(notices)))
+		}
+		if next == "" {
+			if failed > 0 {
+				logger.Warn("Часть извещений не загружена", zap.Int("failed", failed))
+			}
+			return nil
+		}
+		after = next
+	}
+}
+
+// pendingNotices берет noticeScanSize договоров 223-ФЗ с notice_id больше after,
+// группирует их по notice_id и оставляет извещения, которых нет в notices или в
+// agreement_ids которых нет части договоров. Возвращает notice_id, с которого
+// продолжать, пустой — договоры кончились
+func (t *NoticeTask) pendingNotices(ctx context.Context, after string) ([]noticeRef, string, error) {
+	pipeline := bson.A{
+		// $gt по строке отсекает и пустой notice_id, и его отсутствие
+		bson.M{"$match": bson.M{
+			"notice_id": bson.M{"$gt": after},
+			"law":       bson.M{"$in": bson.A{agreement.LawFZ223, nil}},
+		}},
+		bson.M{"$sort": bson.M{"notice_id": 1}},
+		bson.M{"$limit": noticeScanSize},
+		bson.M{"$group": bson.M{"_id": "$notice_id", "agreement_ids": bson.M{"$addToSet": "$_id"}}},
+		bson.M{"$sort": bson.M{"_id": 1}},
+		bson.M{"$lookup": bson.M{"from": "notices", "localField": "_id", "foreignField": "_id", "as": "notice"}},
+		bson.M{"$project": bson.M{
+			"agreement_ids": 1,
+			"pending": bson.M{"$not": bson.A{bson.M{"$setIsSubset": bson.A{
+				"$agreement_ids",
+				bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$notice.agreement_ids", 0}}, bson.A{}}},
+			}}}},
+		}},
+	}
+	rows, err := t.agreeRepo.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, "", err
+	}
+	scanned := 0
+	groups := make([]noticeRef, 0, len(rows))
+	pending := make([]bool, 0, len(rows))
+	for _, row := range rows {
+		id, _ := row["_id"].(string)
+		ref := noticeRef{ID: id}
+		if ids, ok := row["agreement_ids"].(bson.A); ok {
+			scanned += len(ids)
+			for _, v := range ids {
+				if s, ok := v.(string); ok {
+					ref.AgreementIds = append(ref.AgreementIds, s)
+				}
+			}
+		}
+		p, _ := row["pending"].(bool)
+		groups = append(groups, ref)
+		pending = append(pending, p)
+	}
+	next := ""
+	if scanned >= noticeScanSize && len(groups) > 0 {
+		// Лимит мог обрезать договоры последнего извещения, его берем в следующей пачке.
+		// Если извещение в пачке одно, обрезанным его и загружаем, иначе курсор встанет
+		if len(groups) > 1 {
+			groups, pending = groups[:len(groups)-1], pending[:len(pending)-1]
+		}
+		next = groups[len(groups)-1].ID
+	}
+	refs := make([]noticeRef, 0, len(groups))
+	for i, ref := range groups {
+		if pending[i] && ref.ID != "" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs, next, nil
+}
+
+// fetchNotices загружает извещения не более чем в FetchWorkers потоков.
+// Возвращает загруженные извещения и ID тех, что загрузить не удалось
+func fetchNotices(
+	ctx context.Context, logger *zap.Logger, cfg *config.Config, refs []noticeRef,
+	proxies *uagent.ProxyPool, requester request.IRequester,
+) ([]*notice.Notice, []string) {
+	workers := cfg.FetchWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+	results := make([]*notice.Notice, len(refs))
+	var wg sync.WaitGroup
+	for i, ref := range refs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case <-ctx.Done():
+				return
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			}
+			n, err := fetchNotice(ctx, logger, cfg, ref.ID, proxies, requester)
+			if err != nil {
+				logger.Error("Notice get err", zap.String("id", ref.ID), zap.Error(err))
+				return
+			}
+			n.AgreementIds = ref.AgreementIds
+			n.LoadedAt = Now()
+			results[i] = n
+		}()
+	}
+	wg.Wait()
+	var notices []*notice.Notice
+	var failed []string
+	for i, n := range results {
+		if n != nil {
+			notices = append(notices, n)
+		} else {
+			failed = append(failed, refs[i].ID)
+		}
+	}
+	return notices, failed
+}
+
+func fetchNotice(
+	ctx context.Context, logger *zap.Logger, cfg *config.Config, id string,
+	proxies *uagent.ProxyPool, requester request.IRequester,
+) (*notice.Notice, error) {
+	body, err := fetchPage(ctx, logger, proxies, requester, cfg.UrlZakupkiNoticeGetCardWeb+id, archive.KindNotice)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := funcWrapper(ctx, logger, parsePolicy, agreementt.NewParseData(body, notice.ParseNoticeFromMain))
+	if err != nil {
+		return nil, err
+	}
+	if tmp == nil {
+		return nil, fmt.Errorf("no notice on page %s", id)
+	}
+	n, ok := tmp.(*notice.Notice)
+	if !ok {
+		return nil, errors.New("parse error *notice.Notice")
+	}
+	n.ID = id
+	pages := []struct {
+		url   string
+		kind  string
+		parse func(context.Context, *zap.Logger, []byte, *notice.Notice) (any, error)
+	}{
+		{cfg.UrlZakupkiNoticeGetLotsWeb + id, archive.KindNoticeLots, notice.ParseNoticeLots},
+		{cfg.UrlZakupkiNoticeGetDocumentsWeb + id, archive.KindNoticeDocuments, notice.ParseNoticeDocuments},
+	}
+	for _, p := range pages {
+		body, err = fetchPage(ctx, logger, proxies, requester, p.url, p.kind)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = funcWrapper(ctx, logger, parsePolicy, noticet.NewParseDataInNotice(body, p.parse, n)); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,155 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/config"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/tim8842/tender-data-loader/internal/notice"
+	agreementt "github.com/tim8842/tender-data-loader/internal/task/agreement"
+	noticet "github.com/tim8842/tender-data-loader/internal/task/notice"
+	uagentt "github.com/tim8842/tender-data-loader/internal/task/uagent"
+	"github.com/tim8842/tender-data-loader/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+func mockNoticeWrapper(card RetErr) func(ctx context.Context, logger *zap.Logger, policy pkg.RetryPolicy, fn pkg.FuncInWrapp) (any, error) {
+	return func(ctx context.Context, logger *zap.Logger, policy pkg.RetryPolicy, fn pkg.FuncInWrapp) (any, error) {
+		switch fn.(type) {
+		case *uagentt.GetPage:
+			return []byte{10}, nil
+		case *agreementt.ParseData:
+			return card.Return, card.Err
+		case *noticet.ParseDataInNotice:
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("unexpected subtask type: %T", fn)
+		}
+	}
+}
+
+func TestNoticeTask_Process(t *testing.T) {
+	now := time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC)
+	pending := []bson.M{{"_id": "18342197", "agreement_ids": bson.A{"1", "2"}, "pending": true}}
+	tests := []struct {
+		name         string
+		rows         []bson.M
+		aggErr       error
+		card         RetErr
+		mergeErr     error
+		needErr      bool
+		expectMerges int
+	}{
+		{
+			name:         "nothing to load",
+			expectMerges: 0,
+		},
+		{
+			name:         "notice stored with agreement links",
+			rows:         pending,
+			card:         RetErr{&notice.Notice{Number: "32514850397"}, nil},
+			expectMerges: 1,
+		},
+		{
+			name:         "complete notice not reloaded",
+			rows:         []bson.M{{"_id": "18342197", "agreement_ids": bson.A{"1", "2"}, "pending": false}},
+			card:         RetErr{&notice.Notice{Number: "32514850397"}, nil},
+			expectMerges: 0,
+		},
+		{
+			name:         "broken notice skipped until next run",
+			rows:         pending,
+			card:         RetErr{nil, nil},
+			expectMerges: 0,
+		},
+		{
+			name:    "aggregate error",
+			aggErr:  errors.New("db error"),
+			needErr: true,
+		},
+		{
+			name:         "store error",
+			rows:         pending,
+			card:         RetErr{&notice.Notice{Number: "32514850397"}, nil},
+			mergeErr:     errors.New("db error"),
+			needErr:      true,
+			expectMerges: 1,
+		},
+	}
+
+	oldFuncWrapper := funcWrapper
+	oldNow := Now
+	defer func() {
+		funcWrapper = oldFuncWrapper
+		Now = oldNow
+	}()
+	Now = func() time.Time { return now }
+	cfg := &config.Config{FetchWorkers: 1}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			funcWrapper = mockNoticeWrapper(tt.card)
+			mockAgRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+			mockAgRepo.On("Aggregate", mock.Anything, mock.Anything).Return(tt.rows, tt.aggErr).Once()
+			mockAgRepo.On("Aggregate", mock.Anything, mock.Anything).Return(nil, nil)
+			mockNoRepo := new(inmock.MockGenericRepository[*notice.Notice])
+			mockNoRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(tt.mergeErr)
+
+			task := NewNoticeTask(cfg, mockAgRepo, mockNoRepo, nil, nil)
+			err := task.Process(context.Background(), zap.NewNop())
+			if tt.needErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockNoRepo.AssertNumberOfCalls(t, "BulkMergeMany", tt.expectMerges)
+			if tt.expectMerges > 0 {
+				stored := mockNoRepo.Calls[0].Arguments.Get(1).([]*notice.Notice)
+				assert.Equal(t, []*notice.Notice{{
+					ID: "18342197", Number: "32514850397", AgreementIds: []string{"1", "2"}, LoadedAt: now,
+				}}, stored)
+			}
+		})
+	}
+}
+
+// Полная пачка договоров двигает курсор, последнее извещение уходит в следующую
+func TestNoticeTask_PendingNoticesCursor(t *testing.T) {
+	ids := make(bson.A, noticeScanSize-1)
+	for i := range ids {
+		ids[i] = fmt.Sprint(i)
+	}
+	first := []bson.M{
+		{"_id": "100", "agreement_ids": ids, "pending": true},
+		{"_id": "200", "agreement_ids": bson.A{"a"}, "pending": true},
+	}
+	second := []bson.M{{"_id": "200", "agreement_ids": bson.A{"a", "b"}, "pending": true}}
+	afterOf := func(pipeline any) any {
+		return pipeline.(bson.A)[0].(bson.M)["$match"].(bson.M)["notice_id"].(bson.M)["$gt"]
+	}
+
+	mockAgRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+	mockAgRepo.On("Aggregate", mock.Anything, mock.MatchedBy(func(p any) bool { return afterOf(p) == "" })).Return(first, nil).Once()
+	mockAgRepo.On("Aggregate", mock.Anything, mock.MatchedBy(func(p any) bool { return afterOf(p) == "100" })).Return(second, nil).Once()
+	task := NewNoticeTask(&config.Config{}, mockAgRepo, nil, nil, nil)
+
+	refs, next, err := task.pendingNotices(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "100", next)
+	assert.Len(t, refs, 1)
+	assert.Equal(t, "100", refs[0].ID)
+
+	refs, next, err = task.pendingNotices(context.Background(), next)
+	assert.NoError(t, err)
+	assert.Equal(t, "", next)
+	assert.Equal(t, []noticeRef{{ID: "200", AgreementIds: []string{"a", "b"}}}, refs)
+	mockAgRepo.AssertExpectations(t)
+}
@@ -0,0 +1,37 @@
+package opendata
+
+import (
+	"context"
+
+	"github.com/tim8842/tender-data-loader/internal/opendata"
+	"go.uber.org/zap"
+)
+
+// Список архивов в источнике открытых данных
+
+type ListArchives struct {
+	source opendata.Source
+}
+
+func NewListArchives(source opendata.Source) *ListArchives {
+	return &ListArchives{source: source}
+}
+
+func (t ListArchives) Process(ctx context.Context, logger *zap.Logger) (any, error) {
+	return t.source.List(ctx, logger)
+}
+
+// Скачивает архив из источника
+
+type FetchArchive struct {
+	source opendata.Source
+	name   string
+}
+
+func NewFetchArchive(source opendata.Source, name string) *FetchArchive {
+	return &FetchArchive{source: source, name: name}
+}
+
+func (t FetchArchive) Process(ctx context.Context, logger *zap.Logger) (any, error) {
+	return t.source.Fetch(ctx, logger, t.name)
+}
@@ -0,0 +1,178 @@
+package task
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/opendata"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	opendatat "github.com/tim8842/tender-data-loader/internal/task/opendata"
+	"github.com/tim8842/tender-data-loader/internal/webhook"
+	"github.com/tim8842/tender-data-loader/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	openDataImportTaskName = "opendata_import"
+	// openDataBatchSize сколько договоров из архива сохраняем за раз
+	openDataBatchSize = 100
+)
+
+// OpenDataImportTask загружает договоры из zip-архивов открытых данных zakupki.
+// Архивы обходятся по имени, загруженные запоминаются в коллекции opendata_archives
+// и повторно не читаются. Договоры, уже загруженные с сайта, сохраняются тем же путем,
+// что и у задач, читающих сайт, остальные откладываются в opendata_agreements
+type OpenDataImportTask struct {
+	source    opendata.Source
+	agreeRepo agreement.IAgreementRepo
+	custRepo  customer.ICustomerRepo
+	suppRepo  supplier.ISupplierRepo
+	archives  opendata.IArchiveRepo
+	staging   opendata.IStagingRepo
+	notifier  *webhook.Dispatcher
+}
+
+func NewOpenDataImportTask(
+	source opendata.Source, agreeRepo agreement.IAgreementRepo,
+	custRepo customer.ICustomerRepo, suppRepo supplier.ISupplierRepo,
+	archives opendata.IArchiveRepo, staging opendata.IStagingRepo, notifier *webhook.Dispatcher,
+) *OpenDataImportTask {
+	return &OpenDataImportTask{
+		source: source, agreeRepo: agreeRepo, custRepo: custRepo, suppRepo: suppRepo,
+		archives: archives, staging: staging, notifier: notifier,
+	}
+}
+
+func (t *OpenDataImportTask) Process(ctx context.Context, logger *zap.Logger) error {
+	tmp, err := funcWrapper(ctx, logger, requestPolicy, opendatat.NewListArchives(t.source))
+	if err != nil {
+		return err
+	}
+	names, ok := tmp.([]string)
+	if !ok {
+		return errors.New("parse error []string")
+	}
+	done, err := t.importedArchives(ctx, names)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if done[opendata.ArchiveID(name)] {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if pkg.Stopping(ctx) {
+			return nil
+		}
+		archive, err := t.importArchive(ctx, logger, name)
+		if err != nil {
+			logger.Error("OpenDataImportTask: archive failed", zap.String("archive", name), zap.Error(err))
+			return err
+		}
+		if archive == nil {
+			continue
+		}
+		if archive.Error != "" {
+			logger.Error("OpenDataImportTask: bad archive skipped", zap.String("archive", name), zap.String("error", archive.Error))
+		} else {
+			logger.Info("OpenDataImportTask: archive imported", zap.String("archive", name),
+				zap.Int("agreements", archive.Agreements), zap.Int("staged", archive.Staged), zap.Int("failed", archive.Failed))
+		}
+		if err = t.archives.BulkMergeMany(ctx, []*opendata.ImportedArchive{archive}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importedArchives ID уже загруженных архивов из names
+func (t *OpenDataImportTask) importedArchives(ctx context.Context, names []string) (map[string]bool, error) {
+	ids := make([]string, len(names))
+	for i, name := range names {
+		ids[i] = opendata.ArchiveID(name)
+	}
+	imported, err := t.archives.List(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[string]bool, len(imported))
+	for _, a := range imported {
+		done[a.ID] = true
+	}
+	return done, nil
+}
+
+// importArchive скачивает архив и сохраняет договоры пачками, возвращает запись о загрузке.
+// Архив, который не скачался, пропускается без записи и будет прочитан в следующий запуск,
+// битый архив записывается с ошибкой. Ошибкой завершаются только сбои базы и отмена ctx
+func (t *OpenDataImportTask) importArchive(ctx context.Context, logger *zap.Logger, name string) (*opendata.ImportedArchive, error) {
+	tmp, err := funcWrapper(ctx, logger, requestPolicy, opendatat.NewFetchArchive(t.source, name))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		logger.Warn("OpenDataImportTask: archive not fetched, skipped until next run", zap.String("archive", name), zap.Error(err))
+		return nil, nil
+	}
+	data, ok := tmp.([]byte)
+	if !ok {
+		return nil, errors.New("parse error []byte")
+	}
+	archive := &opendata.ImportedArchive{ID: opendata.ArchiveID(name), Name: name}
+	batch := make([]*agreement.AgreementParesedData, 0, openDataBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		resolved, staged, err := opendata.ResolveIDs(ctx, t.agreeRepo, t.custRepo, t.suppRepo, batch)
+		if err != nil {
+			return err
+		}
+		if len(resolved) > 0 {
+			if err := storeAgreements(ctx, logger, t.agreeRepo, t.custRepo, t.suppRepo, t.notifier, resolved); err != nil {
+				return err
+			}
+		}
+		if len(staged) > 0 {
+			docs := make([]*opendata.StagedAgreement, len(staged))
+			for i, v := range staged {
+				docs[i] = &opendata.StagedAgreement{AgreementParesedData: *v, Archive: name, StagedAt: Now()}
+			}
+			if err := t.staging.BulkMergeMany(ctx, docs); err != nil {
+				return err
+			}
+		}
+		archive.Agreements += len(resolved)
+		archive.Staged += len(staged)
+		batch = batch[:0]
+		return nil
+	}
+	failed, err := opendata.ParseArchive(ctx, logger, data, func(v *agreement.AgreementParesedData) error {
+		batch = append(batch, v)
+		if len(batch) < openDataBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+	if failed > 0 {
+		logger.Warn("OpenDataImportTask: skipped files", zap.String("archive", name), zap.Int("files", failed))
+	}
+	archive.Failed = failed
+	if errors.Is(err, opendata.ErrBadArchive) {
+		archive.Error, err = err.Error(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	archive.ImportedAt = Now()
+	return archive, nil
+}
@@ -0,0 +1,145 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/tim8842/tender-data-loader/internal/opendata"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+func TestOpenDataImportTask_Process(t *testing.T) {
+	const archiveName = "contract_Sverdlovskaja_obl_20250523_000000_20250523_235959_daily_001.xml.zip"
+	now := time.Date(2025, 5, 24, 3, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		imported bool
+		stored   []*agreement.Agreement // договоры, уже загруженные с сайта
+		expected *opendata.ImportedArchive
+	}{
+		{
+			name: "new agreements are staged",
+			expected: &opendata.ImportedArchive{ID: opendata.ArchiveID(archiveName), Name: archiveName,
+				ImportedAt: now, Staged: 2, Failed: 1},
+		},
+		{
+			name: "known agreement is merged",
+			stored: []*agreement.Agreement{{ID: "site-1", Number: "56680005928250002570000", CustomerId: "492275",
+				Pfid: "pf", SupplierIds: []string{"old"}}},
+			expected: &opendata.ImportedArchive{ID: opendata.ArchiveID(archiveName), Name: archiveName,
+				ImportedAt: now, Agreements: 1, Staged: 1, Failed: 1},
+		},
+		{name: "already imported", imported: true},
+	}
+
+	oldNow := Now
+	defer func() { Now = oldNow }()
+	Now = func() time.Time { return now }
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := opendata.NewSource("../../assets/test/ParseOpenData", nil)
+			assert.NoError(t, err)
+			archives := new(inmock.MockGenericRepository[*opendata.ImportedArchive])
+			var imported []*opendata.ImportedArchive
+			if tt.imported {
+				imported = append(imported, &opendata.ImportedArchive{ID: opendata.ArchiveID(archiveName)})
+			}
+			archives.On("List", mock.Anything, bson.M{"_id": bson.M{"$in": []string{opendata.ArchiveID(archiveName)}}}, mock.Anything).
+				Return(imported, nil)
+			agreeRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+			custRepo := new(inmock.MockGenericRepository[*customer.Customer])
+			suppRepo := new(inmock.MockGenericRepository[*supplier.Supplier])
+			staging := new(inmock.MockGenericRepository[*opendata.StagedAgreement])
+			if tt.expected != nil {
+				agreeRepo.On("List", mock.Anything, bson.M{"number": bson.M{"$in": []string{"56680005928250002570000", "57705013033250001110000"}}}, mock.Anything).
+					Return(tt.stored, nil).Once()
+				staging.On("BulkMergeMany", mock.Anything, mock.MatchedBy(func(docs []*opendata.StagedAgreement) bool {
+					return len(docs) == tt.expected.Staged && docs[len(docs)-1].ID == "57705013033250001110000" &&
+						docs[len(docs)-1].Customer.ID == "7705013033" && docs[len(docs)-1].Archive == archiveName
+				})).Return(nil).Once()
+				archives.On("BulkMergeMany", mock.Anything, []*opendata.ImportedArchive{tt.expected}).Return(nil).Once()
+			}
+			if tt.expected != nil && tt.expected.Agreements > 0 {
+				custRepo.On("List", mock.Anything, bson.M{"_id": bson.M{"$in": []string{"492275"}}}, mock.Anything).
+					Return([]*customer.Customer{}, nil).Once()
+				agreeRepo.On("BulkMergeMany", mock.Anything, mock.MatchedBy(func(a []*agreement.Agreement) bool {
+					return len(a) == 1 && a[0].ID == "site-1" && a[0].CustomerId == "492275" && a[0].Pfid == "pf" &&
+						assert.ObjectsAreEqual([]string{"6670412345_667001001"}, a[0].SupplierIds)
+				})).Return(nil).Once()
+				custRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil).Once()
+				suppRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil).Once()
+			}
+
+			task := NewOpenDataImportTask(source, agreeRepo, custRepo, suppRepo, archives, staging, nil)
+			assert.NoError(t, task.Process(context.Background(), zap.NewNop()))
+			agreeRepo.AssertExpectations(t)
+			custRepo.AssertExpectations(t)
+			suppRepo.AssertExpectations(t)
+			archives.AssertExpectations(t)
+			staging.AssertExpectations(t)
+		})
+	}
+}
+
+// fakeSource архивы в памяти, для имен без данных Fetch возвращает ошибку
+type fakeSource map[string][]byte
+
+func (s fakeSource) List(ctx context.Context, logger *zap.Logger) ([]string, error) {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s fakeSource) Fetch(ctx context.Context, logger *zap.Logger, name string) ([]byte, error) {
+	if s[name] == nil {
+		return nil, errors.New("non-retryable error: 404")
+	}
+	return s[name], nil
+}
+
+// Битый архив записывается с ошибкой, нескачанный пропускается, следующие загружаются
+func TestOpenDataImportTask_ProcessBadArchives(t *testing.T) {
+	good, err := os.ReadFile("../../assets/test/ParseOpenData/contract_Sverdlovskaja_obl_20250523_000000_20250523_235959_daily_001.xml.zip")
+	assert.NoError(t, err)
+	source := fakeSource{"a_corrupt.zip": good[:len(good)/2], "b_missing.zip": nil, "c_good.zip": good}
+	now := time.Date(2025, 5, 24, 3, 0, 0, 0, time.UTC)
+	oldNow := Now
+	defer func() { Now = oldNow }()
+	Now = func() time.Time { return now }
+
+	archives := new(inmock.MockGenericRepository[*opendata.ImportedArchive])
+	archives.On("List", mock.Anything, mock.Anything, mock.Anything).Return([]*opendata.ImportedArchive{}, nil)
+	var recorded []*opendata.ImportedArchive
+	archives.On("BulkMergeMany", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		recorded = append(recorded, args.Get(1).([]*opendata.ImportedArchive)...)
+	}).Return(nil)
+	agreeRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+	agreeRepo.On("List", mock.Anything, mock.Anything, mock.Anything).Return([]*agreement.Agreement{}, nil)
+	staging := new(inmock.MockGenericRepository[*opendata.StagedAgreement])
+	staging.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+
+	task := NewOpenDataImportTask(source, agreeRepo, nil, nil, archives, staging, nil)
+	assert.NoError(t, task.Process(context.Background(), zap.NewNop()))
+	if assert.Len(t, recorded, 2) {
+		assert.Equal(t, "a_corrupt.zip", recorded[0].Name)
+		assert.Contains(t, recorded[0].Error, opendata.ErrBadArchive.Error())
+		assert.Equal(t, "c_good.zip", recorded[1].Name)
+		assert.Empty(t, recorded[1].Error)
+		assert.Equal(t, 2, recorded[1].Staged)
+	}
+}
@@ -0,0 +1,169 @@
+package task
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	agreementt "github.com/tim8842/tender-data-loader/internal/task/agreement"
+	uagentt "github.com/tim8842/tender-data-loader/internal/task/uagent"
+	"github.com/tim8842/tender-data-loader/internal/uagent"
+	"github.com/tim8842/tender-data-loader/internal/variable"
+	"github.com/tim8842/tender-data-loader/internal/webhook"
+	"github.com/tim8842/tender-data-loader/pkg/archive"
+	"github.com/tim8842/tender-data-loader/pkg/metrics"
+	"github.com/tim8842/tender-data-loader/pkg/request"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// maxSearchPages больше страниц поиск zakupki не отдает
+const maxSearchPages = 100
+
+func defaultUserAgentResponse() *uagent.UserAgentResponse {
+	// Если часто таймаут на серваке
+	return &uagent.UserAgentResponse{UserAgent: map[string]any{"agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/96.0.4664.110 Safari/537.36"}, Proxy: map[string]any{"url": nil}}
+}
+
+// getUserAgentResponse возвращает прокси и user-agent для очередного запроса,
+// без пула запросы идут напрямую
+func getUserAgentResponse(ctx context.Context, logger *zap.Logger, proxies *uagent.ProxyPool) (*uagent.UserAgentResponse, error) {
+	if proxies == nil {
+		return defaultUserAgentResponse(), nil
+	}
+	tmp, err := funcWrapper(ctx, logger, requestPolicy, uagentt.NewAcquireProxy(proxies))
+	if err != nil {
+		return nil, err
+	}
+	userAgentResponse, ok := tmp.(*uagent.UserAgentResponse)
+	if !ok {
+		return nil, errors.New("parse error *model.UserAgentResponse")
+	}
+	return userAgentResponse, nil
+}
+
+// fetchAgreementIds получает страницу поиска и достает из нее id договоров парсером parse
+func fetchAgreementIds(
+	ctx context.Context, logger *zap.Logger,
+	proxies *uagent.ProxyPool, requester request.IRequester, url string,
+	parse func(context.Context, *zap.Logger, []byte) (any, error),
+) ([]string, error) {
+	tmpByte, err := fetchPage(ctx, logger, proxies, requester, url, archive.KindSearch)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := funcWrapper(ctx, logger, parsePolicy, agreementt.NewParseData(tmpByte, parse))
+	if err != nil {
+		return nil, err
+	}
+	ids, ok := tmp.([]string)
+	if !ok {
+		return nil, errors.New("parse error []string")
+	}
+	return ids, nil
+}
+
+// fetchPage получает страницу через прокси из пула или напрямую
+func fetchPage(
+	ctx context.Context, logger *zap.Logger,
+	proxies *uagent.ProxyPool, requester request.IRequester, url string, kind string,
+) ([]byte, error) {
+	userAgentResponse, err := getUserAgentResponse(ctx, logger, proxies)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := funcWrapper(ctx, logger, requestPolicy, uagentt.NewGetPage(url, kind, userAgentResponse, proxies, requester))
+	if err != nil {
+		return nil, err
+	}
+	body, ok := tmp.([]byte)
+	if !ok {
+		return nil, errors.New("parse error []byte")
+	}
+	return body, nil
+}
+
+// storeAgreements раскладывает распарсенные данные по коллекциям и сохраняет их.
+// Договоры, которых до этого не было в базе, отдаются диспетчеру вебхуков
+func storeAgreements(
+	ctx context.Context, logger *zap.Logger, agreeRepo agreement.IAgreementRepo, custRepo customer.ICustomerRepo,
+	suppRepo supplier.ISupplierRepo, notifier *webhook.Dispatcher, arrData []*agreement.AgreementParesedData,
+) error {
+	var fresh []*agreement.AgreementParesedData
+	if notifier != nil {
+		var err error
+		if fresh, err = filterNewAgreements(ctx, agreeRepo, arrData); err != nil {
+			return err
+		}
+	}
+	var customers []*customer.Customer
+	var suppliers []*supplier.Supplier
+	var agreements []*agreement.Agreement
+	for _, v := range arrData {
+		a, c, s := agreement.ParseAgreementDataToModels(v)
+		customers = append(customers, c)
+		suppliers = supplier.Merge(suppliers, s...)
+		agreements = append(agreements, a)
+	}
+	if err := agreeRepo.BulkMergeMany(ctx, agreements); err != nil {
+		return err
+	}
+	if err := custRepo.BulkMergeMany(ctx, customers); err != nil {
+		return err
+	}
+	if err := suppRepo.BulkMergeMany(ctx, suppliers); err != nil {
+		return err
+	}
+	// Ошибка рассылки не должна останавливать загрузку
+	if err := notifier.Notify(ctx, logger, fresh); err != nil {
+		logger.Warn("Ошибка рассылки вебхуков", zap.Error(err))
+	}
+	return nil
+}
+
+// filterNewAgreements оставляет договоры, которых еще нет в базе
+func filterNewAgreements(
+	ctx context.Context, agreeRepo agreement.IAgreementRepo, arrData []*agreement.AgreementParesedData,
+) ([]*agreement.AgreementParesedData, error) {
+	ids := make([]string, len(arrData))
+	for i, v := range arrData {
+		ids[i] = v.ID
+	}
+	stored, err := agreeRepo.List(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[string]bool, len(stored))
+	for _, a := range stored {
+		exists[a.ID] = true
+	}
+	var fresh []*agreement.AgreementParesedData
+	for _, v := range arrData {
+		if !exists[v.ID] {
+			fresh = append(fresh, v)
+		}
+	}
+	return fresh, nil
+}
+
+// observeCursor выставляет метрики курсора догрузки
+func observeCursor(task string, vars variable.VarsBackToNowAgreement) {
+	metrics.CursorDate.WithLabelValues(task).Set(float64(vars.SignedAt.Unix()))
+	metrics.CursorPage.WithLabelValues(task).Set(float64(vars.Page))
+}
+
+type convertibleToVariable interface {
+	ConvertToVariable() (variable.Variable, error)
+}
+
+// saveVariable сохраняет курсор задачи в коллекцию variables
+func saveVariable(ctx context.Context, varRepo variable.IVariableRepo, id string, data convertibleToVariable) error {
+	vData, err := data.ConvertToVariable()
+	if err != nil {
+		return err
+	}
+	return varRepo.Update(ctx, id, &vData)
+}
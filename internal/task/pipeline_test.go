@@ -0,0 +1,61 @@
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	"github.com/tim8842/tender-data-loader/internal/webhook"
+	"github.com/tim8842/tender-data-loader/pkg"
+	"go.uber.org/zap"
+)
+
+func TestStoreAgreements_NotifiesNewOnly(t *testing.T) {
+	ctx := context.Background()
+	arrData := []*agreement.AgreementParesedData{
+		{ID: "1", Customer: &customer.Customer{ID: "c1"}},
+		{ID: "2", Customer: &customer.Customer{ID: "c2"}},
+	}
+	mockAgRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+	mockAgRepo.On("List", mock.Anything, mock.Anything, mock.Anything).Return([]*agreement.Agreement{{ID: "2"}}, nil)
+	mockAgRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+	mockCuRepo := new(inmock.MockGenericRepository[*customer.Customer])
+	mockCuRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+	mockSuRepo := new(inmock.MockGenericRepository[*supplier.Supplier])
+	mockSuRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+	subRepo := new(inmock.MockGenericRepository[*webhook.Subscription])
+	// Подписка на обоих заказчиков, событие должно быть только по новому договору
+	subRepo.On("List", mock.Anything, mock.Anything, mock.Anything).Return([]*webhook.Subscription{
+		{ID: "s1", URL: "http://localhost/hook", CustomerIds: []string{"c1", "c2"}},
+	}, nil)
+	deadRepo := new(inmock.MockGenericRepository[*webhook.DeadLetter])
+	deadRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+	// Очередь на одно событие: второе ушло бы в dead letter
+	notifier := webhook.NewDispatcher(subRepo, deadRepo, nil, pkg.RetryPolicy{}, 1)
+
+	err := storeAgreements(ctx, zap.NewNop(), mockAgRepo, mockCuRepo, mockSuRepo, notifier, arrData)
+
+	assert.NoError(t, err)
+	subRepo.AssertNumberOfCalls(t, "List", 1)
+	deadRepo.AssertNotCalled(t, "BulkMergeMany", mock.Anything, mock.Anything)
+}
+
+func TestStoreAgreements_WithoutNotifier(t *testing.T) {
+	mockAgRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+	mockAgRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+	mockCuRepo := new(inmock.MockGenericRepository[*customer.Customer])
+	mockCuRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+	mockSuRepo := new(inmock.MockGenericRepository[*supplier.Supplier])
+	mockSuRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+
+	err := storeAgreements(context.Background(), zap.NewNop(), mockAgRepo, mockCuRepo, mockSuRepo, nil,
+		[]*agreement.AgreementParesedData{{ID: "1", Customer: &customer.Customer{ID: "c1"}}})
+
+	assert.NoError(t, err)
+	mockAgRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,42 @@
+package task
+
+import (
+	"time"
+
+	"github.com/tim8842/tender-data-loader/pkg"
+	"github.com/tim8842/tender-data-loader/pkg/request"
+)
+
+var (
+	// requestPolicy для запросов к zakupki и сервису user agent:
+	// повторяем 5xx и таймауты с растущей задержкой, 404 и прочее — сразу ошибка
+	requestPolicy = pkg.RetryPolicy{
+		MaxRetries: 3,
+		BaseDelay:  2 * time.Second,
+		MaxDelay:   30 * time.Second,
+		Multiplier: 2,
+		Jitter:     0.2,
+		Retryable:  request.IsRetryable,
+	}
+	// dbPolicy для чтения переменных из базы
+	dbPolicy = pkg.RetryPolicy{
+		MaxRetries: 3,
+		BaseDelay:  time.Second,
+		MaxDelay:   10 * time.Second,
+		Multiplier: 2,
+		Jitter:     0.2,
+	}
+	// parsePolicy — парсинг того же ответа повторять бессмысленно
+	parsePolicy = pkg.RetryPolicy{}
+	// loopPolicy пауза между шагами догрузки после неудачного шага
+	// и сколько неудач подряд терпеть, прежде чем завершить запуск с ошибкой
+	loopPolicy = pkg.RetryPolicy{
+		MaxRetries: 5,
+		BaseDelay:  5 * time.Second,
+		MaxDelay:   time.Minute,
+		Multiplier: 2,
+		Jitter:     0.2,
+	}
+	// noRetryPolicy для составных шагов, которые повторяют запросы внутри себя
+	noRetryPolicy = pkg.RetryPolicy{}
+)
@@ -0,0 +1,146 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/config"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/fillrate"
+	"github.com/tim8842/tender-data-loader/internal/search"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	variablet "github.com/tim8842/tender-data-loader/internal/task/variable"
+	"github.com/tim8842/tender-data-loader/internal/uagent"
+	"github.com/tim8842/tender-data-loader/internal/variable"
+	"github.com/tim8842/tender-data-loader/internal/webhook"
+	"github.com/tim8842/tender-data-loader/pkg"
+	"github.com/tim8842/tender-data-loader/pkg/parser"
+	"github.com/tim8842/tender-data-loader/pkg/request"
+	"go.uber.org/zap"
+)
+
+const recentAgreementVarID = "recent_agreement"
+
+// RecentAgreementTask периодически перечитывает договоры, обновленные за последние
+// N дней, чтобы подхватить опубликованные и измененные после прохода BackToNowAgreementTask.
+// Поиск идет без фильтра по дате заключения с сортировкой по дате обновления: свежие
+// изменения на первых страницах, обход останавливается на первой странице старше since.
+type RecentAgreementTask struct {
+	cfg       *config.Config
+	agreeRepo agreement.IAgreementRepo
+	varRepo   variable.IVariableRepo
+	custRepo  customer.ICustomerRepo
+	suppRepo  supplier.ISupplierRepo
+	proxies   *uagent.ProxyPool
+	requester request.IRequester
+	notifier  *webhook.Dispatcher
+	monitor   *fillrate.Monitor
+}
+
+func NewRecentAgreementTask(
+	cfg *config.Config,
+	agreeRepo agreement.IAgreementRepo, varRepo variable.IVariableRepo,
+	custRepo customer.ICustomerRepo, suppRepo supplier.ISupplierRepo,
+	proxies *uagent.ProxyPool, requester request.IRequester, notifier *webhook.Dispatcher,
+	monitor *fillrate.Monitor,
+) *RecentAgreementTask {
+	return &RecentAgreementTask{
+		cfg: cfg, agreeRepo: agreeRepo, varRepo: varRepo,
+		custRepo: custRepo, suppRepo: suppRepo, proxies: proxies,
+		requester: requester, notifier: notifier, monitor: monitor,
+	}
+}
+
+// Process выполняет один проход, повторные запуски задаются расписанием в StartTasks
+func (t *RecentAgreementTask) Process(ctx context.Context, logger *zap.Logger) error {
+	return t.pass(ctx, logger)
+}
+
+// pass проходит страницы поиска, пока не встретит страницу без договоров,
+// обновленных после Since. Курсор сохраняется после каждой страницы.
+func (t *RecentAgreementTask) pass(ctx context.Context, logger *zap.Logger) error {
+	tmp, err := funcWrapper(ctx, logger, dbPolicy, variablet.NewGetVariableById[variable.VariableRecentAgreement](t.varRepo, recentAgreementVarID))
+	if err != nil {
+		return err
+	}
+	varData, ok := tmp.(*variable.VariableRecentAgreement)
+	if !ok {
+		return errors.New("parse error *variable.VariableRecentAgreement")
+	}
+	varData.ID = recentAgreementVarID
+	if varData.Vars.Page < 1 || varData.Vars.Since.IsZero() {
+		varData.Vars.Page = 1
+		varData.Vars.Since = parser.DateOnly(Now()).AddDate(0, 0, -t.cfg.RecentAgreementDays)
+	}
+	logger.Info("RecentAgreementTask: pass started",
+		zap.Time("since", varData.Vars.Since), zap.Int("page", varData.Vars.Page))
+
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if pkg.Stopping(ctx) {
+			return nil
+		}
+		url, err := t.searchUrl(varData.Vars.Page)
+		if err != nil {
+			return err
+		}
+		ids, err := fetchAgreementIds(ctx, logger, t.proxies, t.requester, url, agreement.ParseAgreementIds)
+		if err != nil {
+			return err
+		}
+		done := len(ids) == 0
+		if !done {
+			tmp, err = funcWrapper(ctx, logger, noRetryPolicy, NewBtnaManyRequests(t.cfg, ids, t.proxies, t.requester))
+			if err != nil && !strings.Contains(err.Error(), "no correct data, empty") {
+				return err
+			}
+			if err == nil {
+				arrData, ok := tmp.([]*agreement.AgreementParesedData)
+				if !ok {
+					return errors.New("error parse []*model.AgreementParesedData")
+				}
+				if err = t.monitor.Observe(recentAgreementVarID, arrData); err != nil {
+					return err
+				}
+				if err = storeAgreements(ctx, logger, t.agreeRepo, t.custRepo, t.suppRepo, t.notifier, arrData); err != nil {
+					return err
+				}
+				// Страница отсортирована по дате обновления, дальше только более старые договоры
+				done = !hasUpdatedSince(arrData, varData.Vars.Since)
+			}
+			done = done || varData.Vars.Page >= maxSearchPages
+		}
+		if done {
+			varData.Vars.Page = 1
+			varData.Vars.Since = time.Time{}
+			varData.Vars.LastSyncAt = Now()
+			logger.Info("RecentAgreementTask: pass finished")
+			return saveVariable(ctx, t.varRepo, varData.ID, varData)
+		}
+		varData.Vars.Page++
+		if err = saveVariable(ctx, t.varRepo, varData.ID, varData); err != nil {
+			return err
+		}
+	}
+}
+
+// searchUrl адрес страницы поиска без ограничения по дате заключения
+func (t *RecentAgreementTask) searchUrl(page int) (string, error) {
+	params := t.cfg.SearchParams(search.SortUpdateDate)
+	params.Page = page
+	return search.URL(t.cfg.UrlZakupkiAgreementSearch, params)
+}
+
+func hasUpdatedSince(arrData []*agreement.AgreementParesedData, since time.Time) bool {
+	for _, v := range arrData {
+		if !v.UpdatedAt.Before(since) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/config"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/fillrate"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	"github.com/tim8842/tender-data-loader/internal/variable"
+	"github.com/tim8842/tender-data-loader/pkg/parser"
+	"go.uber.org/zap"
+)
+
+func TestRecentAgreementTask_pass(t *testing.T) {
+	now, _ := parser.ParseFromDateToTime("20.06.2025")
+	fresh := now.AddDate(0, 0, -1)
+	old := now.AddDate(0, 0, -30)
+	tests := []struct {
+		name          string
+		results       map[string]RetErr
+		mockAgRE      error
+		watchFillRate bool
+		needErr       bool
+		expectUpdates int
+		expectPage    float64
+	}{
+		{
+			name: "old data finishes pass",
+			results: map[string]RetErr{
+				"GetVariable": {&variable.VariableRecentAgreement{Vars: variable.VarsRecentAgreement{Page: 1}}, nil},
+				"GetPage":     {[]byte{10}, nil}, "ParseIDs": {[]string{"1"}, nil},
+				"Btna": {[]*agreement.AgreementParesedData{
+					{ID: "1", UpdatedAt: old, Customer: &customer.Customer{ID: "1"}},
+				}, nil},
+			},
+			expectUpdates: 1,
+			expectPage:    1,
+		},
+		{
+			name: "fresh data runs until page limit",
+			results: map[string]RetErr{
+				"GetVariable": {&variable.VariableRecentAgreement{Vars: variable.VarsRecentAgreement{Page: 98, Since: old}}, nil},
+				"GetPage":     {[]byte{10}, nil}, "ParseIDs": {[]string{"1"}, nil},
+				"Btna": {[]*agreement.AgreementParesedData{
+					{ID: "1", UpdatedAt: fresh, Customer: &customer.Customer{ID: "1"}},
+				}, nil},
+			},
+			expectUpdates: 3,
+			expectPage:    1,
+		},
+		{
+			name: "empty page finishes pass",
+			results: map[string]RetErr{
+				"GetVariable": {&variable.VariableRecentAgreement{Vars: variable.VarsRecentAgreement{Page: 3, Since: old}}, nil},
+				"GetPage":     {[]byte{10}, nil}, "ParseIDs": {[]string{}, nil},
+			},
+			expectUpdates: 1,
+			expectPage:    1,
+		},
+		{
+			name: "store error keeps cursor",
+			results: map[string]RetErr{
+				"GetVariable": {&variable.VariableRecentAgreement{Vars: variable.VarsRecentAgreement{Page: 1}}, nil},
+				"GetPage":     {[]byte{10}, nil}, "ParseIDs": {[]string{"1"}, nil},
+				"Btna": {[]*agreement.AgreementParesedData{
+					{ID: "1", UpdatedAt: fresh, Customer: &customer.Customer{ID: "1"}},
+				}, nil},
+			},
+			mockAgRE:      errors.New("db error"),
+			needErr:       true,
+			expectUpdates: 0,
+		},
+		{
+			name: "low fill rate stops pass before store",
+			results: map[string]RetErr{
+				"GetVariable": {&variable.VariableRecentAgreement{Vars: variable.VarsRecentAgreement{Page: 1}}, nil},
+				"GetPage":     {[]byte{10}, nil}, "ParseIDs": {[]string{"1"}, nil},
+				"Btna": {[]*agreement.AgreementParesedData{
+					{ID: "1", UpdatedAt: fresh, Customer: &customer.Customer{ID: "1"}},
+				}, nil},
+			},
+			watchFillRate: true,
+			needErr:       true,
+			expectUpdates: 0,
+		},
+		{
+			name: "get variable error",
+			results: map[string]RetErr{
+				"GetVariable": {nil, errors.New("db error")},
+			},
+			needErr:       true,
+			expectUpdates: 0,
+		},
+	}
+
+	oldFuncWrapper := funcWrapper
+	oldNow := Now
+	defer func() {
+		funcWrapper = oldFuncWrapper
+		Now = oldNow
+	}()
+	Now = func() time.Time { return now }
+	cfg := &config.Config{RecentAgreementDays: 7}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			funcWrapper = mockFuncWrapperFactory(tt.results)
+			mockAgRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+			mockVaRepo := new(inmock.MockGenericRepository[*variable.Variable])
+			mockCuRepo := new(inmock.MockGenericRepository[*customer.Customer])
+			mockAgRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(tt.mockAgRE)
+			mockCuRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+			mockSuRepo := new(inmock.MockGenericRepository[*supplier.Supplier])
+			mockSuRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+			mockVaRepo.On("Update", mock.Anything, recentAgreementVarID, mock.Anything).Return(nil)
+
+			var monitor *fillrate.Monitor
+			if tt.watchFillRate {
+				monitor = fillrate.NewMonitor(zap.NewNop(), 0.5, 1, 10)
+			}
+			task := NewRecentAgreementTask(cfg, mockAgRepo, mockVaRepo, mockCuRepo, mockSuRepo, nil, nil, nil, monitor)
+			err := task.pass(context.Background(), zap.NewNop())
+			if tt.needErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.watchFillRate {
+				assert.ErrorIs(t, err, fillrate.ErrLowFillRate)
+				mockAgRepo.AssertNotCalled(t, "BulkMergeMany", mock.Anything, mock.Anything)
+			}
+			mockVaRepo.AssertNumberOfCalls(t, "Update", tt.expectUpdates)
+			if tt.expectUpdates > 0 {
+				last := mockVaRepo.Calls[len(mockVaRepo.Calls)-1].Arguments.Get(2).(*variable.Variable)
+				assert.Equal(t, tt.expectPage, last.Vars["page"])
+				assert.NotEmpty(t, last.Vars["last_sync_at"])
+			}
+		})
+	}
+}
+
+func TestRecentAgreementTask_searchUrl(t *testing.T) {
+	cfg := &config.Config{
+		UrlZakupkiAgreementSearch: "https://example.com/search?fz223=on&stage={{statuses}}" +
+			"&contractDateFrom={{from}}&sortBy={{sort}}&pageNumber={{page}}&recordsPerPage=_{{perPage}}",
+		SearchStatuses: []string{"0", "1"},
+		SearchPerPage:  50,
+	}
+	task := &RecentAgreementTask{cfg: cfg}
+	url, err := task.searchUrl(3)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/search?fz223=on&stage=0&stage=1&sortBy=BY_UPDATE_DATE&pageNumber=3&recordsPerPage=_50", url)
+}
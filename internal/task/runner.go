@@ -2,27 +2,99 @@ package task
 
 import (
 	"context"
+	"time"
 
 	"github.com/tim8842/tender-data-loader/internal/agreement"
 	"github.com/tim8842/tender-data-loader/internal/config"
 	"github.com/tim8842/tender-data-loader/internal/customer"
+	"github.com/tim8842/tender-data-loader/internal/fillrate"
+	"github.com/tim8842/tender-data-loader/internal/notice"
+	"github.com/tim8842/tender-data-loader/internal/opendata"
+	"github.com/tim8842/tender-data-loader/internal/shard"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	"github.com/tim8842/tender-data-loader/internal/uagent"
 	"github.com/tim8842/tender-data-loader/internal/variable"
+	"github.com/tim8842/tender-data-loader/internal/webhook"
 	"github.com/tim8842/tender-data-loader/pkg"
+	"github.com/tim8842/tender-data-loader/pkg/request"
 	"go.uber.org/zap"
 )
 
-func StartTasks(
+// SetupTasks создает раннер и регистрирует в нем задачи загрузки.
+// Без пула прокси (proxies == nil) запросы идут напрямую
+func SetupTasks(
 	ctx context.Context, logger *zap.Logger, cfg *config.Config,
 	agreeRepo agreement.IAgreementRepo, varRepo variable.IVariableRepo,
-	custRepo customer.ICustomerRepo,
-) {
-	runner := pkg.NewTaskRunner(ctx, logger, 1)
+	custRepo customer.ICustomerRepo, suppRepo supplier.ISupplierRepo, noticeRepo notice.INoticeRepo,
+	shardRepo shard.IShardRepo, archiveRepo opendata.IArchiveRepo, stagingRepo opendata.IStagingRepo, proxies *uagent.ProxyPool, requester request.IRequester, notifier *webhook.Dispatcher,
+	monitor *fillrate.Monitor,
+) (*pkg.TaskRunner, error) {
+	// По воркеру на каждую задачу: догрузка 44-ФЗ не должна ждать догрузку 223-ФЗ
+	runner := pkg.NewTaskRunner(ctx, logger, 5)
+	monitor.SetPauser(runner)
 
-	// Регистрируем задачу
-	runner.RegisterTask("back_to_now_agreement", NewBackToNowAgreementTask(cfg, agreeRepo, varRepo, custRepo, true))
+	backToNowSchedule, err := pkg.Cron(cfg.BackToNowAgreementSchedule)
+	if err != nil {
+		return nil, err
+	}
 
-	runner.Start()      // Запускаем воркеры
-	defer runner.Stop() // Гарантированно остановим
+	// Регистрируем задачи. Догрузка по шардам регистрируется под тем же именем,
+	// чтобы пауза, готовность и заполненность работали для нее так же
+	var backToNow pkg.TaskHandler = NewBackToNowAgreementTask(cfg, agreeRepo, varRepo, custRepo, suppRepo, proxies, requester, notifier, monitor)
+	if cfg.BackfillShardWorkers > 0 {
+		backToNow = NewBackToNowAgreementShardsTask(cfg, agreeRepo, varRepo, custRepo, suppRepo, shardRepo, proxies, requester, notifier, monitor)
+	}
+	runner.RegisterTask(
+		BackToNowAgreementTaskName, backToNow,
+		pkg.WithSchedule(backToNowSchedule), pkg.WithJitter(cfg.TaskJitter), pkg.WithRunOnStart(),
+	)
+	runner.RegisterTask(
+		recentAgreementVarID,
+		NewRecentAgreementTask(cfg, agreeRepo, varRepo, custRepo, suppRepo, proxies, requester, notifier, monitor),
+		pkg.WithSchedule(pkg.Every(cfg.RecentAgreementInterval)), pkg.WithJitter(cfg.TaskJitter), pkg.WithRunOnStart(),
+	)
+	if cfg.Contract44Enabled() {
+		runner.RegisterTask(
+			backToNowContract44VarID,
+			NewBackToNowContract44Task(cfg, agreeRepo, varRepo, custRepo, suppRepo, proxies, requester, notifier, monitor),
+			pkg.WithSchedule(backToNowSchedule), pkg.WithJitter(cfg.TaskJitter), pkg.WithRunOnStart(),
+		)
+	}
+	if cfg.NoticeEnabled() {
+		noticeSchedule, err := pkg.Cron(cfg.NoticeSchedule)
+		if err != nil {
+			return nil, err
+		}
+		runner.RegisterTask(
+			noticeTaskName,
+			NewNoticeTask(cfg, agreeRepo, noticeRepo, proxies, requester),
+			pkg.WithSchedule(noticeSchedule), pkg.WithJitter(cfg.TaskJitter), pkg.WithRunOnStart(),
+		)
+	}
+	if cfg.OpenDataSource != "" {
+		openDataSchedule, err := pkg.Cron(cfg.OpenDataSchedule)
+		if err != nil {
+			return nil, err
+		}
+		// Архивы качаются напрямую, в архив страниц они не пишутся
+		source, err := opendata.NewSource(cfg.OpenDataSource, &request.Requester{})
+		if err != nil {
+			return nil, err
+		}
+		runner.RegisterTask(
+			openDataImportTaskName,
+			NewOpenDataImportTask(source, agreeRepo, custRepo, suppRepo, archiveRepo, stagingRepo, notifier),
+			pkg.WithSchedule(openDataSchedule), pkg.WithJitter(cfg.TaskJitter), pkg.WithRunOnStart(),
+		)
+	}
+	return runner, nil
+}
+
+// StartTasks запускает раннер и держит его до отмены контекста, после чего дает
+// задачам timeout на то, чтобы сохранить текущую пачку
+func StartTasks(ctx context.Context, runner *pkg.TaskRunner, timeout time.Duration) error {
+	runner.Start() // Запускаем воркеры и расписания
 
-	runner.Enqueue("back_to_now_agreement")
+	<-ctx.Done()
+	return runner.Shutdown(timeout)
 }
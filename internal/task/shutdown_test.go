@@ -0,0 +1,151 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/config"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/tim8842/tender-data-loader/internal/supplier"
+	agreementt "github.com/tim8842/tender-data-loader/internal/task/agreement"
+	uagentt "github.com/tim8842/tender-data-loader/internal/task/uagent"
+	variablet "github.com/tim8842/tender-data-loader/internal/task/variable"
+	"github.com/tim8842/tender-data-loader/internal/variable"
+	"github.com/tim8842/tender-data-loader/pkg"
+	"go.uber.org/zap"
+)
+
+// shutdownWrapper отдает курсор и страницу поиска, а загрузку пачки передает batch
+func shutdownWrapper(signedAt time.Time, batch func(ctx context.Context, fn pkg.FuncInWrapp) (any, error)) func(ctx context.Context, logger *zap.Logger, policy pkg.RetryPolicy, fn pkg.FuncInWrapp) (any, error) {
+	var searched atomic.Bool
+	return func(ctx context.Context, logger *zap.Logger, policy pkg.RetryPolicy, fn pkg.FuncInWrapp) (any, error) {
+		switch fn.(type) {
+		case *variablet.GetVariableBackToNowAgreementById:
+			return &variable.VariableBackToNowAgreement{
+				ID: backToNowAgreementVarID, Vars: variable.VarsBackToNowAgreement{Page: 1, SignedAt: signedAt},
+			}, nil
+		case *uagentt.GetPage:
+			// Первая страница — поиск, остальные — карточки договоров из пачки
+			if !searched.Swap(true) {
+				return []byte{10}, nil
+			}
+			return batch(ctx, fn)
+		case *agreementt.ParseData:
+			return []string{"1", "2"}, nil
+		case *SBtnaManyRequests:
+			return batch(ctx, fn)
+		default:
+			return nil, errors.New("unexpected subtask")
+		}
+	}
+}
+
+func TestBackToNowAgreementTask_Shutdown(t *testing.T) {
+	now := time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		// batch загрузка пачки: started сообщает о ее начале, release — разрешение закончить
+		batch        func(started chan<- struct{}, release <-chan struct{}) func(ctx context.Context, fn pkg.FuncInWrapp) (any, error)
+		timeout      time.Duration
+		expectErr    error
+		expectStored int
+		expectPage   float64
+	}{
+		{
+			name: "batch finished before deadline is stored, cursor moves once",
+			batch: func(started chan<- struct{}, release <-chan struct{}) func(ctx context.Context, fn pkg.FuncInWrapp) (any, error) {
+				return func(ctx context.Context, fn pkg.FuncInWrapp) (any, error) {
+					close(started)
+					<-release
+					return []*agreement.AgreementParesedData{
+						{ID: "1", Customer: &customer.Customer{ID: "1"}},
+						{ID: "2", Customer: &customer.Customer{ID: "1"}},
+					}, nil
+				}
+			},
+			timeout:      5 * time.Second,
+			expectStored: 1,
+			expectPage:   2,
+		},
+		{
+			name: "batch cut by deadline is discarded, cursor stays",
+			batch: func(started chan<- struct{}, release <-chan struct{}) func(ctx context.Context, fn pkg.FuncInWrapp) (any, error) {
+				var once atomic.Bool
+				return func(ctx context.Context, fn pkg.FuncInWrapp) (any, error) {
+					if _, ok := fn.(*SBtnaManyRequests); ok {
+						// Настоящая пачка: карточки договоров качаются через funcWrapper
+						return fn.Process(ctx, zap.NewNop())
+					}
+					if !once.Swap(true) {
+						close(started)
+					}
+					<-ctx.Done()
+					return nil, ctx.Err()
+				}
+			},
+			timeout:   50 * time.Millisecond,
+			expectErr: pkg.ErrShutdownTimeout,
+		},
+	}
+
+	oldFuncWrapper := funcWrapper
+	oldNow := Now
+	defer func() {
+		funcWrapper = oldFuncWrapper
+		Now = oldNow
+	}()
+	Now = func() time.Time { return now }
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started, release := make(chan struct{}), make(chan struct{})
+			funcWrapper = shutdownWrapper(now.AddDate(0, 0, -3), tt.batch(started, release))
+			mockAgRepo := new(inmock.MockGenericRepository[*agreement.Agreement])
+			mockVaRepo := new(inmock.MockGenericRepository[*variable.Variable])
+			mockCuRepo := new(inmock.MockGenericRepository[*customer.Customer])
+			mockSuRepo := new(inmock.MockGenericRepository[*supplier.Supplier])
+			mockAgRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+			mockCuRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+			mockSuRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+			mockVaRepo.On("Update", mock.Anything, backToNowAgreementVarID, mock.Anything).Return(nil)
+
+			cfg := &config.Config{FetchWorkers: 2}
+			runner := pkg.NewTaskRunner(context.Background(), zap.NewNop(), 1)
+			runner.RegisterTask(BackToNowAgreementTaskName,
+				NewBackToNowAgreementTask(cfg, mockAgRepo, mockVaRepo, mockCuRepo, mockSuRepo, nil, nil, nil, nil))
+			runner.Start()
+			assert.NoError(t, runner.Trigger(BackToNowAgreementTaskName))
+			select {
+			case <-started:
+			case <-time.After(time.Second):
+				t.Fatal("batch did not start")
+			}
+
+			// Сигнал остановки приходит посреди пачки
+			shutdown := make(chan error, 1)
+			go func() { shutdown <- runner.Shutdown(tt.timeout) }()
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+
+			select {
+			case err := <-shutdown:
+				assert.ErrorIs(t, err, tt.expectErr)
+			case <-time.After(5 * time.Second):
+				t.Fatal("runner did not stop")
+			}
+			mockAgRepo.AssertNumberOfCalls(t, "BulkMergeMany", tt.expectStored)
+			mockVaRepo.AssertNumberOfCalls(t, "Update", tt.expectStored)
+			if tt.expectStored > 0 {
+				last := mockVaRepo.Calls[len(mockVaRepo.Calls)-1].Arguments.Get(2).(*variable.Variable)
+				assert.Equal(t, tt.expectPage, last.Vars["page"])
+			}
+		})
+	}
+}
@@ -2,41 +2,78 @@ package uagent
 
 import (
 	"context"
+	"errors"
+	"strconv"
+	"time"
 
 	"github.com/tim8842/tender-data-loader/internal/uagent"
+	"github.com/tim8842/tender-data-loader/pkg/archive"
+	"github.com/tim8842/tender-data-loader/pkg/metrics"
 	"github.com/tim8842/tender-data-loader/pkg/request"
 	"go.uber.org/zap"
 )
 
-type GetRequest struct {
-	url string
+// Берет прокси и UserAgent из пула
+
+type AcquireProxy struct {
+	pool *uagent.ProxyPool
 }
 
-func NewGetRequest(url string) *GetRequest {
-	return &GetRequest{url: url}
+func NewAcquireProxy(pool *uagent.ProxyPool) *AcquireProxy {
+	return &AcquireProxy{pool: pool}
 }
 
-func (t GetRequest) Process(ctx context.Context, logger *zap.Logger) (any, error) {
-	data, ok := uagent.GetUserAgent(ctx, t.url, logger, &request.Requester{})
-	if ok != nil {
-		return nil, ok
-	}
-	return data, ok
+func (t AcquireProxy) Process(ctx context.Context, logger *zap.Logger) (any, error) {
+	return t.pool.Acquire(ctx, logger)
 }
 
-// Гет запрос с проски и UserAgent
+// Гет запрос с проски и UserAgent, результат сообщается в пул
 
 type GetPage struct {
 	url               string
+	kind              string // вид страницы для архива
 	userAgentResponse *uagent.UserAgentResponse
+	pool              *uagent.ProxyPool
+	requester         request.IRequester
 }
 
-func NewGetPage(url string, userAgentResponse *uagent.UserAgentResponse) *GetPage {
-	return &GetPage{url: url, userAgentResponse: userAgentResponse}
+func NewGetPage(
+	url string, kind string, userAgentResponse *uagent.UserAgentResponse,
+	pool *uagent.ProxyPool, requester request.IRequester,
+) *GetPage {
+	if requester == nil {
+		requester = &request.Requester{}
+	}
+	return &GetPage{url: url, kind: kind, userAgentResponse: userAgentResponse, pool: pool, requester: requester}
 }
 
 func (t GetPage) Process(ctx context.Context, logger *zap.Logger) (any, error) {
-	req := &request.Requester{}
-	data, err := uagent.GetPage(ctx, logger, t.url, t.userAgentResponse, req)
+	// Прокси и метрики оцениваем по сетевой части запроса, без ожидания лимитера
+	ctx, timing := request.WithTiming(archive.WithKind(ctx, t.kind))
+	start := time.Now()
+	data, err := uagent.GetPage(ctx, logger, t.url, t.userAgentResponse, t.requester)
+	elapsed, ok := timing.Network()
+	if !ok {
+		elapsed = time.Since(start)
+	}
+	t.pool.Report(t.userAgentResponse, elapsed, err)
+	observeFetch(t.kind, t.userAgentResponse, elapsed, err)
 	return data, err
 }
+
+func observeFetch(kind string, userAgentResponse *uagent.UserAgentResponse, elapsed time.Duration, err error) {
+	proxy := "direct"
+	if userAgentResponse != nil && userAgentResponse.Proxy["url"] != nil && userAgentResponse.Proxy["url"] != "" {
+		proxy = "proxy"
+	}
+	code := "200"
+	if err != nil {
+		code = "error"
+		var statusErr *request.StatusError
+		if errors.As(err, &statusErr) {
+			code = strconv.Itoa(statusErr.StatusCode)
+		}
+	}
+	metrics.FetchRequests.WithLabelValues(kind, code, proxy).Inc()
+	metrics.FetchDuration.WithLabelValues(kind, proxy).Observe(elapsed.Seconds())
+}
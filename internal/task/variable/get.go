@@ -34,3 +34,29 @@ func (t GetVariableBackToNowAgreementById) Process(ctx context.Context, logger *
 		return nil, ok
 	}
 }
+
+// GetVariableById читает переменную и приводит ее к типизированной модели T
+type GetVariableById[T any] struct {
+	variableRepo variable.IVariableRepo
+	id           string
+}
+
+func NewGetVariableById[T any](variableRepo variable.IVariableRepo, id string) *GetVariableById[T] {
+	return &GetVariableById[T]{variableRepo: variableRepo, id: id}
+}
+
+func (t GetVariableById[T]) Process(ctx context.Context, logger *zap.Logger) (any, error) {
+	data, err := t.variableRepo.GetByID(ctx, t.id)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var model T
+	if err = json.Unmarshal(b, &model); err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
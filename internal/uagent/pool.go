@@ -0,0 +1,272 @@
+package uagent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/pkg/request"
+	"go.uber.org/zap"
+)
+
+var ErrNoProxy = errors.New("no proxy available")
+
+const (
+	// latencyWeight вес нового замера в скользящем среднем задержки
+	latencyWeight = 0.3
+	// refillInterval неполный пул догружается в фоне не чаще раза в интервал:
+	// сервис может отдавать меньше уникальных прокси, чем нужно пулу
+	refillInterval = time.Minute
+	// refillTimeout сколько фоновая догрузка ждет сервис прокси
+	refillTimeout = 30 * time.Second
+)
+
+// ProxyStats статистика одного прокси в пуле
+type ProxyStats struct {
+	ID                  int        `json:"id"`
+	ProxyURL            string     `json:"proxy_url"`
+	UserAgent           string     `json:"user_agent"`
+	Successes           int64      `json:"successes"`
+	Failures            int64      `json:"failures"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	AvgLatencyMs        int64      `json:"avg_latency_ms"`
+	Score               float64    `json:"score"`
+	LastUsedAt          *time.Time `json:"last_used_at,omitempty"`
+}
+
+// PoolStats состояние пула прокси
+type PoolStats struct {
+	Enabled bool         `json:"enabled"`
+	Size    int          `json:"size"`
+	Target  int          `json:"target"`
+	Evicted int64        `json:"evicted"`
+	Proxies []ProxyStats `json:"proxies"`
+}
+
+type proxyEntry struct {
+	resp       *UserAgentResponse
+	stats      ProxyStats
+	avgLatency time.Duration
+	lastUsed   time.Time
+}
+
+// score доля успешных запросов (со сглаживанием), деленная на задержку в секундах + 1
+func (e *proxyEntry) score() float64 {
+	rate := float64(e.stats.Successes+1) / float64(e.stats.Successes+e.stats.Failures+2)
+	return rate / (1 + e.avgLatency.Seconds())
+}
+
+// ProxyPool держит заранее загруженные прокси из сервиса URL_GET_PROXY,
+// выдает самый здоровый и выкидывает те, что перестали работать
+type ProxyPool struct {
+	mu          sync.Mutex
+	fillMu      sync.Mutex
+	url         string
+	requester   request.IRequester
+	size        int
+	maxFailures int
+	entries     []*proxyEntry
+	evicted     int64
+	lastFill    time.Time // окончание последней догрузки
+	refilling   bool      // идет фоновая догрузка
+	now         func() time.Time
+}
+
+func NewProxyPool(url string, requester request.IRequester, size int, maxFailures int) *ProxyPool {
+	if size < 1 {
+		size = 1
+	}
+	if maxFailures < 1 {
+		maxFailures = 1
+	}
+	return &ProxyPool{
+		url: url, requester: requester, size: size, maxFailures: maxFailures,
+		now: time.Now,
+	}
+}
+
+// Fill догружает пул до нужного размера, повторяющиеся прокси пропускаются
+func (p *ProxyPool) Fill(ctx context.Context, logger *zap.Logger) error {
+	p.fillMu.Lock()
+	defer p.fillMu.Unlock()
+	var lastErr error
+	for attempts := 0; p.Len() < p.size && attempts < 2*p.size; attempts++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		resp, err := GetUserAgent(ctx, p.url, logger, p.requester)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if !p.add(resp) {
+			logger.Debug("Прокси уже есть в пуле", zap.String("proxy", proxyURL(resp)))
+		}
+	}
+	p.mu.Lock()
+	p.lastFill = p.now()
+	p.mu.Unlock()
+	if p.Len() == 0 {
+		if lastErr != nil {
+			return lastErr
+		}
+		return ErrNoProxy
+	}
+	return nil
+}
+
+func (p *ProxyPool) add(resp *UserAgentResponse) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	url := proxyURL(resp)
+	for _, e := range p.entries {
+		if proxyURL(e.resp) == url {
+			return false
+		}
+	}
+	agent, _ := resp.UserAgent["agent"].(string)
+	p.entries = append(p.entries, &proxyEntry{
+		resp:  resp,
+		stats: ProxyStats{ID: resp.ID, ProxyURL: url, UserAgent: agent},
+	})
+	return true
+}
+
+// Len количество прокси в пуле
+func (p *ProxyPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+// Acquire возвращает прокси с лучшей оценкой, при равной — дольше всех не использованный.
+// Пустой пул сначала догружается, неполный догружается в фоне, не задерживая запрос
+func (p *ProxyPool) Acquire(ctx context.Context, logger *zap.Logger) (*UserAgentResponse, error) {
+	if p.Len() == 0 {
+		if err := p.Fill(ctx, logger); err != nil && p.Len() == 0 {
+			return nil, err
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.entries) < p.size {
+		p.refillLocked(ctx, logger)
+	}
+	var best *proxyEntry
+	for _, e := range p.entries {
+		if best == nil || e.score() > best.score() ||
+			(e.score() == best.score() && e.lastUsed.Before(best.lastUsed)) {
+			best = e
+		}
+	}
+	if best == nil {
+		return nil, ErrNoProxy
+	}
+	best.lastUsed = p.now()
+	return best.resp, nil
+}
+
+// refillLocked запускает фоновую догрузку, если она не идет и прошлая была давно.
+// Вызывается под p.mu
+func (p *ProxyPool) refillLocked(ctx context.Context, logger *zap.Logger) {
+	if p.refilling || p.now().Sub(p.lastFill) < refillInterval {
+		return
+	}
+	p.refilling = true
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refillTimeout)
+	go func() {
+		defer cancel()
+		if err := p.Fill(ctx, logger); err != nil {
+			logger.Warn("Ошибка фоновой догрузки пула прокси", zap.Error(err))
+		}
+		p.mu.Lock()
+		p.refilling = false
+		p.mu.Unlock()
+	}()
+}
+
+// Report учитывает результат запроса через прокси. 403 и 429 выкидывают прокси сразу,
+// другие ошибки — после maxFailures неудач подряд
+func (p *ProxyPool) Report(resp *UserAgentResponse, latency time.Duration, err error) {
+	if p == nil || resp == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	idx := -1
+	for i, e := range p.entries {
+		if e.resp == resp {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return
+	}
+	e := p.entries[idx]
+	if !isProxyFailure(err) {
+		e.stats.Successes++
+		e.stats.ConsecutiveFailures = 0
+		if e.avgLatency == 0 {
+			e.avgLatency = latency
+		} else {
+			e.avgLatency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(e.avgLatency))
+		}
+		return
+	}
+	e.stats.Failures++
+	e.stats.ConsecutiveFailures++
+	var statusErr *request.StatusError
+	blocked := errors.As(err, &statusErr) &&
+		(statusErr.StatusCode == http.StatusForbidden || statusErr.StatusCode == http.StatusTooManyRequests)
+	if blocked || e.stats.ConsecutiveFailures >= p.maxFailures {
+		p.entries = append(p.entries[:idx], p.entries[idx+1:]...)
+		p.evicted++
+	}
+}
+
+// isProxyFailure ответ 404 и другие 4xx значит, что прокси отработал
+func isProxyFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var statusErr *request.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 ||
+			statusErr.StatusCode == http.StatusForbidden ||
+			statusErr.StatusCode == http.StatusProxyAuthRequired ||
+			statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// Stats возвращает состояние пула, прокси отсортированы по оценке
+func (p *ProxyPool) Stats() PoolStats {
+	if p == nil {
+		return PoolStats{Proxies: []ProxyStats{}}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	res := PoolStats{Enabled: true, Size: len(p.entries), Target: p.size, Evicted: p.evicted,
+		Proxies: make([]ProxyStats, 0, len(p.entries))}
+	for _, e := range p.entries {
+		st := e.stats
+		st.AvgLatencyMs = e.avgLatency.Milliseconds()
+		st.Score = e.score()
+		if !e.lastUsed.IsZero() {
+			lastUsed := e.lastUsed
+			st.LastUsedAt = &lastUsed
+		}
+		res.Proxies = append(res.Proxies, st)
+	}
+	sort.SliceStable(res.Proxies, func(i, j int) bool { return res.Proxies[i].Score > res.Proxies[j].Score })
+	return res
+}
+
+func proxyURL(resp *UserAgentResponse) string {
+	url, _ := resp.Proxy["url"].(string)
+	return url
+}
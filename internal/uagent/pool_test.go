@@ -0,0 +1,127 @@
+package uagent_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/tim8842/tender-data-loader/internal/uagent"
+	"github.com/tim8842/tender-data-loader/pkg/request"
+	"go.uber.org/zap"
+)
+
+func proxyJSON(id string) []byte {
+	return []byte(`{"id":` + id + `,"proxy":{"url":"http://proxy` + id + `"},"user_agent":{"agent":"agent` + id + `"}}`)
+}
+
+func newTestPool(t *testing.T, size, maxFailures int, responses ...[]byte) *uagent.ProxyPool {
+	mockReq := new(inmock.MockRequester)
+	for _, r := range responses {
+		mockReq.On("Get", mock.Anything, mock.Anything, "proxy-service", 5*time.Second, mock.Anything).
+			Return(r, nil).Once()
+	}
+	pool := uagent.NewProxyPool("proxy-service", mockReq, size, maxFailures)
+	assert.NoError(t, pool.Fill(context.Background(), zap.NewNop()))
+	return pool
+}
+
+func TestProxyPool_FillSkipsDuplicates(t *testing.T) {
+	pool := newTestPool(t, 2, 3, proxyJSON("1"), proxyJSON("1"), proxyJSON("2"))
+
+	stats := pool.Stats()
+	assert.True(t, stats.Enabled)
+	assert.Equal(t, 2, stats.Size)
+	assert.ElementsMatch(t, []string{"http://proxy1", "http://proxy2"},
+		[]string{stats.Proxies[0].ProxyURL, stats.Proxies[1].ProxyURL})
+}
+
+func TestProxyPool_FillError(t *testing.T) {
+	mockReq := new(inmock.MockRequester)
+	mockReq.On("Get", mock.Anything, mock.Anything, "proxy-service", 5*time.Second, mock.Anything).
+		Return([]byte(nil), errors.New("service down"))
+	pool := uagent.NewProxyPool("proxy-service", mockReq, 2, 3)
+
+	_, err := pool.Acquire(context.Background(), zap.NewNop())
+	assert.EqualError(t, err, "service down")
+}
+
+func TestProxyPool_AcquireHealthiest(t *testing.T) {
+	pool := newTestPool(t, 2, 3, proxyJSON("1"), proxyJSON("2"))
+	ctx := context.Background()
+
+	first, err := pool.Acquire(ctx, zap.NewNop())
+	assert.NoError(t, err)
+	second, err := pool.Acquire(ctx, zap.NewNop())
+	assert.NoError(t, err)
+	// при равной оценке выдаются по очереди
+	assert.NotSame(t, first, second)
+
+	pool.Report(first, 100*time.Millisecond, errors.New("timeout"))
+	pool.Report(second, 100*time.Millisecond, nil)
+	for i := 0; i < 3; i++ {
+		got, err := pool.Acquire(ctx, zap.NewNop())
+		assert.NoError(t, err)
+		assert.Same(t, second, got)
+	}
+}
+
+// Неполный пул не ходит в сервис на каждой выдаче
+func TestProxyPool_AcquireDoesNotRefillEveryCall(t *testing.T) {
+	mockReq := new(inmock.MockRequester)
+	mockReq.On("Get", mock.Anything, mock.Anything, "proxy-service", 5*time.Second, mock.Anything).
+		Return(proxyJSON("1"), nil)
+	pool := uagent.NewProxyPool("proxy-service", mockReq, 3, 3)
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		_, err := pool.Acquire(ctx, zap.NewNop())
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, pool.Len())
+	// Одна синхронная догрузка пустого пула: 2*size попыток
+	mockReq.AssertNumberOfCalls(t, "Get", 6)
+}
+
+func TestProxyPool_Eviction(t *testing.T) {
+	tests := []struct {
+		name    string
+		errs    []error
+		evicted bool
+	}{
+		{"403 сразу", []error{&request.StatusError{StatusCode: 403}}, true},
+		{"429 сразу", []error{&request.StatusError{StatusCode: 429}}, true},
+		{"ошибки подряд", []error{errors.New("a"), errors.New("b")}, true},
+		{"успех сбрасывает счетчик", []error{errors.New("a"), nil, errors.New("b")}, false},
+		{"404 не вина прокси", []error{&request.StatusError{StatusCode: 404}, &request.StatusError{StatusCode: 404}}, false},
+		{"отмена не учитывается", []error{context.Canceled, context.Canceled}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := newTestPool(t, 1, 2, proxyJSON("1"))
+			resp, err := pool.Acquire(context.Background(), zap.NewNop())
+			assert.NoError(t, err)
+			for _, e := range tt.errs {
+				pool.Report(resp, time.Millisecond, e)
+			}
+			stats := pool.Stats()
+			if tt.evicted {
+				assert.Equal(t, 0, stats.Size)
+				assert.Equal(t, int64(1), stats.Evicted)
+			} else {
+				assert.Equal(t, 1, stats.Size)
+				assert.Equal(t, int64(0), stats.Evicted)
+			}
+		})
+	}
+}
+
+func TestProxyPool_NilStats(t *testing.T) {
+	var pool *uagent.ProxyPool
+	stats := pool.Stats()
+	assert.False(t, stats.Enabled)
+	assert.Empty(t, stats.Proxies)
+}
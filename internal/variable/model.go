@@ -28,8 +28,27 @@ type VariableBackToNowAgreement struct {
 }
 
 func (t VariableBackToNowAgreement) ConvertToVariable() (Variable, error) {
+	return convertToVariable(t)
+}
+
+// VarsRecentAgreement - курсор задачи перечитывания последних договоров
+type VarsRecentAgreement struct {
+	Page       int       `bson:"page,omitempty" json:"page"`
+	Since      time.Time `bson:"since,omitempty" json:"since"`               // нижняя граница даты обновления в текущем проходе
+	LastSyncAt time.Time `bson:"last_sync_at,omitempty" json:"last_sync_at"` // время окончания последнего полного прохода
+}
+
+type VariableRecentAgreement struct {
+	ID   string              `bson:"_id,omitempty" json:"id"`
+	Vars VarsRecentAgreement `bson:"vars" json:"vars"`
+}
+
+func (t VariableRecentAgreement) ConvertToVariable() (Variable, error) {
+	return convertToVariable(t)
+}
 
-	jsonData, err := json.Marshal(t)
+func convertToVariable(src any) (Variable, error) {
+	jsonData, err := json.Marshal(src)
 	if err != nil {
 		return Variable{}, fmt.Errorf("failed to marshal source struct: %w", err)
 	}
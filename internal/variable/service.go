@@ -6,16 +6,26 @@ import (
 	"go.uber.org/zap"
 )
 
+// baseVariables переменные, которые должны существовать до запуска задач
+func baseVariables() []Variable {
+	return []Variable{
+		{ID: "back_to_now_agreement", Vars: map[string]any{"page": 50, "signed_at": "2011-02-02T00:00:00Z"}},
+		{ID: "recent_agreement", Vars: map[string]any{"page": 1}},
+		// Реестр контрактов 44-ФЗ ведется с 2014 года
+		{ID: "back_to_now_contract44", Vars: map[string]any{"page": 1, "signed_at": "2014-01-01T00:00:00Z"}},
+	}
+}
+
 func CreateBaseVariables(ctx context.Context, logger *zap.Logger, varRepo IVariableRepo) error {
-	modVar := Variable{ID: "back_to_now_agreement", Vars: map[string]any{"page": 50, "signed_at": "2011-02-02T00:00:00Z"}}
-	_, err := varRepo.GetByID(ctx, modVar.ID)
-	if err != nil {
-		err = varRepo.Create(ctx, &modVar)
+	for _, modVar := range baseVariables() {
+		_, err := varRepo.GetByID(ctx, modVar.ID)
 		if err != nil {
-			logger.Error("Ошибка в создании базовы переменных " + err.Error())
+			err = varRepo.Create(ctx, &modVar)
+			if err != nil {
+				logger.Error("Ошибка в создании базовы переменных " + err.Error())
+				return err
+			}
 		}
-		return err
 	}
 	return nil
-
 }
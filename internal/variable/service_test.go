@@ -40,3 +40,16 @@ func TestCreateBase(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateBase_CreatesMissing(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	mockVarR := new(inmock.MockGenericRepository[*variable.Variable])
+	mockVarR.On("GetByID", mock.Anything, "back_to_now_agreement").Return(&variable.Variable{}, nil).Once()
+	mockVarR.On("GetByID", mock.Anything, "recent_agreement").Return(&variable.Variable{}, errors.New("not found")).Once()
+	mockVarR.On("Create", mock.Anything, &variable.Variable{ID: "recent_agreement", Vars: map[string]any{"page": 1}}).Return(nil).Once()
+	mockVarR.On("GetByID", mock.Anything, "back_to_now_contract44").Return(&variable.Variable{}, nil).Once()
+
+	assert.NoError(t, variable.CreateBaseVariables(ctx, logger, mockVarR))
+	mockVarR.AssertExpectations(t)
+}
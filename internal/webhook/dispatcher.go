@@ -0,0 +1,238 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/pkg"
+	"github.com/tim8842/tender-data-loader/pkg/request"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderID        = "X-Webhook-Id"
+
+	DefaultQueueSize = 1000
+	// DefaultLaneSize сколько событий ждут доставки у одной подписки
+	DefaultLaneSize = 100
+)
+
+var (
+	errQueueFull = errors.New("очередь доставки переполнена")
+	errStopped   = errors.New("доставка остановлена: сервис завершает работу")
+)
+
+// DefaultPolicy повторяем 5xx, 429 и сетевые ошибки, на 4xx сразу в dead letter
+func DefaultPolicy(maxRetries int) pkg.RetryPolicy {
+	return pkg.RetryPolicy{
+		MaxRetries: maxRetries,
+		BaseDelay:  time.Second,
+		MaxDelay:   time.Minute,
+		Multiplier: 2,
+		Jitter:     0.2,
+		Retryable:  request.IsRetryable,
+	}
+}
+
+// Sign подпись тела запроса: "sha256=" + hex(HMAC-SHA256(secret, body))
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type delivery struct {
+	id    string
+	sub   *Subscription
+	event string
+	body  []byte
+}
+
+// Dispatcher подбирает подписки под новые договоры и доставляет события в фоне.
+// Nil-диспетчер ничего не делает, так задачи работают без вебхуков
+type Dispatcher struct {
+	subRepo  ISubscriptionRepo
+	deadRepo IDeadLetterRepo
+	client   *http.Client
+	policy   pkg.RetryPolicy
+	queue    chan *delivery
+}
+
+func NewDispatcher(
+	subRepo ISubscriptionRepo, deadRepo IDeadLetterRepo,
+	client *http.Client, policy pkg.RetryPolicy, queueSize int,
+) *Dispatcher {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if queueSize < 1 {
+		queueSize = DefaultQueueSize
+	}
+	return &Dispatcher{
+		subRepo: subRepo, deadRepo: deadRepo,
+		client: client, policy: policy,
+		queue: make(chan *delivery, queueSize),
+	}
+}
+
+// Run доставляет события из очереди до отмены контекста. У каждой подписки своя
+// очередь и своя горутина: медленный подписчик с его повторами не задерживает
+// остальных. После отмены все, что не успели доставить, сохраняется в dead letter,
+// и только потом Run возвращается
+func (d *Dispatcher) Run(ctx context.Context, logger *zap.Logger) {
+	lanes := make(map[string]chan *delivery)
+	var wg sync.WaitGroup
+	defer func() {
+		for _, lane := range lanes {
+			close(lane)
+		}
+		wg.Wait()
+		d.drain(ctx, logger)
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-d.queue:
+			lane, ok := lanes[job.sub.ID]
+			if !ok {
+				lane = make(chan *delivery, DefaultLaneSize)
+				lanes[job.sub.ID] = lane
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					d.runLane(ctx, logger, lane)
+				}()
+			}
+			select {
+			case lane <- job:
+			default:
+				logger.Warn("Очередь подписки переполнена", zap.String("id", job.id), zap.String("subscription", job.sub.ID))
+				d.bury(ctx, logger, job, errQueueFull)
+			}
+		}
+	}
+}
+
+// runLane доставляет события одной подписки по порядку
+func (d *Dispatcher) runLane(ctx context.Context, logger *zap.Logger, lane <-chan *delivery) {
+	for job := range lane {
+		if ctx.Err() != nil {
+			d.bury(ctx, logger, job, errStopped)
+			continue
+		}
+		d.deliver(ctx, logger, job)
+	}
+}
+
+// drain сохраняет в dead letter события, которые остались в общей очереди
+func (d *Dispatcher) drain(ctx context.Context, logger *zap.Logger) {
+	for {
+		select {
+		case job := <-d.queue:
+			d.bury(ctx, logger, job, errStopped)
+		default:
+			return
+		}
+	}
+}
+
+// Notify ставит в очередь события по новым договорам для подходящих подписок
+func (d *Dispatcher) Notify(ctx context.Context, logger *zap.Logger, data []*agreement.AgreementParesedData) error {
+	if d == nil || len(data) == 0 {
+		return nil
+	}
+	subs, err := d.subRepo.List(ctx, bson.M{"active": true})
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, v := range data {
+		a, c, _ := agreement.ParseAgreementDataToModels(v)
+		for _, sub := range subs {
+			matched := sub.Matches(a, c)
+			if len(matched) == 0 {
+				continue
+			}
+			event := &Event{
+				ID: sub.ID + "_" + a.ID, Type: EventAgreementCreated,
+				SubscriptionId: sub.ID, CreatedAt: now, Matched: matched,
+				Agreement: a, Customer: c,
+			}
+			body, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			job := &delivery{id: event.ID, sub: sub, event: event.Type, body: body}
+			select {
+			case d.queue <- job:
+			default:
+				logger.Warn("Очередь вебхуков переполнена", zap.String("id", job.id))
+				d.bury(ctx, logger, job, errQueueFull)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, logger *zap.Logger, job *delivery) {
+	_, err := pkg.RetryWithPolicy(ctx, logger, d.policy, &post{client: d.client, job: job})
+	if err != nil {
+		logger.Warn("Вебхук не доставлен", zap.String("id", job.id), zap.String("url", job.sub.URL), zap.Error(err))
+		d.bury(ctx, logger, job, err)
+		return
+	}
+	logger.Info("Вебхук доставлен", zap.String("id", job.id), zap.String("url", job.sub.URL))
+}
+
+// bury сохраняет недоставленное событие в dead letter
+func (d *Dispatcher) bury(ctx context.Context, logger *zap.Logger, job *delivery, cause error) {
+	dead := &DeadLetter{
+		ID: job.id, SubscriptionId: job.sub.ID, URL: job.sub.URL,
+		Event: job.event, Payload: string(job.body), Error: cause.Error(), FailedAt: time.Now(),
+	}
+	// Контекст задачи может быть уже отменен, запись в dead letter не должна из-за этого теряться
+	if err := d.deadRepo.BulkMergeMany(context.WithoutCancel(ctx), []*DeadLetter{dead}); err != nil {
+		logger.Error("Не удалось сохранить dead letter", zap.String("id", job.id), zap.Error(err))
+	}
+}
+
+// post одна попытка доставки, подходит для pkg.RetryWithPolicy
+type post struct {
+	client *http.Client
+	job    *delivery
+}
+
+func (p *post) Process(ctx context.Context, logger *zap.Logger) (any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.job.sub.URL, bytes.NewReader(p.job.body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, p.job.event)
+	req.Header.Set(HeaderID, p.job.id)
+	req.Header.Set(HeaderSignature, Sign(p.job.sub.Secret, p.job.body))
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &request.StatusError{StatusCode: resp.StatusCode}
+	}
+	return nil, nil
+}
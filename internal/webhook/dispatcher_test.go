@@ -0,0 +1,220 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/tim8842/tender-data-loader/internal/webhook"
+	"github.com/tim8842/tender-data-loader/pkg"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+var testData = []*agreement.AgreementParesedData{
+	{
+		ID:       "1",
+		Customer: &customer.Customer{ID: "492275", INN: "5110001373"},
+		Services: []*agreement.AgreementService{{Name: "ноутбук", OKPD2: "ОКПД2: 26.20.11.110 Компьютеры портативные"}},
+	},
+	{ID: "2", Customer: &customer.Customer{ID: "1", INN: "7700000000"}},
+}
+
+func TestSubscription_Matches(t *testing.T) {
+	a, c, _ := agreement.ParseAgreementDataToModels(testData[0])
+	tests := []struct {
+		name     string
+		sub      webhook.Subscription
+		expected []string
+	}{
+		{"customer id", webhook.Subscription{CustomerIds: []string{"492275"}}, []string{"customer_id:492275"}},
+		{"inn", webhook.Subscription{INNs: []string{"5110001373"}}, []string{"inn:5110001373"}},
+		{"okpd2 prefix", webhook.Subscription{OKPD2: []string{"26.20"}}, []string{"okpd2:26.20"}},
+		{"no match", webhook.Subscription{CustomerIds: []string{"1"}, OKPD2: []string{"26.30"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.sub.Matches(a, c))
+		})
+	}
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	sub := &webhook.Subscription{ID: "s1", URL: srv.URL, Secret: "secret", OKPD2: []string{"26.20"}, Active: true}
+	subRepo := new(inmock.MockGenericRepository[*webhook.Subscription])
+	subRepo.On("List", mock.Anything, bson.M{"active": true}, mock.Anything).Return([]*webhook.Subscription{sub}, nil)
+	deadRepo := new(inmock.MockGenericRepository[*webhook.DeadLetter])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d := webhook.NewDispatcher(subRepo, deadRepo, srv.Client(), pkg.RetryPolicy{}, 10)
+	go d.Run(ctx, zap.NewNop())
+
+	assert.NoError(t, d.Notify(ctx, zap.NewNop(), testData))
+
+	select {
+	case r := <-received:
+		body := <-bodies
+		assert.Equal(t, webhook.Sign("secret", body), r.Header.Get(webhook.HeaderSignature))
+		assert.Equal(t, "s1_1", r.Header.Get(webhook.HeaderID))
+		assert.Equal(t, webhook.EventAgreementCreated, r.Header.Get(webhook.HeaderEvent))
+		var event webhook.Event
+		assert.NoError(t, json.Unmarshal(body, &event))
+		assert.Equal(t, "1", event.Agreement.ID)
+		assert.Equal(t, "5110001373", event.Customer.INN)
+		assert.Equal(t, []string{"okpd2:26.20"}, event.Matched)
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+	// Договор 2 ни под что не подходит
+	select {
+	case <-received:
+		t.Fatal("unexpected delivery")
+	case <-time.After(50 * time.Millisecond):
+	}
+	deadRepo.AssertNotCalled(t, "BulkMergeMany", mock.Anything, mock.Anything)
+}
+
+func TestDispatcher_DeadLetter(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	sub := &webhook.Subscription{ID: "s1", URL: srv.URL, Secret: "secret", CustomerIds: []string{"492275"}, Active: true}
+	subRepo := new(inmock.MockGenericRepository[*webhook.Subscription])
+	subRepo.On("List", mock.Anything, mock.Anything, mock.Anything).Return([]*webhook.Subscription{sub}, nil)
+	deadRepo := new(inmock.MockGenericRepository[*webhook.DeadLetter])
+	buried := make(chan *webhook.DeadLetter, 1)
+	deadRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		buried <- args.Get(1).([]*webhook.DeadLetter)[0]
+	}).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	policy := webhook.DefaultPolicy(2)
+	policy.BaseDelay, policy.MaxDelay = time.Millisecond, time.Millisecond
+	d := webhook.NewDispatcher(subRepo, deadRepo, srv.Client(), policy, 10)
+	go d.Run(ctx, zap.NewNop())
+
+	assert.NoError(t, d.Notify(ctx, zap.NewNop(), testData))
+
+	select {
+	case dead := <-buried:
+		assert.Equal(t, "s1_1", dead.ID)
+		assert.Equal(t, srv.URL, dead.URL)
+		assert.Contains(t, dead.Error, "503")
+		assert.Contains(t, dead.Payload, `"subscription_id":"s1"`)
+	case <-time.After(2 * time.Second):
+		t.Fatal("dead letter was not saved")
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestDispatcher_NilIsNoop(t *testing.T) {
+	var d *webhook.Dispatcher
+	assert.NoError(t, d.Notify(context.Background(), zap.NewNop(), testData))
+}
+
+// Медленный подписчик не задерживает доставку остальным
+func TestDispatcher_SlowSubscriber(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+	received := make(chan string, 1)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(webhook.HeaderID)
+	}))
+	defer fast.Close()
+
+	subs := []*webhook.Subscription{
+		{ID: "slow", URL: slow.URL, CustomerIds: []string{"492275"}, Active: true},
+		{ID: "fast", URL: fast.URL, INNs: []string{"7700000000"}, Active: true},
+	}
+	subRepo := new(inmock.MockGenericRepository[*webhook.Subscription])
+	subRepo.On("List", mock.Anything, mock.Anything, mock.Anything).Return(subs, nil)
+	deadRepo := new(inmock.MockGenericRepository[*webhook.DeadLetter])
+	deadRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d := webhook.NewDispatcher(subRepo, deadRepo, &http.Client{}, pkg.RetryPolicy{}, 10)
+	go d.Run(ctx, zap.NewNop())
+
+	assert.NoError(t, d.Notify(ctx, zap.NewNop(), testData))
+	select {
+	case id := <-received:
+		assert.Equal(t, "fast_2", id)
+	case <-time.After(2 * time.Second):
+		t.Fatal("fast subscriber waited for slow one")
+	}
+}
+
+// При остановке недоставленные события уходят в dead letter
+func TestDispatcher_ShutdownBuriesQueued(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	sub := &webhook.Subscription{ID: "s1", URL: srv.URL, CustomerIds: []string{"492275", "1"}, Active: true}
+	subRepo := new(inmock.MockGenericRepository[*webhook.Subscription])
+	subRepo.On("List", mock.Anything, mock.Anything, mock.Anything).Return([]*webhook.Subscription{sub}, nil)
+	deadRepo := new(inmock.MockGenericRepository[*webhook.DeadLetter])
+	var buried []string
+	deadRepo.On("BulkMergeMany", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		buried = append(buried, args.Get(1).([]*webhook.DeadLetter)[0].ID)
+	}).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := webhook.NewDispatcher(subRepo, deadRepo, &http.Client{}, pkg.RetryPolicy{}, 10)
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx, zap.NewNop())
+		close(done)
+	}()
+
+	assert.NoError(t, d.Notify(ctx, zap.NewNop(), testData))
+	<-started
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("dispatcher did not stop")
+	}
+	assert.ElementsMatch(t, []string{"s1_1", "s1_2"}, buried)
+}
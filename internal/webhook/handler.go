@@ -0,0 +1,224 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const deadLettersLimit = 100
+
+// WebhookHandler обрабатывает запросы к подпискам на вебхуки
+type WebhookHandler struct {
+	logger   *zap.Logger
+	subRepo  ISubscriptionRepo
+	deadRepo IDeadLetterRepo
+}
+
+// NewWebhookHandler создает новый handler
+func NewWebhookHandler(
+	logger *zap.Logger,
+	subRepo ISubscriptionRepo,
+	deadRepo IDeadLetterRepo,
+) *WebhookHandler {
+	return &WebhookHandler{
+		logger:   logger,
+		subRepo:  subRepo,
+		deadRepo: deadRepo,
+	}
+}
+
+// ListSubscriptions godoc
+// @Summary Список подписок
+// @Description Возвращает все подписки на вебхуки без секретов
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} model.Subscription
+// @Failure 500 {object} map[string]string
+// @Router /webhooks [get]
+func (h *WebhookHandler) ListSubscriptions(c *fiber.Ctx) error {
+	subs, err := h.subRepo.List(c.Context(), bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		h.logger.Error("Ошибка при получении подписок", zap.Error(err))
+		return internalError(c)
+	}
+	for _, s := range subs {
+		s.Secret = ""
+	}
+	if subs == nil {
+		subs = []*Subscription{}
+	}
+	return c.JSON(subs)
+}
+
+// CreateSubscription godoc
+// @Summary Создать подписку
+// @Description Создает подписку на новые договоры. Если secret не передан, он генерируется и возвращается только в этом ответе
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param subscription body model.Subscription true "Подписка"
+// @Success 201 {object} model.Subscription
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateSubscription(c *fiber.Ctx) error {
+	sub := &Subscription{Active: true}
+	if err := c.BodyParser(sub); err != nil {
+		return badRequest(c, err)
+	}
+	if err := sub.Validate(); err != nil {
+		return badRequest(c, err)
+	}
+	sub.ID = randomHex(8)
+	if sub.Secret == "" {
+		sub.Secret = randomHex(32)
+	}
+	sub.CreatedAt = time.Now()
+	if err := h.subRepo.Create(c.Context(), sub); err != nil {
+		h.logger.Error("Ошибка при создании подписки", zap.Error(err))
+		return internalError(c)
+	}
+	h.logger.Info("Создана подписка", zap.String("id", sub.ID), zap.String("url", sub.URL))
+	return c.Status(fiber.StatusCreated).JSON(sub)
+}
+
+// GetSubscription godoc
+// @Summary Получить подписку
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Success 200 {object} model.Subscription
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) GetSubscription(c *fiber.Ctx) error {
+	sub, err := h.get(c)
+	if sub == nil {
+		return err
+	}
+	sub.Secret = ""
+	return c.JSON(sub)
+}
+
+// UpdateSubscription godoc
+// @Summary Изменить подписку
+// @Description Заменяет адрес, фильтры и активность подписки. Secret меняется, только если передан
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param subscription body model.Subscription true "Подписка"
+// @Success 200 {object} model.Subscription
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) UpdateSubscription(c *fiber.Ctx) error {
+	old, err := h.get(c)
+	if old == nil {
+		return err
+	}
+	sub := &Subscription{}
+	if err := c.BodyParser(sub); err != nil {
+		return badRequest(c, err)
+	}
+	if err := sub.Validate(); err != nil {
+		return badRequest(c, err)
+	}
+	sub.ID, sub.CreatedAt = old.ID, old.CreatedAt
+	if sub.Secret == "" {
+		sub.Secret = old.Secret
+	}
+	if err := h.subRepo.Update(c.Context(), sub.ID, sub); err != nil {
+		h.logger.Error("Ошибка при изменении подписки", zap.String("id", sub.ID), zap.Error(err))
+		return internalError(c)
+	}
+	sub.Secret = ""
+	return c.JSON(sub)
+}
+
+// DeleteSubscription godoc
+// @Summary Удалить подписку
+// @Tags webhooks
+// @Param id path string true "ID подписки"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteSubscription(c *fiber.Ctx) error {
+	sub, err := h.get(c)
+	if sub == nil {
+		return err
+	}
+	if err := h.subRepo.Delete(c.Context(), sub.ID); err != nil {
+		h.logger.Error("Ошибка при удалении подписки", zap.String("id", sub.ID), zap.Error(err))
+		return internalError(c)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListDeadLetters godoc
+// @Summary Недоставленные события
+// @Description Возвращает последние события, которые не удалось доставить после всех повторов
+// @Tags webhooks
+// @Produce json
+// @Param subscription_id query string false "ID подписки"
+// @Success 200 {array} model.DeadLetter
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/dead-letters [get]
+func (h *WebhookHandler) ListDeadLetters(c *fiber.Ctx) error {
+	filter := bson.M{}
+	if id := c.Query("subscription_id"); id != "" {
+		filter["subscription_id"] = id
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "failed_at", Value: -1}}).SetLimit(deadLettersLimit)
+	dead, err := h.deadRepo.List(c.Context(), filter, opts)
+	if err != nil {
+		h.logger.Error("Ошибка при получении dead letter", zap.Error(err))
+		return internalError(c)
+	}
+	if dead == nil {
+		dead = []*DeadLetter{}
+	}
+	return c.JSON(dead)
+}
+
+// get достает подписку по id из пути, при ошибке сам пишет ответ и возвращает nil
+func (h *WebhookHandler) get(c *fiber.Ctx) (*Subscription, error) {
+	id := c.Params("id")
+	sub, err := h.subRepo.GetByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) || strings.Contains(err.Error(), "not found") {
+			return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "subscription not found",
+			})
+		}
+		h.logger.Error("Ошибка при получении подписки", zap.String("id", id), zap.Error(err))
+		return nil, internalError(c)
+	}
+	return sub, nil
+}
+
+func badRequest(c *fiber.Ctx, err error) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+func internalError(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "internal server error",
+	})
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,122 @@
+package webhook_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	inmock "github.com/tim8842/tender-data-loader/internal/mock"
+	"github.com/tim8842/tender-data-loader/internal/webhook"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+func newWebhookApp(subRepo *inmock.MockGenericRepository[*webhook.Subscription], deadRepo *inmock.MockGenericRepository[*webhook.DeadLetter]) *fiber.App {
+	app := fiber.New()
+	h := webhook.NewWebhookHandler(zap.NewNop(), subRepo, deadRepo)
+	app.Get("/webhooks", h.ListSubscriptions)
+	app.Post("/webhooks", h.CreateSubscription)
+	app.Get("/webhooks/dead-letters", h.ListDeadLetters)
+	app.Get("/webhooks/:id", h.GetSubscription)
+	app.Put("/webhooks/:id", h.UpdateSubscription)
+	app.Delete("/webhooks/:id", h.DeleteSubscription)
+	return app
+}
+
+func TestWebhookHandler_CreateSubscription(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		createErr      error
+		expectedStatus int
+	}{
+		{"created", `{"url":"http://localhost:9000/hook","okpd2":["26.20"]}`, nil, fiber.StatusCreated},
+		{"bad url", `{"url":"localhost","okpd2":["26.20"]}`, nil, fiber.StatusBadRequest},
+		{"empty filter", `{"url":"http://localhost:9000/hook"}`, nil, fiber.StatusBadRequest},
+		{"db error", `{"url":"http://localhost:9000/hook","inns":["5110001373"]}`, errors.New("db error"), fiber.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subRepo := new(inmock.MockGenericRepository[*webhook.Subscription])
+			subRepo.On("Create", mock.Anything, mock.Anything).Return(tt.createErr)
+			app := newWebhookApp(subRepo, nil)
+			req := httptest.NewRequest("POST", "/webhooks", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.expectedStatus != fiber.StatusCreated {
+				return
+			}
+			body, _ := io.ReadAll(resp.Body)
+			var sub webhook.Subscription
+			assert.NoError(t, json.Unmarshal(body, &sub))
+			assert.NotEmpty(t, sub.ID)
+			assert.Len(t, sub.Secret, 64)
+			assert.True(t, sub.Active)
+		})
+	}
+}
+
+func TestWebhookHandler_GetAndDelete(t *testing.T) {
+	subRepo := new(inmock.MockGenericRepository[*webhook.Subscription])
+	subRepo.On("GetByID", mock.Anything, "s1").Return(&webhook.Subscription{ID: "s1", URL: "http://localhost/hook", Secret: "secret"}, nil)
+	subRepo.On("GetByID", mock.Anything, "s2").Return((*webhook.Subscription)(nil), mongo.ErrNoDocuments)
+	subRepo.On("Delete", mock.Anything, "s1").Return(nil)
+	app := newWebhookApp(subRepo, nil)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/webhooks/s1", nil))
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.NotContains(t, string(body), "secret")
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/webhooks/s2", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("DELETE", "/webhooks/s1", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+	subRepo.AssertCalled(t, "Delete", mock.Anything, "s1")
+}
+
+func TestWebhookHandler_UpdateSubscription(t *testing.T) {
+	subRepo := new(inmock.MockGenericRepository[*webhook.Subscription])
+	subRepo.On("GetByID", mock.Anything, "s1").Return(&webhook.Subscription{ID: "s1", URL: "http://localhost/hook", Secret: "secret"}, nil)
+	subRepo.On("Update", mock.Anything, "s1", mock.MatchedBy(func(s *webhook.Subscription) bool {
+		// секрет сохраняется, если не передан
+		return s.ID == "s1" && s.Secret == "secret" && !s.Active && s.CustomerIds[0] == "492275"
+	})).Return(nil)
+	app := newWebhookApp(subRepo, nil)
+
+	req := httptest.NewRequest("PUT", "/webhooks/s1", strings.NewReader(`{"url":"http://localhost/hook","customer_ids":["492275"],"active":false}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	subRepo.AssertExpectations(t)
+}
+
+func TestWebhookHandler_ListDeadLetters(t *testing.T) {
+	deadRepo := new(inmock.MockGenericRepository[*webhook.DeadLetter])
+	deadRepo.On("List", mock.Anything, bson.M{"subscription_id": "s1"}, mock.Anything).Return([]*webhook.DeadLetter{{ID: "s1_1", SubscriptionId: "s1"}}, nil)
+	app := newWebhookApp(nil, deadRepo)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/webhooks/dead-letters?subscription_id=s1", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	var dead []*webhook.DeadLetter
+	assert.NoError(t, json.Unmarshal(body, &dead))
+	assert.Len(t, dead, 1)
+	deadRepo.AssertExpectations(t)
+}
@@ -0,0 +1,105 @@
+package webhook
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/internal/agreement"
+	"github.com/tim8842/tender-data-loader/internal/customer"
+)
+
+const EventAgreementCreated = "agreement.created"
+
+var (
+	ErrBadURL      = errors.New("url must be an absolute http(s) url")
+	ErrEmptyFilter = errors.New("at least one of customer_ids, inns, okpd2 is required")
+)
+
+// Subscription подписка на новые договоры заказчиков или кодов ОКПД2
+type Subscription struct {
+	ID          string    `bson:"_id,omitempty" json:"id"`
+	URL         string    `bson:"url" json:"url"`                   // куда отправлять POST
+	Secret      string    `bson:"secret" json:"secret,omitempty"`   // ключ HMAC подписи, отдается только при создании
+	CustomerIds []string  `bson:"customer_ids" json:"customer_ids"` // ID заказчиков (agencyId)
+	INNs        []string  `bson:"inns" json:"inns"`                 // ИНН заказчиков
+	OKPD2       []string  `bson:"okpd2" json:"okpd2"`               // префиксы кодов ОКПД2 услуг
+	Active      bool      `bson:"active" json:"active"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+}
+
+func (t Subscription) GetID() any {
+	return t.ID
+}
+
+// Validate проверяет адрес и что подписка хоть на что-то подписана
+func (t *Subscription) Validate() error {
+	u, err := url.Parse(t.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrBadURL
+	}
+	if len(t.CustomerIds) == 0 && len(t.INNs) == 0 && len(t.OKPD2) == 0 {
+		return ErrEmptyFilter
+	}
+	return nil
+}
+
+// okpd2Prefix в базе код хранится вместе с префиксом "ОКПД2:" и наименованием
+var okpd2Prefix = regexp.MustCompile(`^ОКПД2:\s*`)
+
+// Matches возвращает условия подписки, под которые попал договор
+func (t *Subscription) Matches(a *agreement.Agreement, c *customer.Customer) []string {
+	var matched []string
+	for _, id := range t.CustomerIds {
+		if id != "" && id == a.CustomerId {
+			matched = append(matched, "customer_id:"+id)
+		}
+	}
+	if c != nil {
+		for _, inn := range t.INNs {
+			if inn != "" && inn == strings.TrimSpace(c.INN) {
+				matched = append(matched, "inn:"+inn)
+			}
+		}
+	}
+	for _, prefix := range t.OKPD2 {
+		if prefix == "" {
+			continue
+		}
+		for _, s := range a.Services {
+			if strings.HasPrefix(okpd2Prefix.ReplaceAllString(strings.TrimSpace(s.OKPD2), ""), prefix) {
+				matched = append(matched, "okpd2:"+prefix)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// Event тело запроса к подписчику
+type Event struct {
+	ID             string               `json:"id"` // <subscription_id>_<agreement_id>, по нему подписчик отсекает повторы
+	Type           string               `json:"type"`
+	SubscriptionId string               `json:"subscription_id"`
+	CreatedAt      time.Time            `json:"created_at"`
+	Matched        []string             `json:"matched"`
+	Agreement      *agreement.Agreement `json:"agreement"`
+	Customer       *customer.Customer   `json:"customer"`
+}
+
+// DeadLetter событие, которое не удалось доставить после всех повторов
+type DeadLetter struct {
+	ID             string    `bson:"_id,omitempty" json:"id"` // ID события
+	SubscriptionId string    `bson:"subscription_id" json:"subscription_id"`
+	URL            string    `bson:"url" json:"url"`
+	Event          string    `bson:"event" json:"event"`
+	Payload        string    `bson:"payload" json:"payload"`
+	Error          string    `bson:"error" json:"error"`
+	FailedAt       time.Time `bson:"failed_at" json:"failed_at"`
+}
+
+func (t DeadLetter) GetID() any {
+	return t.ID
+}
@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/tim8842/tender-data-loader/pkg/repository"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SubscriptionRepo struct {
+	*repository.GenericRepository[*Subscription]
+}
+
+func (r *SubscriptionRepo) GetByID(ctx context.Context, id string) (*Subscription, error) {
+	return r.GenericRepository.GetByID(ctx, id)
+}
+
+func (r *SubscriptionRepo) List(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*Subscription, error) {
+	return r.GenericRepository.List(ctx, filter, opts...)
+}
+
+type ISubscriptionRepo interface {
+	Create(ctx context.Context, doc *Subscription) error
+	GetByID(ctx context.Context, id string) (*Subscription, error)
+	Update(ctx context.Context, id string, doc *Subscription) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*Subscription, error)
+}
+
+type DeadLetterRepo struct {
+	*repository.GenericRepository[*DeadLetter]
+}
+
+func (r *DeadLetterRepo) BulkMergeMany(ctx context.Context, docs []*DeadLetter) error {
+	return r.BulkCreateOrUpdateMany(ctx, docs)
+}
+
+func (r *DeadLetterRepo) List(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*DeadLetter, error) {
+	return r.GenericRepository.List(ctx, filter, opts...)
+}
+
+type IDeadLetterRepo interface {
+	BulkMergeMany(ctx context.Context, docs []*DeadLetter) error
+	List(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*DeadLetter, error)
+}
@@ -0,0 +1,98 @@
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Виды страниц в архиве
+const (
+	KindSearch    = "search"     // страница поиска
+	KindContract  = "contract"   // карточка договора
+	KindPrintForm = "print_form" // печатная форма договора
+	KindCustomer  = "customer"   // карточка заказчика
+	KindOther     = "other"
+
+	KindContract44        = "contract44"         // карточка контракта 44-ФЗ
+	KindContract44Objects = "contract44_objects" // объекты закупки контракта 44-ФЗ
+	KindNotice            = "notice"             // общая информация извещения о закупке
+	KindNoticeLots        = "notice_lots"        // список лотов извещения
+	KindNoticeDocuments   = "notice_documents"   // документы извещения
+)
+
+var ErrNotFound = errors.New("page not found in archive")
+
+// Page страница, сохраненная в архиве
+type Page struct {
+	URL       string    `json:"url" bson:"url"`
+	Kind      string    `json:"kind" bson:"kind"`
+	FetchedAt time.Time `json:"fetched_at" bson:"fetched_at"`
+	Hash      string    `json:"hash" bson:"hash"` // sha256 тела страницы
+	Size      int       `json:"size" bson:"size"`
+	Body      []byte    `json:"-" bson:"-"`
+}
+
+// NewPage создает страницу и считает хэш тела
+func NewPage(url string, kind string, body []byte, fetchedAt time.Time) *Page {
+	return &Page{URL: url, Kind: kind, FetchedAt: fetchedAt, Hash: Hash(body), Size: len(body), Body: body}
+}
+
+// Hash sha256 в hex
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Store хранилище страниц. Для одного URL хранится последняя версия,
+// если тело не изменилось, Save ничего не пишет
+type Store interface {
+	Save(ctx context.Context, page *Page) error
+	Load(ctx context.Context, url string) (*Page, error)
+	// Walk обходит страницы вида kind (пустой kind — все страницы)
+	Walk(ctx context.Context, kind string, fn func(*Page) error) error
+}
+
+// Mode режим работы архива
+type Mode string
+
+const (
+	ModeOff    Mode = "off"    // архив не используется
+	ModeRecord Mode = "record" // страницы качаются из сети и сохраняются
+	ModeReplay Mode = "replay" // страницы берутся только из архива
+)
+
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(s); m {
+	case ModeOff, ModeRecord, ModeReplay:
+		return m, nil
+	case "":
+		return ModeOff, nil
+	default:
+		return "", fmt.Errorf("unknown archive mode %q", s)
+	}
+}
+
+type kindKey struct{}
+
+// WithKind помечает в контексте, какую страницу сейчас загружаем
+func WithKind(ctx context.Context, kind string) context.Context {
+	return context.WithValue(ctx, kindKey{}, kind)
+}
+
+// KindFromContext возвращает вид страницы из контекста или KindOther
+func KindFromContext(ctx context.Context) string {
+	if kind, ok := ctx.Value(kindKey{}).(string); ok && kind != "" {
+		return kind
+	}
+	return KindOther
+}
+
+// Бэкенды хранения
+const (
+	BackendDisk   = "disk"
+	BackendGridFS = "gridfs"
+)
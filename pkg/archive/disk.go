@@ -0,0 +1,135 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DiskStore хранит страницы на диске: <root>/<xx>/<sha256(url)>.html.gz и рядом .json с метаданными
+type DiskStore struct {
+	root string
+}
+
+func NewDiskStore(root string) (*DiskStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskStore{root: root}, nil
+}
+
+func (s *DiskStore) paths(url string) (body string, meta string) {
+	key := Hash([]byte(url))
+	base := filepath.Join(s.root, key[:2], key)
+	return base + ".html.gz", base + ".json"
+}
+
+func (s *DiskStore) Save(ctx context.Context, page *Page) error {
+	bodyPath, metaPath := s.paths(page.URL)
+	if old, err := readMeta(metaPath); err == nil && old.Hash == page.Hash {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(bodyPath), 0o755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(page.Body); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := writeFile(bodyPath, buf.Bytes()); err != nil {
+		return err
+	}
+	meta, err := json.Marshal(page)
+	if err != nil {
+		return err
+	}
+	// метаданные пишутся последними: страница без .json считается незаписанной
+	return writeFile(metaPath, meta)
+}
+
+func (s *DiskStore) Load(ctx context.Context, url string) (*Page, error) {
+	bodyPath, metaPath := s.paths(url)
+	page, err := readMeta(metaPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := readBody(bodyPath, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *DiskStore) Walk(ctx context.Context, kind string, fn func(*Page) error) error {
+	return filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		page, err := readMeta(path)
+		if err != nil {
+			return err
+		}
+		if kind != "" && page.Kind != kind {
+			return nil
+		}
+		if err := readBody(strings.TrimSuffix(path, ".json")+".html.gz", page); err != nil {
+			return err
+		}
+		return fn(page)
+	})
+}
+
+func readMeta(path string) (*Page, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var page Page
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func readBody(path string, page *Page) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	page.Body, err = io.ReadAll(zr)
+	return err
+}
+
+// writeFile пишет через временный файл, чтобы не оставить половину страницы
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,77 @@
+package archive_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/pkg/archive"
+)
+
+func TestDiskStore_SaveLoad(t *testing.T) {
+	ctx := context.Background()
+	store, err := archive.NewDiskStore(t.TempDir())
+	assert.NoError(t, err)
+
+	fetchedAt := time.Date(2025, 6, 11, 10, 0, 0, 0, time.UTC)
+	page := archive.NewPage("https://zakupki.gov.ru/card?id=1", archive.KindContract, []byte("<html>1</html>"), fetchedAt)
+	assert.NoError(t, store.Save(ctx, page))
+
+	got, err := store.Load(ctx, page.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, page.URL, got.URL)
+	assert.Equal(t, archive.KindContract, got.Kind)
+	assert.True(t, fetchedAt.Equal(got.FetchedAt))
+	assert.Equal(t, archive.Hash([]byte("<html>1</html>")), got.Hash)
+	assert.Equal(t, []byte("<html>1</html>"), got.Body)
+
+	_, err = store.Load(ctx, "https://zakupki.gov.ru/card?id=2")
+	assert.ErrorIs(t, err, archive.ErrNotFound)
+}
+
+func TestDiskStore_SameHashNotRewritten(t *testing.T) {
+	ctx := context.Background()
+	store, err := archive.NewDiskStore(t.TempDir())
+	assert.NoError(t, err)
+
+	first := time.Date(2025, 6, 11, 0, 0, 0, 0, time.UTC)
+	url := "https://zakupki.gov.ru/card?id=1"
+	assert.NoError(t, store.Save(ctx, archive.NewPage(url, archive.KindContract, []byte("a"), first)))
+	assert.NoError(t, store.Save(ctx, archive.NewPage(url, archive.KindContract, []byte("a"), first.Add(time.Hour))))
+	got, err := store.Load(ctx, url)
+	assert.NoError(t, err)
+	assert.True(t, first.Equal(got.FetchedAt))
+
+	assert.NoError(t, store.Save(ctx, archive.NewPage(url, archive.KindContract, []byte("b"), first.Add(2*time.Hour))))
+	got, err = store.Load(ctx, url)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("b"), got.Body)
+}
+
+func TestDiskStore_Walk(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := archive.NewDiskStore(dir)
+	assert.NoError(t, err)
+	now := time.Now()
+	assert.NoError(t, store.Save(ctx, archive.NewPage("u1", archive.KindContract, []byte("1"), now)))
+	assert.NoError(t, store.Save(ctx, archive.NewPage("u2", archive.KindContract, []byte("2"), now)))
+	assert.NoError(t, store.Save(ctx, archive.NewPage("u3", archive.KindCustomer, []byte("3"), now)))
+	// недописанный файл без метаданных пропускается
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "broken.html.gz"), []byte("x"), 0o644))
+
+	var urls []string
+	err = store.Walk(ctx, archive.KindContract, func(p *archive.Page) error {
+		urls = append(urls, p.URL)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"u1", "u2"}, urls)
+
+	count := 0
+	assert.NoError(t, store.Walk(ctx, "", func(p *archive.Page) error { count++; return nil }))
+	assert.Equal(t, 3, count)
+}
@@ -0,0 +1,131 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridFSStore хранит сжатые страницы в GridFS, имя файла — URL, метаданные — Page
+type GridFSStore struct {
+	bucket *gridfs.Bucket
+}
+
+type gridFSFile struct {
+	ID       primitive.ObjectID `bson:"_id"`
+	Metadata Page               `bson:"metadata"`
+}
+
+func NewGridFSStore(db *mongo.Database, bucketName string) (*GridFSStore, error) {
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(bucketName))
+	if err != nil {
+		return nil, err
+	}
+	return &GridFSStore{bucket: bucket}, nil
+}
+
+func (s *GridFSStore) Save(ctx context.Context, page *Page) error {
+	old, err := s.files(ctx, bson.M{"filename": page.URL})
+	if err != nil {
+		return err
+	}
+	if len(old) > 0 && old[0].Metadata.Hash == page.Hash {
+		return nil
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(page.Body); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	opts := options.GridFSUpload().SetMetadata(page)
+	if _, err := s.bucket.UploadFromStream(page.URL, &buf, opts); err != nil {
+		return err
+	}
+	// храним только последнюю версию
+	for _, f := range old {
+		if err := s.bucket.DeleteContext(ctx, f.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *GridFSStore) Load(ctx context.Context, url string) (*Page, error) {
+	files, err := s.files(ctx, bson.M{"filename": url})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, ErrNotFound
+	}
+	return s.download(files[0])
+}
+
+func (s *GridFSStore) Walk(ctx context.Context, kind string, fn func(*Page) error) error {
+	filter := bson.M{}
+	if kind != "" {
+		filter["metadata.kind"] = kind
+	}
+	cursor, err := s.bucket.FindContext(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var f gridFSFile
+		if err := cursor.Decode(&f); err != nil {
+			return err
+		}
+		page, err := s.download(f)
+		if err != nil {
+			return err
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// files возвращает файлы по фильтру, новые первыми
+func (s *GridFSStore) files(ctx context.Context, filter bson.M) ([]gridFSFile, error) {
+	cursor, err := s.bucket.FindContext(ctx, filter, options.GridFSFind().SetSort(bson.D{{Key: "uploadDate", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	var files []gridFSFile
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (s *GridFSStore) download(f gridFSFile) (*Page, error) {
+	stream, err := s.bucket.OpenDownloadStream(f.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	zr, err := gzip.NewReader(stream)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	page := f.Metadata
+	page.Body = body
+	return &page, nil
+}
@@ -0,0 +1,43 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tim8842/tender-data-loader/pkg/request"
+	"go.uber.org/zap"
+)
+
+// Requester оборачивает IRequester: в режиме record сохраняет каждую страницу в архив,
+// в режиме replay отдает страницы из архива, не ходя в сеть
+type Requester struct {
+	next  request.IRequester
+	store Store
+	mode  Mode
+	now   func() time.Time
+}
+
+func NewRequester(next request.IRequester, store Store, mode Mode) *Requester {
+	return &Requester{next: next, store: store, mode: mode, now: time.Now}
+}
+
+func (r *Requester) Get(ctx context.Context, logger *zap.Logger, url string, timeout time.Duration, opts ...*request.RequestOptions) ([]byte, error) {
+	if r.mode == ModeReplay {
+		page, err := r.store.Load(ctx, url)
+		if err != nil {
+			return nil, fmt.Errorf("archive replay %s: %w", url, err)
+		}
+		return page.Body, nil
+	}
+	fetchedAt := r.now()
+	body, err := r.next.Get(ctx, logger, url, timeout, opts...)
+	if err != nil || r.mode != ModeRecord {
+		return body, err
+	}
+	if err := r.store.Save(ctx, NewPage(url, KindFromContext(ctx), body, fetchedAt)); err != nil {
+		// страница уже скачана, ошибку архива не пробрасываем
+		logger.Error("Ошибка сохранения страницы в архив", zap.String("url", url), zap.Error(err))
+	}
+	return body, nil
+}
@@ -0,0 +1,79 @@
+package archive_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tim8842/tender-data-loader/pkg/archive"
+	"github.com/tim8842/tender-data-loader/pkg/request"
+	"go.uber.org/zap"
+)
+
+type mockRequester struct {
+	mock.Mock
+}
+
+func (m *mockRequester) Get(ctx context.Context, logger *zap.Logger, url string, timeout time.Duration, opts ...*request.RequestOptions) ([]byte, error) {
+	args := m.Called(ctx, logger, url, timeout, opts)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func TestRequester(t *testing.T) {
+	const url = "https://zakupki.gov.ru/card?id=1"
+	tests := []struct {
+		name       string
+		mode       archive.Mode
+		stored     []byte
+		netBody    []byte
+		netErr     error
+		expectNet  bool
+		expectBody []byte
+		expectErr  bool
+		expectSave bool
+	}{
+		{name: "off — только сеть", mode: archive.ModeOff, netBody: []byte("net"), expectNet: true, expectBody: []byte("net")},
+		{name: "record сохраняет", mode: archive.ModeRecord, netBody: []byte("net"), expectNet: true, expectBody: []byte("net"), expectSave: true},
+		{name: "record не сохраняет ошибку", mode: archive.ModeRecord, netErr: errors.New("500"), expectNet: true, expectErr: true},
+		{name: "replay из архива", mode: archive.ModeReplay, stored: []byte("archived"), expectBody: []byte("archived")},
+		{name: "replay без страницы", mode: archive.ModeReplay, expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := archive.WithKind(context.Background(), archive.KindContract)
+			store, err := archive.NewDiskStore(t.TempDir())
+			assert.NoError(t, err)
+			if tt.stored != nil {
+				assert.NoError(t, store.Save(ctx, archive.NewPage(url, archive.KindContract, tt.stored, time.Now())))
+			}
+			next := new(mockRequester)
+			next.On("Get", mock.Anything, mock.Anything, url, 5*time.Second, mock.Anything).Return(tt.netBody, tt.netErr)
+
+			body, err := archive.NewRequester(next, store, tt.mode).Get(ctx, zap.NewNop(), url, 5*time.Second)
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectBody, body)
+			}
+			if tt.expectNet {
+				next.AssertNumberOfCalls(t, "Get", 1)
+			} else {
+				next.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+			if tt.stored == nil {
+				page, err := store.Load(ctx, url)
+				if tt.expectSave {
+					assert.NoError(t, err)
+					assert.Equal(t, tt.netBody, page.Body)
+					assert.Equal(t, archive.KindContract, page.Kind)
+				} else {
+					assert.ErrorIs(t, err, archive.ErrNotFound)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package archive
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// NewStore создает хранилище по названию бэкенда: disk — каталог dir, gridfs — bucket "pages" в db
+func NewStore(backend string, dir string, db *mongo.Database) (Store, error) {
+	switch backend {
+	case BackendDisk, "":
+		return NewDiskStore(dir)
+	case BackendGridFS:
+		return NewGridFSStore(db, "pages")
+	default:
+		return nil, fmt.Errorf("unknown archive backend %q", backend)
+	}
+}
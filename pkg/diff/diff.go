@@ -0,0 +1,41 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Fields возвращает bson-имена полей, которые отличаются у двух значений одного типа-структуры
+func Fields[T any](old, new *T) []string {
+	ov, nv := reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem()
+	typ := ov.Type()
+	var fields []string
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		a, b := ov.Field(i).Interface(), nv.Field(i).Interface()
+		if !equal(a, b) {
+			fields = append(fields, fieldName(f))
+		}
+	}
+	return fields
+}
+
+// equal сравнивает значения, время — через Equal (из Mongo приходит UTC с миллисекундами)
+func equal(a, b any) bool {
+	if ta, ok := a.(time.Time); ok {
+		return ta.UnixMilli() == b.(time.Time).UnixMilli()
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func fieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("bson"), ",")
+	if name == "" || name == "-" {
+		return f.Name
+	}
+	return name
+}
@@ -0,0 +1,31 @@
+package diff_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/pkg/diff"
+)
+
+type item struct {
+	Name string `bson:"name"`
+}
+
+type doc struct {
+	ID       string    `bson:"_id,omitempty"`
+	Price    float64   `bson:"price"`
+	SignedAt time.Time `bson:"signed_at"`
+	Subject  string    `bson:"subject"`
+	Items    []*item   `bson:"services"`
+	NoTag    int
+}
+
+func TestFields(t *testing.T) {
+	date := time.Date(2025, 6, 11, 10, 0, 0, 0, time.Local)
+	old := &doc{ID: "1", Price: 10, SignedAt: date.UTC(), Subject: "a"}
+	new := &doc{ID: "1", Price: 20, SignedAt: date, Subject: "a",
+		Items: []*item{{Name: "s"}}, NoTag: 1}
+	assert.Equal(t, []string{"price", "services", "NoTag"}, diff.Fields(old, new))
+	assert.Empty(t, diff.Fields(old, old))
+}
@@ -0,0 +1,306 @@
+package extract
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/tim8842/tender-data-loader/pkg/parser"
+)
+
+// Apply заполняет target (указатель на структуру) по правилам подходящей версии
+// страницы page и возвращает эту версию. Поля, для которых значение не нашлось,
+// не трогаются, поэтому target можно дополнять с нескольких страниц
+func (r *Rules) Apply(page string, doc *goquery.Document, target any) (string, error) {
+	layout, err := r.Layout(page, doc)
+	if err != nil {
+		return "", err
+	}
+	for _, rule := range layout.Fields {
+		for _, raw := range rule.values(doc) {
+			value, ok := convert(applySteps(raw, rule.Post), rule.Type)
+			if !ok {
+				continue
+			}
+			if err := setField(reflect.ValueOf(target), rule.Field, value); err != nil {
+				return layout.Version, err
+			}
+			break
+		}
+	}
+	for _, table := range layout.Tables {
+		if err := table.apply(doc, target); err != nil {
+			return layout.Version, err
+		}
+	}
+	return layout.Version, nil
+}
+
+// values сырые значения найденных элементов по порядку. Берется первое,
+// которое после постобработки и приведения к типу не пустое
+func (r *Rule) values(doc *goquery.Document) []string {
+	var sel *goquery.Selection
+	if r.Title != "" {
+		tag := r.Tag
+		if tag == "" {
+			tag = "span"
+		}
+		el, err := parser.GetElementFromHtmlByTitle(doc, tag, r.Title)
+		if err != nil {
+			return nil
+		}
+		sel = el
+		if r.Selector != "" {
+			sel = el.Find(r.Selector)
+		}
+	} else {
+		sel = doc.Find(r.Selector)
+	}
+	if r.Index != nil {
+		sel = sel.Eq(*r.Index)
+	}
+	if r.Children || r.Join != "" {
+		if sel.Length() == 0 {
+			return nil
+		}
+		parts := sel
+		if r.Children && sel.Children().Length() > 0 {
+			parts = sel.Children()
+		}
+		var texts []string
+		parts.Each(func(i int, s *goquery.Selection) {
+			texts = append(texts, strings.TrimSpace(s.Text()))
+		})
+		return []string{strings.Join(texts, r.Join)}
+	}
+	var res []string
+	sel.Each(func(i int, s *goquery.Selection) {
+		attr := r.Attr
+		if attr == "" && r.Param != "" {
+			attr = "href"
+		}
+		value := s.Text()
+		if attr != "" {
+			var ok bool
+			if value, ok = s.Attr(attr); !ok {
+				return
+			}
+		}
+		if r.Param != "" {
+			value = parser.GetParamFromHref(value, r.Param)
+		}
+		res = append(res, value)
+	})
+	return res
+}
+
+func (t *TableRule) apply(doc *goquery.Document, target any) error {
+	var err error
+	doc.Find(t.HeaderSelector).EachWithBreak(func(i int, header *goquery.Selection) bool {
+		if t.HeaderText != "" && strings.TrimSpace(header.Text()) != t.HeaderText {
+			return true
+		}
+		var columns []string
+		header.Parent().Next().Find("tr").EachWithBreak(func(j int, row *goquery.Selection) bool {
+			row.Find("th").Each(func(k int, cell *goquery.Selection) {
+				columns = append(columns, strings.TrimSpace(cell.Text()))
+			})
+			if j == 0 {
+				return true
+			}
+			var item reflect.Value
+			item, err = appendItem(reflect.ValueOf(target), t.Field)
+			if err != nil {
+				return false
+			}
+			row.Find("td").EachWithBreak(func(k int, cell *goquery.Selection) bool {
+				if k >= len(columns) {
+					return false
+				}
+				for _, c := range t.Columns {
+					if !contains(c.Titles, columns[k]) {
+						continue
+					}
+					value, ok := convert(applySteps(cell.Text(), c.Post), c.Type)
+					if !ok {
+						continue
+					}
+					if err = setField(item, c.Field, value); err != nil {
+						return false
+					}
+				}
+				return true
+			})
+			return err == nil
+		})
+		return err == nil
+	})
+	return err
+}
+
+func contains(titles []string, title string) bool {
+	for _, t := range titles {
+		if strings.TrimSpace(t) == title {
+			return true
+		}
+	}
+	return false
+}
+
+// convert приводит строку к типу правила. Пустые и неразобранные значения пропускаются
+func convert(v string, typ string) (any, bool) {
+	switch typ {
+	case TypePrice:
+		f, err := parser.ParsePriceToFloat(v)
+		return f, err == nil
+	case TypeDate:
+		t, err := parser.ParseFromDateToTime(strings.TrimSpace(v))
+		return t, err == nil
+	default:
+		return v, v != ""
+	}
+}
+
+// field находит поле структуры по пути из json-тегов, по пути создавая nil-указатели
+func field(v reflect.Value, path string) (reflect.Value, error) {
+	for _, name := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("field %s: nil target", path)
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("field %s: %s is not a struct", path, v.Type())
+		}
+		i, ok := fieldIndex(v.Type(), name)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("field %s: no field %s in %s", path, name, v.Type())
+		}
+		v = v.Field(i)
+	}
+	return v, nil
+}
+
+// fieldIndex номер поля структуры t с json-тегом name
+func fieldIndex(t reflect.Type, name string) (int, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		tag, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if tag == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Check проверяет, что правила всех версий страницы page ложатся на target так же,
+// как его заполнит Apply: пути существуют, типы значений подходят полям, поля таблиц —
+// срезы указателей на структуры. Так опечатка в правилах видна при загрузке, а не при разборе
+func (r *Rules) Check(page string, target any) error {
+	t := reflect.TypeOf(target)
+	for _, l := range r.Pages[page] {
+		for _, rule := range l.Fields {
+			if err := checkField(t, rule.Field, rule.Type); err != nil {
+				return fmt.Errorf("page %s version %s: %w", page, l.Version, err)
+			}
+		}
+		for _, table := range l.Tables {
+			if err := table.check(t); err != nil {
+				return fmt.Errorf("page %s version %s: %w", page, l.Version, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (t *TableRule) check(target reflect.Type) error {
+	f, err := fieldType(target, t.Field)
+	if err != nil {
+		return err
+	}
+	if f.Kind() != reflect.Slice || f.Elem().Kind() != reflect.Pointer {
+		return fmt.Errorf("field %s: %s is not a slice of pointers", t.Field, f)
+	}
+	item := f.Elem()
+	for _, c := range t.Columns {
+		if err := checkField(item, c.Field, c.Type); err != nil {
+			return fmt.Errorf("table %s: %w", t.Field, err)
+		}
+	}
+	return nil
+}
+
+// checkField проверяет, что в поле path можно записать значение типа typ
+func checkField(target reflect.Type, path, typ string) error {
+	f, err := fieldType(target, path)
+	if err != nil {
+		return err
+	}
+	val := valueType(typ)
+	if !val.AssignableTo(f) {
+		return fmt.Errorf("field %s: can not set %s to %s", path, val, f)
+	}
+	return nil
+}
+
+// fieldType тип поля по пути из json-тегов, как его найдет field
+func fieldType(t reflect.Type, path string) (reflect.Type, error) {
+	for _, name := range strings.Split(path, ".") {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("field %s: %s is not a struct", path, t)
+		}
+		i, ok := fieldIndex(t, name)
+		if !ok {
+			return nil, fmt.Errorf("field %s: no field %s in %s", path, name, t)
+		}
+		t = t.Field(i).Type
+	}
+	return t, nil
+}
+
+// valueType тип значения, которое convert возвращает для типа правила
+func valueType(typ string) reflect.Type {
+	switch typ {
+	case TypePrice:
+		return reflect.TypeOf(float64(0))
+	case TypeDate:
+		return reflect.TypeOf(time.Time{})
+	default:
+		return reflect.TypeOf("")
+	}
+}
+
+func setField(target reflect.Value, path string, value any) error {
+	f, err := field(target, path)
+	if err != nil {
+		return err
+	}
+	val := reflect.ValueOf(value)
+	if !val.Type().AssignableTo(f.Type()) {
+		return fmt.Errorf("field %s: can not set %s to %s", path, val.Type(), f.Type())
+	}
+	f.Set(val)
+	return nil
+}
+
+// appendItem добавляет в срез указателей path новый элемент и возвращает его
+func appendItem(target reflect.Value, path string) (reflect.Value, error) {
+	f, err := field(target, path)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if f.Kind() != reflect.Slice || f.Type().Elem().Kind() != reflect.Pointer {
+		return reflect.Value{}, fmt.Errorf("field %s: %s is not a slice of pointers", path, f.Type())
+	}
+	item := reflect.New(f.Type().Elem().Elem())
+	f.Set(reflect.Append(f, item))
+	return item, nil
+}
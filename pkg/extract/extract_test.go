@@ -0,0 +1,279 @@
+package extract_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tim8842/tender-data-loader/pkg/extract"
+)
+
+type item struct {
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+type owner struct {
+	INN string `json:"inn"`
+}
+
+type page struct {
+	Number   string    `json:"number"`
+	Price    float64   `json:"price"`
+	SignedAt time.Time `json:"signed_at"`
+	Link     string    `json:"link"`
+	Works    string    `json:"works"`
+	Owner    *owner    `json:"owner"`
+	Items    []*item   `json:"items"`
+}
+
+const rulesYAML = `
+pages:
+  card:
+    - version: old
+      extends: new
+      match: {selector: th, text: Стоимость}
+      tables:
+        - field: items
+          header_selector: .header
+          header_text: Товары
+          columns:
+            - {titles: [Товар], field: name}
+            - {titles: [Стоимость], field: price, type: price}
+    - version: new
+      fields:
+        - field: number
+          selector: .number
+          post: [trim, trim_prefix: "№", remove_spaces]
+        - field: price
+          selector: .price
+          post: [remove: "₽"]
+          type: price
+        - field: signed_at
+          selector: .date
+          index: 1
+          post: [remove_spaces, split: {sep: "—", part: 1}]
+          type: date
+        - field: link
+          title: Ссылка
+          selector: a
+          param: id
+        - field: works
+          title: Виды деятельности
+          children: true
+          join: ","
+        - field: owner.inn
+          title: "ИНН/КПП:"
+          tag: td
+          post: [split: {sep: "/", part: 0}]
+      tables:
+        - field: items
+          header_selector: .header
+          header_text: Товары
+          columns:
+            - titles: [Товар]
+              field: name
+            - titles: ["Цена, руб"]
+              field: price
+              type: price
+`
+
+const newPage = `<html><body>
+<span class="number">№ 123 456</span>
+<span class="price">нет</span><span class="price">1 500,50 ₽</span>
+<span class="date">01.01.2025</span><span class="date">01.02.2025 — 10.03.2025</span>
+<div><span>Ссылка</span><span><a href="/view.html?id=42">карточка</a></span></div>
+<div><span>Виды деятельности</span><span><b> 85.31 </b><b>87.90</b></span></div>
+<table><tr><td>ИНН/КПП:</td><td>7705013033/770501001</td></tr></table>
+<div><div class="header">Товары</div></div>
+<div><table>
+<tr><th>Товар</th><th>Цена, руб</th></tr>
+<tr><td>Бумага</td><td>10,5</td></tr>
+<tr><td>Ручки</td><td>3</td></tr>
+</table></div>
+</body></html>`
+
+const oldPage = `<html><body>
+<span class="number">№ 77</span>
+<div><div class="header">Товары</div></div>
+<div><table>
+<tr><th>Товар</th><th>Стоимость</th></tr>
+<tr><td>Уголь</td><td>100</td></tr>
+</table></div>
+</body></html>`
+
+func doc(t *testing.T, html string) *goquery.Document {
+	d, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	require.NoError(t, err)
+	return d
+}
+
+func TestRules_Apply(t *testing.T) {
+	rules, err := extract.Load([]byte(rulesYAML))
+	require.NoError(t, err)
+	tests := []struct {
+		name            string
+		html            string
+		expectedVersion string
+		expected        *page
+	}{
+		{
+			name:            "new layout",
+			html:            newPage,
+			expectedVersion: "new",
+			expected: &page{
+				Number: "123456", Price: 1500.50,
+				SignedAt: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+				Link:     "42", Works: "85.31,87.90",
+				Owner: &owner{INN: "7705013033"},
+				Items: []*item{{Name: "Бумага", Price: 10.5}, {Name: "Ручки", Price: 3}},
+			},
+		},
+		{
+			name:            "old layout inherits fields",
+			html:            oldPage,
+			expectedVersion: "old",
+			expected: &page{
+				Number: "77", Price: 9,
+				Items: []*item{{Name: "Уголь", Price: 100}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Ненайденные поля не затираются
+			target := &page{Price: 9}
+			version, err := rules.Apply("card", doc(t, tt.html), target)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedVersion, version)
+			assert.Equal(t, tt.expected, target)
+		})
+	}
+}
+
+func TestRules_ApplyErrors(t *testing.T) {
+	rules, err := extract.Load([]byte(`
+pages:
+  card:
+    - version: "1"
+      fields:
+        - {field: missing, selector: .number}
+  typed:
+    - version: "1"
+      fields:
+        - {field: number, selector: .price, type: price}
+  strict:
+    - version: "1"
+      match: {selector: .absent}
+      fields:
+        - {field: number, selector: .number}
+`))
+	require.NoError(t, err)
+	_, err = rules.Apply("card", doc(t, newPage), &page{})
+	assert.ErrorContains(t, err, "no field missing")
+	_, err = rules.Apply("typed", doc(t, newPage), &page{})
+	assert.ErrorContains(t, err, "can not set float64")
+	_, err = rules.Apply("strict", doc(t, newPage), &page{})
+	assert.ErrorIs(t, err, extract.ErrNoLayout)
+	_, err = rules.Apply("unknown", doc(t, newPage), &page{})
+	assert.ErrorIs(t, err, extract.ErrNoLayout)
+}
+
+func TestRules_Check(t *testing.T) {
+	rules, err := extract.Load([]byte(rulesYAML))
+	require.NoError(t, err)
+	assert.NoError(t, rules.Check("card", &page{}))
+	assert.NoError(t, rules.Check("unknown", &page{}))
+
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{
+			name: "unknown field",
+			yaml: "pages: {card: [{version: a, fields: [{field: owner.kpp, selector: .n}]}]}",
+			err:  "page card version a: field owner.kpp: no field kpp in extract_test.owner",
+		},
+		{
+			name: "wrong type",
+			yaml: "pages: {card: [{version: a, fields: [{field: number, selector: .n, type: date}]}]}",
+			err:  "can not set time.Time to string",
+		},
+		{
+			name: "path through scalar",
+			yaml: "pages: {card: [{version: a, fields: [{field: number.inn, selector: .n}]}]}",
+			err:  "string is not a struct",
+		},
+		{
+			name: "table not a slice",
+			yaml: "pages: {card: [{version: a, tables: [{field: owner, header_selector: .h, columns: [{titles: [a], field: inn}]}]}]}",
+			err:  "is not a slice of pointers",
+		},
+		{
+			name: "unknown column",
+			yaml: "pages: {card: [{version: a, tables: [{field: items, header_selector: .h, columns: [{titles: [a], field: cost}]}]}]}",
+			err:  "table items: field cost: no field cost",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := extract.Load([]byte(tt.yaml))
+			require.NoError(t, err)
+			assert.ErrorContains(t, rules.Check("card", &page{}), tt.err)
+		})
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{
+			name: "unknown step",
+			yaml: "pages: {card: [{version: a, fields: [{field: number, selector: .n, post: [upper]}]}]}",
+			err:  `unknown post step "upper"`,
+		},
+		{
+			name: "unknown type",
+			yaml: "pages: {card: [{version: a, fields: [{field: number, selector: .n, type: int}]}]}",
+			err:  `unknown type "int"`,
+		},
+		{
+			name: "no selector",
+			yaml: "pages: {card: [{version: a, fields: [{field: number}]}]}",
+			err:  "selector or title required",
+		},
+		{
+			name: "unknown base",
+			yaml: "pages: {card: [{version: a, extends: b}]}",
+			err:  "extends unknown version b",
+		},
+		{
+			name: "cycle",
+			yaml: "pages: {card: [{version: a, extends: b}, {version: b, extends: a}]}",
+			err:  "extends cycle",
+		},
+		{
+			name: "duplicate version",
+			yaml: "pages: {card: [{version: a}, {version: a}]}",
+			err:  "duplicate version a",
+		},
+		{
+			name: "bad yaml",
+			yaml: "pages: [",
+			err:  "parse rules",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := extract.Load([]byte(tt.yaml))
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"gopkg.in/yaml.v3"
+)
+
+// Правила извлечения полей из html-страниц. Для каждой страницы задается список
+// версий разметки, используется первая версия, чье условие match выполняется.
+// Версия без match подходит к любой странице, поэтому ее ставят последней
+
+var ErrNoLayout = errors.New("no layout matches page")
+
+// Rules правила по страницам: имя страницы → версии разметки
+type Rules struct {
+	Pages map[string][]*Layout `yaml:"pages"`
+}
+
+// Layout одна версия разметки страницы
+type Layout struct {
+	Version string       `yaml:"version"`
+	Extends string       `yaml:"extends"` // версия той же страницы, чьи правила берутся за основу
+	Match   *Match       `yaml:"match"`
+	Fields  []*Rule      `yaml:"fields"`
+	Tables  []*TableRule `yaml:"tables"`
+}
+
+// Match условие выбора версии: на странице есть элемент selector, содержащий text
+type Match struct {
+	Selector string `yaml:"selector"`
+	Text     string `yaml:"text"`
+}
+
+// Rule правило одного поля. Элемент ищется css-селектором selector или по подписи:
+// title в теге tag (по умолчанию span), значение — следующий такой же тег (parser.GetFromHtmlByTitle).
+// Для найденного по подписи элемента selector ищет уже внутри него
+type Rule struct {
+	Field    string `yaml:"field"` // путь по json-тегам цели, например customer.inn
+	Selector string `yaml:"selector"`
+	Title    string `yaml:"title"`
+	Tag      string `yaml:"tag"`
+	Index    *int   `yaml:"index"`    // брать только элемент с этим номером
+	Attr     string `yaml:"attr"`     // значение атрибута вместо текста
+	Param    string `yaml:"param"`    // параметр ссылки из атрибута (по умолчанию href)
+	Children bool   `yaml:"children"` // склеить текст дочерних элементов через join
+	Join     string `yaml:"join"`
+	Post     []Step `yaml:"post"`
+	Type     string `yaml:"type"` // string (по умолчанию), price или date
+}
+
+// TableRule таблица в блоке, следующем за заголовком. Каждая строка после первой
+// дает новый элемент среза field, ячейки разбираются по заголовкам колонок
+type TableRule struct {
+	Field          string        `yaml:"field"`
+	HeaderSelector string        `yaml:"header_selector"`
+	HeaderText     string        `yaml:"header_text"`
+	Columns        []*ColumnRule `yaml:"columns"`
+}
+
+// ColumnRule правило ячейки: заголовки колонки (любой из) и поле элемента
+type ColumnRule struct {
+	Titles []string `yaml:"titles"`
+	Field  string   `yaml:"field"`
+	Post   []Step   `yaml:"post"`
+	Type   string   `yaml:"type"`
+}
+
+// Load разбирает правила из yaml и проверяет их
+func Load(data []byte) (*Rules, error) {
+	r := &Rules{}
+	if err := yaml.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("parse rules: %w", err)
+	}
+	if err := r.resolve(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// LoadFile читает правила из файла
+func LoadFile(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Load(data)
+}
+
+// Layout выбирает версию разметки страницы page для документа
+func (r *Rules) Layout(page string, doc *goquery.Document) (*Layout, error) {
+	for _, l := range r.Pages[page] {
+		if l.Match.matches(doc) {
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNoLayout, page)
+}
+
+func (m *Match) matches(doc *goquery.Document) bool {
+	if m == nil {
+		return true
+	}
+	found := false
+	doc.Find(m.Selector).EachWithBreak(func(i int, s *goquery.Selection) bool {
+		found = m.Text == "" || strings.Contains(s.Text(), m.Text)
+		return !found
+	})
+	return found
+}
+
+// resolve подставляет правила базовых версий и проверяет правила
+func (r *Rules) resolve() error {
+	for page, layouts := range r.Pages {
+		byVersion := map[string]*Layout{}
+		for _, l := range layouts {
+			if l.Version == "" {
+				return fmt.Errorf("page %s: layout without version", page)
+			}
+			if _, ok := byVersion[l.Version]; ok {
+				return fmt.Errorf("page %s: duplicate version %s", page, l.Version)
+			}
+			byVersion[l.Version] = l
+		}
+		for _, l := range layouts {
+			if err := l.inherit(byVersion, map[string]bool{}); err != nil {
+				return fmt.Errorf("page %s: %w", page, err)
+			}
+		}
+		for _, l := range layouts {
+			if err := l.validate(); err != nil {
+				return fmt.Errorf("page %s version %s: %w", page, l.Version, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (l *Layout) inherit(byVersion map[string]*Layout, seen map[string]bool) error {
+	if l.Extends == "" {
+		return nil
+	}
+	if seen[l.Version] {
+		return fmt.Errorf("extends cycle at version %s", l.Version)
+	}
+	seen[l.Version] = true
+	base, ok := byVersion[l.Extends]
+	if !ok {
+		return fmt.Errorf("version %s extends unknown version %s", l.Version, l.Extends)
+	}
+	if err := base.inherit(byVersion, seen); err != nil {
+		return err
+	}
+	l.Fields = mergeByField(base.Fields, l.Fields, func(r *Rule) string { return r.Field })
+	l.Tables = mergeByField(base.Tables, l.Tables, func(t *TableRule) string { return t.Field })
+	l.Extends = ""
+	return nil
+}
+
+// mergeByField правила базы, замененные одноименными правилами версии, и новые правила версии
+func mergeByField[T any](base, own []T, field func(T) string) []T {
+	res := make([]T, 0, len(base)+len(own))
+	overridden := map[string]bool{}
+	for _, o := range own {
+		overridden[field(o)] = true
+	}
+	for _, b := range base {
+		if !overridden[field(b)] {
+			res = append(res, b)
+		}
+	}
+	return append(res, own...)
+}
+
+func (l *Layout) validate() error {
+	if l.Match != nil && l.Match.Selector == "" {
+		return errors.New("match without selector")
+	}
+	for _, f := range l.Fields {
+		if f.Field == "" {
+			return errors.New("rule without field")
+		}
+		if f.Selector == "" && f.Title == "" {
+			return fmt.Errorf("field %s: selector or title required", f.Field)
+		}
+		if err := validatePost(f.Post, f.Type); err != nil {
+			return fmt.Errorf("field %s: %w", f.Field, err)
+		}
+	}
+	for _, t := range l.Tables {
+		if t.Field == "" || t.HeaderSelector == "" {
+			return errors.New("table without field or header_selector")
+		}
+		for _, c := range t.Columns {
+			if c.Field == "" || len(c.Titles) == 0 {
+				return fmt.Errorf("table %s: column without field or titles", t.Field)
+			}
+			if err := validatePost(c.Post, c.Type); err != nil {
+				return fmt.Errorf("table %s column %s: %w", t.Field, c.Field, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validatePost(post []Step, typ string) error {
+	for _, s := range post {
+		if _, ok := steps[s.Op]; !ok {
+			return fmt.Errorf("unknown post step %q", s.Op)
+		}
+	}
+	switch typ {
+	case "", TypeString, TypePrice, TypeDate:
+		return nil
+	default:
+		return fmt.Errorf("unknown type %q", typ)
+	}
+}
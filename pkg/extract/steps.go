@@ -0,0 +1,89 @@
+package extract
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Типы значений: строка кладется как есть, price и date разбираются
+// через parser.ParsePriceToFloat и parser.ParseFromDateToTime
+const (
+	TypeString = "string"
+	TypePrice  = "price"
+	TypeDate   = "date"
+)
+
+// Step шаг постобработки строки. В yaml записывается именем (trim)
+// или именем с аргументом (trim_prefix: "№", split: {sep: "/", part: 0})
+type Step struct {
+	Op   string
+	Arg  string
+	Sep  string
+	Part int
+	Old  string
+	New  string
+}
+
+func (s *Step) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		s.Op = node.Value
+		return nil
+	case yaml.MappingNode:
+		if len(node.Content) != 2 {
+			return fmt.Errorf("line %d: post step must have one key", node.Line)
+		}
+		s.Op = node.Content[0].Value
+		arg := node.Content[1]
+		if arg.Kind == yaml.ScalarNode {
+			s.Arg = arg.Value
+			return nil
+		}
+		var opts struct {
+			Sep  string `yaml:"sep"`
+			Part int    `yaml:"part"`
+			Old  string `yaml:"old"`
+			New  string `yaml:"new"`
+		}
+		if err := arg.Decode(&opts); err != nil {
+			return err
+		}
+		s.Sep, s.Part, s.Old, s.New = opts.Sep, opts.Part, opts.Old, opts.New
+		return nil
+	default:
+		return fmt.Errorf("line %d: bad post step", node.Line)
+	}
+}
+
+var steps = map[string]func(v string, s Step) string{
+	// trim обрезает пробелы по краям
+	"trim": func(v string, s Step) string { return strings.TrimSpace(v) },
+	// collapse схлопывает пробельные символы в один пробел
+	"collapse": func(v string, s Step) string { return strings.Join(strings.Fields(v), " ") },
+	// nbsp заменяет неразрывные пробелы обычными
+	"nbsp": func(v string, s Step) string { return strings.ReplaceAll(v, "\u00A0", " ") },
+	// remove_spaces удаляет пробелы, в том числе неразрывные
+	"remove_spaces": func(v string, s Step) string {
+		return strings.ReplaceAll(strings.ReplaceAll(v, " ", ""), "\u00A0", "")
+	},
+	"remove":      func(v string, s Step) string { return strings.ReplaceAll(v, s.Arg, "") },
+	"trim_prefix": func(v string, s Step) string { return strings.TrimPrefix(v, s.Arg) },
+	"replace":     func(v string, s Step) string { return strings.ReplaceAll(v, s.Old, s.New) },
+	// split берет часть part строки, разрезанной по sep. Если частей меньше — пустая строка
+	"split": func(v string, s Step) string {
+		parts := strings.Split(v, s.Sep)
+		if s.Part < 0 || s.Part >= len(parts) {
+			return ""
+		}
+		return parts[s.Part]
+	},
+}
+
+func applySteps(v string, post []Step) string {
+	for _, s := range post {
+		v = steps[s.Op](v, s)
+	}
+	return v
+}
@@ -0,0 +1,180 @@
+package ftp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Коды ответов сервера, с которыми работает клиент
+const (
+	codeReady          = 220
+	codeLoggedIn       = 230
+	codeNeedPassword   = 331
+	codeOK             = 200
+	codePassive        = 227
+	codeTransferDone   = 2 // любой 2xx после передачи
+	codeTransferOpened = 1 // 125 или 150 перед передачей
+	anonymousUser      = "anonymous"
+	anonymousPassword  = "anonymous@"
+)
+
+// Conn минимальный FTP-клиент: вход, двоичный режим, пассивные передачи NLST и RETR.
+// Этого хватает, чтобы читать выгрузки с ftp.zakupki.gov.ru. Не безопасен для
+// использования из нескольких горутин
+type Conn struct {
+	ctx     context.Context
+	text    *textproto.Conn
+	host    string
+	unwatch func() bool
+}
+
+// Dial подключается к addr (host:port) и входит под user. Пустой user — вход anonymous.
+// Отмена ctx прерывает подключение и все последующие команды
+func Dial(ctx context.Context, addr, user, password string) (*Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	c := &Conn{ctx: ctx, text: textproto.NewConn(conn), host: host, unwatch: watch(ctx, conn)}
+	if err := c.login(user, password); err != nil {
+		c.close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Conn) login(user, password string) error {
+	if _, _, err := c.text.ReadResponse(codeReady); err != nil {
+		return err
+	}
+	if user == "" {
+		user, password = anonymousUser, anonymousPassword
+	}
+	code, _, err := c.cmd(0, "USER %s", user)
+	if err != nil {
+		return err
+	}
+	switch code {
+	case codeLoggedIn:
+	case codeNeedPassword:
+		if _, _, err := c.cmd(codeLoggedIn, "PASS %s", password); err != nil {
+			return err
+		}
+	default:
+		return &textproto.Error{Code: code, Msg: "unexpected reply to USER"}
+	}
+	_, _, err = c.cmd(codeOK, "TYPE I")
+	return err
+}
+
+// NameList имена в каталоге dir, как их отдает NLST
+func (c *Conn) NameList(dir string) ([]string, error) {
+	body, err := c.transfer("NLST " + dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, line := range strings.Split(string(body), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			names = append(names, line)
+		}
+	}
+	return names, nil
+}
+
+// Retr файл целиком
+func (c *Conn) Retr(path string) ([]byte, error) {
+	return c.transfer("RETR " + path)
+}
+
+// Quit завершает сеанс и закрывает соединение
+func (c *Conn) Quit() error {
+	_, _, err := c.cmd(0, "QUIT")
+	if closeErr := c.close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (c *Conn) close() error {
+	c.unwatch()
+	return c.text.Close()
+}
+
+// transfer открывает пассивное соединение, отправляет команду и читает данные до конца
+func (c *Conn) transfer(command string) ([]byte, error) {
+	_, msg, err := c.cmd(codePassive, "PASV")
+	if err != nil {
+		return nil, err
+	}
+	port, err := passivePort(msg)
+	if err != nil {
+		return nil, err
+	}
+	// Адрес из ответа PASV не используем: за NAT сервер часто отдает внутренний
+	var d net.Dialer
+	data, err := d.DialContext(c.ctx, "tcp", net.JoinHostPort(c.host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	defer data.Close()
+	defer watch(c.ctx, data)()
+	if _, _, err := c.cmd(codeTransferOpened, "%s", command); err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(data)
+	if err != nil {
+		return nil, err
+	}
+	data.Close()
+	if _, _, err := c.text.ReadResponse(codeTransferDone); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// cmd отправляет команду и ждет ответ с кодом expect, 0 — любой
+func (c *Conn) cmd(expect int, format string, args ...any) (int, string, error) {
+	if err := c.text.PrintfLine(format, args...); err != nil {
+		return 0, "", err
+	}
+	return c.text.ReadResponse(expect)
+}
+
+// watch переносит срок и отмену ctx на соединение, возвращает функцию снятия отмены
+func watch(ctx context.Context, conn net.Conn) func() bool {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	return context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+}
+
+// passivePort порт из ответа "Entering Passive Mode (h1,h2,h3,h4,p1,p2)"
+func passivePort(msg string) (int, error) {
+	start, end := strings.Index(msg, "("), strings.LastIndex(msg, ")")
+	if start < 0 || end < start {
+		return 0, fmt.Errorf("bad PASV reply: %s", msg)
+	}
+	parts := strings.Split(msg[start+1:end], ",")
+	if len(parts) != 6 {
+		return 0, fmt.Errorf("bad PASV reply: %s", msg)
+	}
+	hi, err1 := strconv.Atoi(strings.TrimSpace(parts[4]))
+	lo, err2 := strconv.Atoi(strings.TrimSpace(parts[5]))
+	if err1 != nil || err2 != nil || hi < 0 || hi > 255 || lo < 0 || lo > 255 {
+		return 0, fmt.Errorf("bad PASV reply: %s", msg)
+	}
+	return hi<<8 | lo, nil
+}
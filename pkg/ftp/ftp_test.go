@@ -0,0 +1,133 @@
+package ftp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeServer FTP-сервер с файлами в памяти, пароль "free" или вход anonymous
+func fakeServer(t *testing.T, files map[string]string) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn, files)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serve(conn net.Conn, files map[string]string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(format string, args ...any) { fmt.Fprintf(conn, format+"\r\n", args...) }
+	reply("220 ready")
+	var user string
+	var data net.Listener
+	send := func(body string) {
+		reply("150 opening data connection")
+		c, err := data.Accept()
+		if err != nil {
+			return
+		}
+		c.Write([]byte(body))
+		c.Close()
+		data.Close()
+		reply("226 transfer complete")
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch cmd {
+		case "USER":
+			user = arg
+			if user == "anonymous" {
+				reply("230 logged in")
+			} else {
+				reply("331 password required")
+			}
+		case "PASS":
+			if arg == "free" {
+				reply("230 logged in")
+			} else {
+				reply("530 login incorrect")
+			}
+		case "TYPE":
+			reply("200 type set")
+		case "PASV":
+			data, _ = net.Listen("tcp", "127.0.0.1:0")
+			port := data.Addr().(*net.TCPAddr).Port
+			reply("227 Entering Passive Mode (10,0,0,1,%d,%d)", port>>8, port&0xff)
+		case "NLST":
+			var names []string
+			for name := range files {
+				if strings.HasPrefix(name, arg) {
+					names = append(names, name)
+				}
+			}
+			send(strings.Join(names, "\r\n") + "\r\n")
+		case "RETR":
+			body, ok := files[arg]
+			if !ok {
+				data.Close()
+				reply("550 file not found")
+				continue
+			}
+			send(body)
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestConn(t *testing.T) {
+	addr := fakeServer(t, map[string]string{"/out/a.zip": "zip a"})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, err := Dial(ctx, addr, "free", "free")
+	assert.NoError(t, err)
+	names, err := c.NameList("/out/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/out/a.zip"}, names)
+	body, err := c.Retr("/out/a.zip")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("zip a"), body)
+	_, err = c.Retr("/out/missing.zip")
+	assert.ErrorContains(t, err, "550")
+	assert.NoError(t, c.Quit())
+
+	_, err = Dial(ctx, addr, "free", "wrong")
+	assert.ErrorContains(t, err, "530")
+	c, err = Dial(ctx, addr, "", "")
+	assert.NoError(t, err)
+	assert.NoError(t, c.Quit())
+}
+
+func TestPassivePort(t *testing.T) {
+	port, err := passivePort("Entering Passive Mode (192,168,1,10,195,80).")
+	assert.NoError(t, err)
+	assert.Equal(t, 195*256+80, port)
+	_, err = passivePort("Entering Passive Mode")
+	assert.Error(t, err)
+	_, err = passivePort("Entering Passive Mode (1,2,3,4,300,1)")
+	assert.Error(t, err)
+}
@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Middleware считает запросы к API и время их обработки
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+		code := c.Response().StatusCode()
+		if err != nil {
+			// Код ответа выставит обработчик ошибок fiber уже после middleware
+			code = fiber.StatusInternalServerError
+			if e, ok := err.(*fiber.Error); ok {
+				code = e.Code
+			}
+		}
+		route := c.Route().Path
+		HTTPRequests.WithLabelValues(c.Method(), route, strconv.Itoa(code)).Inc()
+		HTTPDuration.WithLabelValues(c.Method(), route).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// Handler отдает метрики в формате Prometheus
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.Handler())
+}
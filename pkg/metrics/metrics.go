@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "tender"
+
+// Загрузка страниц zakupki. target — вид страницы (search, contract, print_form...),
+// proxy — direct или proxy, code — статус ответа или error для сетевых ошибок
+var (
+	FetchRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "fetch_requests_total",
+		Help: "HTTP-запросы к страницам источника",
+	}, []string{"target", "code", "proxy"})
+	FetchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Name: "fetch_duration_seconds",
+		Help:    "Время загрузки страницы источника",
+		Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"target", "proxy"})
+)
+
+// Повторы функций в pkg.RetryWithPolicy. func — тип обернутой функции
+var (
+	Retries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "retries_total",
+		Help: "Повторные попытки выполнения функций",
+	}, []string{"func"})
+	RetriesExhausted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "retries_exhausted_total",
+		Help: "Функции, не выполненные после всех повторов",
+	}, []string{"func"})
+)
+
+// ParseFailures ошибки парсеров страниц, parser — имя функции парсера
+var ParseFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace, Name: "parse_failures_total",
+	Help: "Ошибки разбора страниц",
+}, []string{"parser"})
+
+// DocumentsUpserted документы, записанные массовым upsert. result — inserted или updated
+var DocumentsUpserted = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace, Name: "documents_upserted_total",
+	Help: "Документы, вставленные или измененные при сохранении",
+}, []string{"collection", "result"})
+
+// TaskDuration длительность запусков задач раннера. result — ok, error или cancelled
+var TaskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace, Name: "task_duration_seconds",
+	Help:    "Время выполнения задач",
+	Buckets: []float64{1, 10, 30, 60, 300, 900, 1800, 3600, 3 * 3600, 6 * 3600},
+}, []string{"task", "result"})
+
+// Курсор догрузки: день заключения (unix-время) и страница поиска
+var (
+	CursorDate = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace, Name: "backfill_cursor_date_seconds",
+		Help: "Дата, до которой дошла догрузка",
+	}, []string{"task"})
+	CursorPage = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace, Name: "backfill_cursor_page",
+		Help: "Страница поиска, на которой стоит догрузка",
+	}, []string{"task"})
+)
+
+// Запросы к API сервиса. route — шаблон маршрута fiber, а не фактический путь
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "http_requests_total",
+		Help: "Запросы к API",
+	}, []string{"method", "route", "code"})
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Name: "http_request_duration_seconds",
+		Help:    "Время обработки запросов к API",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// FuncName короткое имя функции для метки: agreement.ParseAgreementFromMain
+func FuncName(fn any) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return "unknown"
+	}
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return "unknown"
+	}
+	name := f.Name()
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// TypeName имя типа без указателя и пути пакета: uagent.GetPage
+func TypeName(v any) string {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return "unknown"
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	name := t.String()
+	// У дженериков в имени полный путь параметра: GetVariableById[github.com/.../variable.X]
+	if i := strings.Index(name, "["); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
@@ -0,0 +1,58 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/pkg/metrics"
+	"github.com/tim8842/tender-data-loader/pkg/parser"
+)
+
+type generic[T any] struct{}
+
+type plain struct{}
+
+func TestFuncName(t *testing.T) {
+	assert.Equal(t, "parser.ParsePriceToFloat", metrics.FuncName(parser.ParsePriceToFloat))
+	assert.Equal(t, "unknown", metrics.FuncName(nil))
+	assert.Equal(t, "unknown", metrics.FuncName("not a func"))
+}
+
+func TestTypeName(t *testing.T) {
+	assert.Equal(t, "metrics_test.plain", metrics.TypeName(&plain{}))
+	assert.Equal(t, "metrics_test.plain", metrics.TypeName(plain{}))
+	assert.Equal(t, "metrics_test.generic", metrics.TypeName(&generic[strings.Builder]{}))
+	assert.Equal(t, "unknown", metrics.TypeName(nil))
+}
+
+func TestMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(metrics.Middleware())
+	app.Get("/metrics", metrics.Handler())
+	app.Get("/items/:id", func(c *fiber.Ctx) error {
+		if c.Params("id") == "missing" {
+			return fiber.ErrNotFound
+		}
+		return c.SendString("ok")
+	})
+
+	for _, id := range []string{"1", "2", "missing"} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/items/"+id, nil))
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("GET", "/items/:id", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("GET", "/items/:id", "404")))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), `tender_http_requests_total{code="200",method="GET",route="/items/:id"} 2`)
+}
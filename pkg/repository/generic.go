@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 
+	"github.com/tim8842/tender-data-loader/pkg/metrics"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -63,7 +64,11 @@ func (r *GenericRepository[T]) BulkCreateOrUpdateMany(ctx context.Context, docs
 
 		models = append(models, replaceModel)
 	}
-	_, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	res, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if res != nil {
+		metrics.DocumentsUpserted.WithLabelValues(r.collection.Name(), "inserted").Add(float64(res.UpsertedCount))
+		metrics.DocumentsUpserted.WithLabelValues(r.collection.Name(), "updated").Add(float64(res.ModifiedCount))
+	}
 	if err != nil {
 		r.logger.Error("Failed to perform bulk upsert operations", zap.Error(err))
 		return err
@@ -128,10 +133,66 @@ func (r *GenericRepository[T]) List(ctx context.Context, filter interface{}, opt
 	return results, nil
 }
 
+// Stream finds documents matching a filter and passes them to fn one by one,
+// without loading the whole result into memory. Iteration stops on the first fn error
+func (r *GenericRepository[T]) Stream(ctx context.Context, filter interface{}, fn func(T) error, opts ...*options.FindOptions) error {
+	cursor, err := r.collection.Find(ctx, filter, opts...)
+	if err != nil {
+		r.logger.Error("Failed to stream documents", zap.Error(err))
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var elem T
+		if err := cursor.Decode(&elem); err != nil {
+			r.logger.Error("Failed to decode document from cursor", zap.Error(err))
+			return err
+		}
+		if err := fn(elem); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		r.logger.Error("Cursor error after iteration", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Aggregate runs an aggregation pipeline and decodes results into []bson.M
+func (r *GenericRepository[T]) Aggregate(ctx context.Context, pipeline interface{}) ([]bson.M, error) {
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		r.logger.Error("Failed to aggregate documents", zap.Error(err))
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []bson.M
+	if err := cursor.All(ctx, &results); err != nil {
+		r.logger.Error("Failed to decode aggregation results", zap.Error(err))
+		return nil, err
+	}
+	return results, nil
+}
+
 func (r *GenericRepository[T]) CountDocuments(ctx context.Context, filter interface{}) (int64, error) {
 	return r.collection.CountDocuments(ctx, filter)
 }
 
+// CreateIndexes creates the given indexes; existing ones with the same spec are left as is
+func (r *GenericRepository[T]) CreateIndexes(ctx context.Context, models ...mongo.IndexModel) error {
+	if len(models) == 0 {
+		return nil
+	}
+	_, err := r.collection.Indexes().CreateMany(ctx, models)
+	if err != nil {
+		r.logger.Error("Failed to create indexes", zap.Error(err))
+	}
+	return err
+}
+
 func (r *GenericRepository[T]) ReturnCollection() *mongo.Collection {
 	return r.collection
 }
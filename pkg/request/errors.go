@@ -0,0 +1,39 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// StatusError возвращается, когда сервер ответил статусом не из 2xx
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("неверный статус ответа: %d", e.StatusCode)
+}
+
+// IsRetryable сообщает, имеет ли смысл повторить запрос после ошибки:
+// повторяются 5xx, 408, 429, таймауты и сетевые ошибки,
+// остальные статусы, отмена контекста и прочие ошибки (например, парсинга) — нет
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 ||
+			statusErr.StatusCode == http.StatusRequestTimeout ||
+			statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
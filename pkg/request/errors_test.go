@@ -0,0 +1,35 @@
+package request_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/pkg/request"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil", nil, false},
+		{"500", &request.StatusError{StatusCode: 500}, true},
+		{"503 обернутая", fmt.Errorf("get page: %w", &request.StatusError{StatusCode: 503}), true},
+		{"429", &request.StatusError{StatusCode: 429}, true},
+		{"404", &request.StatusError{StatusCode: 404}, false},
+		{"таймаут", fmt.Errorf("ошибка при выполнении запроса: %w", context.DeadlineExceeded), true},
+		{"отмена", fmt.Errorf("ошибка при выполнении запроса: %w", context.Canceled), false},
+		{"сетевая ошибка", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"ошибка парсинга", errors.New("no correct data"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, request.IsRetryable(tt.err))
+		})
+	}
+}
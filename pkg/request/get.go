@@ -19,7 +19,9 @@ type RequestOptions struct {
 type IRequester interface {
 	Get(ctx context.Context, logger *zap.Logger, url string, timeout time.Duration, opts ...*RequestOptions) ([]byte, error)
 }
-type Requester struct{}
+type Requester struct {
+	Limiter *HostLimiter // ограничение частоты запросов, nil — общий лимитер из SetRateLimit
+}
 
 // getRequest выполняет HTTP GET-запрос к указанному URL и возвращает JSON-данные.
 //
@@ -34,6 +36,26 @@ type Requester struct{}
 //	 - ошибку (если произошла).
 
 func (d *Requester) Get(ctx context.Context, logger *zap.Logger, url string, timeout time.Duration, options ...*RequestOptions) ([]byte, error) {
+	// 0. Ждем своей очереди к хосту, время ожидания не входит в таймаут
+	limiter := d.Limiter
+	if limiter == nil {
+		limiter = defaultLimiter.Load()
+	}
+	if limiter != nil {
+		parsed, err := urlPackage.Parse(url)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при создании запроса: %w", err)
+		}
+		if err := limiter.Wait(ctx, parsed.Host); err != nil {
+			return nil, fmt.Errorf("ожидание лимита запросов прервано: %w", err)
+		}
+	}
+
+	if timing := timingFrom(ctx); timing != nil {
+		start := time.Now()
+		defer func() { timing.record(time.Since(start)) }()
+	}
+
 	// 1. Создаем контекст с таймаутом (если не передан)
 	if timeout > 0 {
 		var cancel context.CancelFunc
@@ -86,7 +108,7 @@ func (d *Requester) Get(ctx context.Context, logger *zap.Logger, url string, tim
 	// 5. Обрабатываем статус ответа
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		logger.Warn(fmt.Sprintf("Неверный статус ответа: %d", resp.StatusCode))
-		return nil, fmt.Errorf("неверный статус ответа: %d", resp.StatusCode)
+		return nil, &StatusError{StatusCode: resp.StatusCode}
 	}
 
 	// 6. Читаем тело ответа
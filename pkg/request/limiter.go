@@ -0,0 +1,87 @@
+package request
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HostLimiter ограничивает частоту запросов к каждому хосту (token bucket)
+type HostLimiter struct {
+	mu      sync.Mutex
+	rps     float64
+	burst   int
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewHostLimiter создает лимитер: rps запросов в секунду на хост, burst — запас без ожидания.
+// При rps <= 0 ограничение не применяется
+func NewHostLimiter(rps float64, burst int) *HostLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &HostLimiter{rps: rps, burst: burst, buckets: make(map[string]*tokenBucket), now: time.Now}
+}
+
+var defaultLimiter atomic.Pointer[HostLimiter]
+
+// SetRateLimit задает общий лимитер, который используют все Requester без своего лимитера
+func SetRateLimit(rps float64, burst int) {
+	defaultLimiter.Store(NewHostLimiter(rps, burst))
+}
+
+// Wait ждет, пока для хоста освободится токен, или отмены контекста
+func (l *HostLimiter) Wait(ctx context.Context, host string) error {
+	if l == nil || l.rps <= 0 {
+		return nil
+	}
+	wait := l.reserve(host)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.release(host)
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve забирает токен (баланс может уйти в минус) и возвращает время ожидания
+func (l *HostLimiter) reserve(host string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	b, ok := l.buckets[host]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.burst), last: now}
+		l.buckets[host] = b
+	}
+	b.tokens = math.Min(float64(l.burst), b.tokens+now.Sub(b.last).Seconds()*l.rps)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / l.rps * float64(time.Second))
+}
+
+// release возвращает токен, если запрос так и не был выполнен
+func (l *HostLimiter) release(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b, ok := l.buckets[host]; ok {
+		b.tokens = math.Min(float64(l.burst), b.tokens+1)
+	}
+}
@@ -0,0 +1,89 @@
+package request_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/pkg/request"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestHostLimiter_Wait(t *testing.T) {
+	limiter := request.NewHostLimiter(20, 2)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, limiter.Wait(ctx, "a"))
+	}
+	// два запроса из запаса, еще два ждут по 50мс
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 90*time.Millisecond)
+
+	// у другого хоста свой запас
+	start = time.Now()
+	assert.NoError(t, limiter.Wait(ctx, "b"))
+	assert.Less(t, time.Since(start), 20*time.Millisecond)
+}
+
+func TestHostLimiter_WaitCancelled(t *testing.T) {
+	limiter := request.NewHostLimiter(0.1, 1)
+	assert.NoError(t, limiter.Wait(context.Background(), "a"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.Wait(ctx, "a"), context.DeadlineExceeded)
+}
+
+func TestHostLimiter_Disabled(t *testing.T) {
+	var nilLimiter *request.HostLimiter
+	assert.NoError(t, nilLimiter.Wait(context.Background(), "a"))
+	assert.NoError(t, request.NewHostLimiter(0, 1).Wait(context.Background(), "a"))
+}
+
+func TestRequester_GetWithLimiter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	req := &request.Requester{Limiter: request.NewHostLimiter(0.1, 1)}
+	logger := zaptest.NewLogger(t)
+	_, err := req.Get(context.Background(), logger, server.URL, time.Second)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = req.Get(ctx, logger, server.URL, time.Second)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// Ожидание лимитера не входит во время запроса, по которому оцениваются прокси
+func TestRequester_GetTimingExcludesLimiter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	req := &request.Requester{Limiter: request.NewHostLimiter(10, 1)}
+	logger := zaptest.NewLogger(t)
+	_, err := req.Get(context.Background(), logger, server.URL, time.Second)
+	assert.NoError(t, err)
+
+	ctx, timing := request.WithTiming(context.Background())
+	start := time.Now()
+	_, err = req.Get(ctx, logger, server.URL, time.Second)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+	network, ok := timing.Network()
+	assert.True(t, ok)
+	assert.Less(t, network, 50*time.Millisecond)
+
+	_, timing = request.WithTiming(context.Background())
+	_, ok = timing.Network()
+	assert.False(t, ok)
+}
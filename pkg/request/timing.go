@@ -0,0 +1,38 @@
+package request
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+type timingKey struct{}
+
+// Timing время сетевой части запроса: от отправки после ожидания лимитера до чтения тела.
+// По нему оценивается задержка прокси, ожидание своей очереди к хосту в него не входит
+type Timing struct {
+	network  atomic.Int64
+	recorded atomic.Bool
+}
+
+// WithTiming добавляет в контекст Timing, который заполнит Requester.Get
+func WithTiming(ctx context.Context) (context.Context, *Timing) {
+	t := &Timing{}
+	return context.WithValue(ctx, timingKey{}, t), t
+}
+
+// Network время сетевой части запроса. false — запрос до сети не дошел,
+// например страница взята из архива или ожидание лимитера прервано
+func (t *Timing) Network() (time.Duration, bool) {
+	return time.Duration(t.network.Load()), t.recorded.Load()
+}
+
+func (t *Timing) record(d time.Duration) {
+	t.network.Store(int64(d))
+	t.recorded.Store(true)
+}
+
+func timingFrom(ctx context.Context) *Timing {
+	t, _ := ctx.Value(timingKey{}).(*Timing)
+	return t
+}
@@ -0,0 +1,39 @@
+package pkg
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule определяет время следующего запуска задачи
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+// MinEvery наименьший интервал Every: с нулевым Next вернул бы то же время,
+// и планировщик запускал бы задачу без пауз
+const MinEvery = time.Millisecond
+
+// Every запускает задачу с фиксированным интервалом, не меньше MinEvery
+func Every(interval time.Duration) Schedule {
+	return everySchedule{interval: max(interval, MinEvery)}
+}
+
+// Cron разбирает cron-выражение из 5 полей или дескриптор вида @hourly, @every 1h
+func Cron(expr string) (Schedule, error) {
+	s, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cron expression %q: %w", expr, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,45 @@
+package pkg_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tim8842/tender-data-loader/pkg"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	base := time.Date(2025, 6, 11, 10, 17, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		expr      string
+		every     time.Duration
+		expectErr bool
+		expected  time.Time
+	}{
+		{name: "every", every: 15 * time.Minute, expected: base.Add(15 * time.Minute)},
+		{name: "every zero clamped", every: 0, expected: base.Add(pkg.MinEvery)},
+		{name: "every negative clamped", every: -time.Minute, expected: base.Add(pkg.MinEvery)},
+		{name: "cron hourly", expr: "0 * * * *", expected: time.Date(2025, 6, 11, 11, 0, 0, 0, time.UTC)},
+		{name: "cron descriptor", expr: "@daily", expected: time.Date(2025, 6, 12, 0, 0, 0, 0, time.UTC)},
+		{name: "cron every", expr: "@every 1h", expected: base.Add(time.Hour)},
+		{name: "bad cron", expr: "61 * * * *", expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s pkg.Schedule
+			var err error
+			if tt.expr != "" {
+				s, err = pkg.Cron(tt.expr)
+			} else {
+				s = pkg.Every(tt.every)
+			}
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, s.Next(base))
+		})
+	}
+}
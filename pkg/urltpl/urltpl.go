@@ -0,0 +1,143 @@
+package urltpl
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrMissingValue не задано значение подстановки в пути адреса
+var ErrMissingValue = errors.New("missing value")
+
+var placeholderRe = regexp.MustCompile(`\{\{\s*([A-Za-z][A-Za-z0-9_]*)\s*\}\}`)
+
+// Template шаблон адреса с подстановками {{name}}.
+// Параметр запроса, в котором пустая подстановка, выкидывается целиком, а параметр
+// вида key={{name}} со списком значений повторяется: key=a&key=b. Порядок параметров
+// сохраняется, чтобы адрес совпадал с записанным в архиве страниц
+type Template struct {
+	raw    string
+	base   string
+	params []param
+}
+
+type param struct {
+	key   string
+	value string
+	eq    bool // был ли знак =, key без значения пишется как есть
+}
+
+// Parse разбирает шаблон. Если передан allowed, другие подстановки считаются ошибкой
+func Parse(raw string, allowed ...string) (*Template, error) {
+	if err := check(raw, allowed); err != nil {
+		return nil, err
+	}
+	t := &Template{raw: raw, base: raw}
+	base, query, ok := strings.Cut(raw, "?")
+	if !ok {
+		return t, nil
+	}
+	t.base = base
+	for _, part := range strings.Split(query, "&") {
+		if part == "" {
+			continue
+		}
+		key, value, eq := strings.Cut(part, "=")
+		t.params = append(t.params, param{key: key, value: value, eq: eq})
+	}
+	return t, nil
+}
+
+func check(raw string, allowed []string) error {
+	rest := placeholderRe.ReplaceAllString(raw, "")
+	if strings.Contains(rest, "{{") || strings.Contains(rest, "}}") {
+		return fmt.Errorf("bad placeholder in %q", raw)
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, m := range placeholderRe.FindAllStringSubmatch(raw, -1) {
+		if !slices.Contains(allowed, m[1]) {
+			return fmt.Errorf("unknown placeholder {{%s}}, allowed: %s", m[1], strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// String исходный шаблон
+func (t *Template) String() string {
+	return t.raw
+}
+
+// Names имена подстановок в порядке появления
+func (t *Template) Names() []string {
+	var names []string
+	for _, m := range placeholderRe.FindAllStringSubmatch(t.raw, -1) {
+		if !slices.Contains(names, m[1]) {
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// Expand подставляет значения. В пути все подстановки обязательны
+func (t *Template) Expand(values map[string][]string) (string, error) {
+	var missing error
+	base := placeholderRe.ReplaceAllStringFunc(t.base, func(s string) string {
+		name := placeholderRe.FindStringSubmatch(s)[1]
+		v := first(values[name])
+		if v == "" {
+			missing = fmt.Errorf("%w for {{%s}}", ErrMissingValue, name)
+		}
+		return url.PathEscape(v)
+	})
+	if missing != nil {
+		return "", missing
+	}
+	if len(t.params) == 0 {
+		return base, nil
+	}
+	parts := make([]string, 0, len(t.params))
+	for _, p := range t.params {
+		parts = append(parts, p.expand(values)...)
+	}
+	return base + "?" + strings.Join(parts, "&"), nil
+}
+
+func (p param) expand(values map[string][]string) []string {
+	for _, m := range placeholderRe.FindAllStringSubmatch(p.key+p.value, -1) {
+		if first(values[m[1]]) == "" {
+			return nil
+		}
+	}
+	if !p.eq {
+		return []string{substitute(p.key, values)}
+	}
+	key := substitute(p.key, values)
+	if m := placeholderRe.FindStringSubmatch(p.value); m != nil && m[0] == p.value {
+		list := make([]string, 0, len(values[m[1]]))
+		for _, v := range values[m[1]] {
+			if v != "" {
+				list = append(list, key+"="+url.QueryEscape(v))
+			}
+		}
+		return list
+	}
+	return []string{key + "=" + substitute(p.value, values)}
+}
+
+func substitute(s string, values map[string][]string) string {
+	return placeholderRe.ReplaceAllStringFunc(s, func(m string) string {
+		return url.QueryEscape(first(values[placeholderRe.FindStringSubmatch(m)[1]]))
+	})
+}
+
+func first(list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	return list[0]
+}
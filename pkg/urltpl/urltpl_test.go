@@ -0,0 +1,76 @@
+package urltpl_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tim8842/tender-data-loader/pkg/urltpl"
+)
+
+func TestTemplate_Expand(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		values   map[string][]string
+		expected string
+	}{
+		{
+			name:     "keeps order and literal params",
+			raw:      "https://example.com/search?fz223=on&from={{from}}&to={{to}}&page={{page}}&size=_50",
+			values:   map[string][]string{"from": {"01.02.2024"}, "to": {"02.02.2024"}, "page": {"3"}},
+			expected: "https://example.com/search?fz223=on&from=01.02.2024&to=02.02.2024&page=3&size=_50",
+		},
+		{
+			name:     "empty placeholder drops param",
+			raw:      "https://example.com/search?from={{from}}&page={{page}}&size=_{{perPage}}",
+			values:   map[string][]string{"page": {"1"}, "from": {""}},
+			expected: "https://example.com/search?page=1",
+		},
+		{
+			name:     "list repeats param",
+			raw:      "https://example.com/search?stage={{statuses}}&page={{page}}",
+			values:   map[string][]string{"statuses": {"0", "1"}, "page": {"1"}},
+			expected: "https://example.com/search?stage=0&stage=1&page=1",
+		},
+		{
+			name:     "placeholder in key and partial value",
+			raw:      "https://example.com/search?{{field}}From={{from}}&size=_{{perPage}}&flag",
+			values:   map[string][]string{"field": {"contractDate"}, "from": {"01.02.2024"}, "perPage": {"50"}},
+			expected: "https://example.com/search?contractDateFrom=01.02.2024&size=_50&flag",
+		},
+		{
+			name:     "escapes values",
+			raw:      "https://example.com/{{id}}?q={{q}}",
+			values:   map[string][]string{"id": {"a b"}, "q": {"x&y"}},
+			expected: "https://example.com/a%20b?q=x%26y",
+		},
+		{
+			name:     "no query",
+			raw:      "https://example.com/card",
+			expected: "https://example.com/card",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl, err := urltpl.Parse(tt.raw)
+			require.NoError(t, err)
+			url, err := tpl.Expand(tt.values)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, url)
+		})
+	}
+}
+
+func TestTemplate_Errors(t *testing.T) {
+	_, err := urltpl.Parse("https://example.com/?page={{page}", "page")
+	assert.ErrorContains(t, err, "bad placeholder")
+	_, err = urltpl.Parse("https://example.com/?page={{pgae}}", "page")
+	assert.ErrorContains(t, err, "unknown placeholder {{pgae}}")
+
+	tpl, err := urltpl.Parse("https://example.com/{{id}}?page={{page}}")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "page"}, tpl.Names())
+	_, err = tpl.Expand(map[string][]string{"page": {"1"}})
+	assert.ErrorIs(t, err, urltpl.ErrMissingValue)
+}
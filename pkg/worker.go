@@ -2,8 +2,14 @@ package pkg
 
 import (
 	"context"
+	"errors"
+	"math/rand"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/tim8842/tender-data-loader/pkg/metrics"
 	"go.uber.org/zap"
 )
 
@@ -12,28 +18,189 @@ type TaskHandler interface {
 	Process(ctx context.Context, logger *zap.Logger) error
 }
 
+const (
+	taskIdle int32 = iota
+	taskQueued
+	taskRunning
+)
+
+// Состояния задачи для внешнего наблюдения
+const (
+	TaskStateIdle    = "idle"
+	TaskStateQueued  = "queued"
+	TaskStateRunning = "running"
+	TaskStateFailed  = "failed"
+	TaskStatePaused  = "paused"
+)
+
+var (
+	ErrTaskNotFound    = errors.New("task not found")
+	ErrTaskPaused      = errors.New("task paused")
+	ErrTaskBusy        = errors.New("task already queued or running")
+	ErrTaskNotRunning  = errors.New("task not running")
+	ErrNoProgress      = errors.New("task does not report progress")
+	ErrShutdownTimeout = errors.New("tasks did not finish before shutdown deadline")
+	ErrRunnerStopped   = errors.New("task runner stopped")
+)
+
+type stoppingKey struct{}
+
+// Stopping сообщает задаче, что раннер останавливается: текущую пачку нужно
+// доделать и сохранить, а следующую не начинать
+func Stopping(ctx context.Context) bool {
+	ch, ok := ctx.Value(stoppingKey{}).(chan struct{})
+	if !ok {
+		return false
+	}
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// ProgressReporter задача, которая сообщает время последнего успешного шага,
+// например сохраненной пачки. Используется проверкой готовности
+type ProgressReporter interface {
+	LastProgress() time.Time
+}
+
+// TaskStatus снимок состояния задачи
+type TaskStatus struct {
+	Name           string     `json:"name"`
+	State          string     `json:"state"`
+	Paused         bool       `json:"paused"`
+	Scheduled      bool       `json:"scheduled"`
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	Runs           int64      `json:"runs"`
+	Failures       int64      `json:"failures"`
+	Cancels        int64      `json:"cancels"`
+}
+
+// TaskOption дополнительные настройки регистрируемой задачи
+type TaskOption func(*taskEntry)
+
+// WithSchedule запускает задачу по расписанию
+func WithSchedule(schedule Schedule) TaskOption {
+	return func(e *taskEntry) {
+		e.schedule = schedule
+	}
+}
+
+// WithJitter добавляет к каждому запуску по расписанию случайную задержку до jitter
+func WithJitter(jitter time.Duration) TaskOption {
+	return func(e *taskEntry) {
+		e.jitter = jitter
+	}
+}
+
+// WithRunOnStart ставит задачу в очередь сразу при старте, не дожидаясь расписания
+func WithRunOnStart() TaskOption {
+	return func(e *taskEntry) {
+		e.runOnStart = true
+	}
+}
+
+type taskEntry struct {
+	name       string
+	handler    TaskHandler
+	schedule   Schedule
+	jitter     time.Duration
+	runOnStart bool
+	state      atomic.Int32 // защита от наложения запусков одной задачи
+	paused     atomic.Bool
+
+	mu             sync.Mutex
+	cancel         context.CancelFunc
+	lastStartedAt  time.Time
+	lastFinishedAt time.Time
+	lastErr        error
+	runs           int64
+	failures       int64
+	cancels        int64
+}
+
+func (e *taskEntry) status(name string) TaskStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := TaskStatus{
+		Name:      name,
+		Paused:    e.paused.Load(),
+		Scheduled: e.schedule != nil,
+		Runs:      e.runs,
+		Failures:  e.failures,
+		Cancels:   e.cancels,
+	}
+	if !e.lastStartedAt.IsZero() {
+		t := e.lastStartedAt
+		st.LastStartedAt = &t
+	}
+	if !e.lastFinishedAt.IsZero() {
+		t := e.lastFinishedAt
+		st.LastFinishedAt = &t
+	}
+	if e.lastErr != nil {
+		st.LastError = e.lastErr.Error()
+	}
+	switch {
+	case e.state.Load() == taskRunning:
+		st.State = TaskStateRunning
+	case e.state.Load() == taskQueued:
+		st.State = TaskStateQueued
+	case st.Paused:
+		st.State = TaskStatePaused
+	case e.lastErr != nil:
+		st.State = TaskStateFailed
+	default:
+		st.State = TaskStateIdle
+	}
+	return st
+}
+
 // TaskRunner управляет запуском воркеров и тасок
 type TaskRunner struct {
 	logger      *zap.Logger
 	ctx         context.Context
 	workerCount int
 	tasksChan   chan string
-	handlers    map[string]TaskHandler
+	handlers    map[string]*taskEntry
 	wg          sync.WaitGroup
+	schedWg     sync.WaitGroup
+	sendWg      sync.WaitGroup // отправки из Trigger, канал задач закрывается после них
+	sendMu      sync.Mutex
+	stopped     bool
+	done        chan struct{}
+	alive       atomic.Int32 // сколько воркеров сейчас работает
+	cancel      context.CancelFunc
+	stopping    chan struct{}
+	stopOnce    sync.Once
+	drainOnce   sync.Once
 }
 
 func NewTaskRunner(ctx context.Context, logger *zap.Logger, workerCount int) *TaskRunner {
+	ctx, cancel := context.WithCancel(ctx)
+	stopping := make(chan struct{})
 	return &TaskRunner{
-		ctx:         ctx,
+		ctx:         context.WithValue(ctx, stoppingKey{}, stopping),
 		logger:      logger,
 		workerCount: workerCount,
 		tasksChan:   make(chan string),
-		handlers:    make(map[string]TaskHandler),
+		handlers:    make(map[string]*taskEntry),
+		done:        make(chan struct{}),
+		cancel:      cancel,
+		stopping:    stopping,
 	}
 }
 
-func (r *TaskRunner) RegisterTask(name string, handler TaskHandler) {
-	r.handlers[name] = handler
+func (r *TaskRunner) RegisterTask(name string, handler TaskHandler, opts ...TaskOption) {
+	entry := &taskEntry{name: name, handler: handler}
+	for _, opt := range opts {
+		opt(entry)
+	}
+	r.handlers[name] = entry
 }
 
 func (r *TaskRunner) Start() {
@@ -42,26 +209,263 @@ func (r *TaskRunner) Start() {
 	for i := 0; i < r.workerCount; i++ {
 		go r.worker(i + 1)
 	}
+	for name, entry := range r.handlers {
+		if entry.schedule == nil && !entry.runOnStart {
+			continue
+		}
+		r.schedWg.Add(1)
+		go r.scheduler(name, entry)
+	}
 }
 
+// Stop останавливает расписания и ждет, пока воркеры закончат текущие задачи
 func (r *TaskRunner) Stop() {
-	r.logger.Info("Stopping task runner")
-	close(r.tasksChan)
-	r.wg.Wait()
-	r.logger.Info("All workers stopped")
+	r.stopOnce.Do(func() {
+		r.logger.Info("Stopping task runner")
+		close(r.done)
+		r.schedWg.Wait()
+		// После done новые отправки не начинаются, а начатые выходят по <-r.done
+		r.sendMu.Lock()
+		r.stopped = true
+		r.sendMu.Unlock()
+		r.sendWg.Wait()
+		close(r.tasksChan)
+		r.wg.Wait()
+		r.cancel()
+		r.logger.Info("All workers stopped")
+	})
 }
 
+// Shutdown мягко останавливает раннер: задачи видят Stopping и после текущей пачки
+// выходят. Если за timeout они не завершились, их контекст отменяется, и недокачанная
+// пачка отбрасывается без сохранения
+func (r *TaskRunner) Shutdown(timeout time.Duration) error {
+	r.drainOnce.Do(func() { close(r.stopping) })
+	stopped := make(chan struct{})
+	go func() {
+		r.Stop()
+		close(stopped)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-stopped:
+		return nil
+	case <-timer.C:
+		r.logger.Warn("Tasks did not finish in time, cancelling", zap.Duration("timeout", timeout))
+		r.cancel()
+		<-stopped
+		return ErrShutdownTimeout
+	}
+}
+
+// Enqueue ставит задачу в очередь. Если задача на паузе, уже в очереди или выполняется, запуск пропускается
 func (r *TaskRunner) Enqueue(taskName string) {
+	entry, ok := r.handlers[taskName]
+	if ok {
+		if entry.paused.Load() {
+			r.logger.Info("Task paused, skip", zap.String("task", taskName))
+			return
+		}
+		if !entry.state.CompareAndSwap(taskIdle, taskQueued) {
+			r.logger.Info("Task already queued or running, skip", zap.String("task", taskName))
+			return
+		}
+	}
+	r.send(taskName, entry)
+}
+
+func (r *TaskRunner) send(taskName string, entry *taskEntry) {
 	select {
 	case r.tasksChan <- taskName:
 		r.logger.Debug("Task enqueued", zap.String("task", taskName))
 	case <-r.ctx.Done():
+		r.resetState(entry)
 		r.logger.Warn("Failed to enqueue task, context done", zap.String("task", taskName))
+	case <-r.done:
+		r.resetState(entry)
+		r.logger.Warn("Failed to enqueue task, runner stopped", zap.String("task", taskName))
+	}
+}
+
+func (r *TaskRunner) resetState(entry *taskEntry) {
+	if entry != nil {
+		entry.state.Store(taskIdle)
+	}
+}
+
+// Trigger запускает задачу вне расписания, не дожидаясь свободного воркера
+func (r *TaskRunner) Trigger(taskName string) error {
+	entry, ok := r.handlers[taskName]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	if entry.paused.Load() {
+		return ErrTaskPaused
+	}
+	r.sendMu.Lock()
+	defer r.sendMu.Unlock()
+	if r.stopped {
+		return ErrRunnerStopped
+	}
+	if !entry.state.CompareAndSwap(taskIdle, taskQueued) {
+		return ErrTaskBusy
+	}
+	r.sendWg.Add(1)
+	go func() {
+		defer r.sendWg.Done()
+		r.send(taskName, entry)
+	}()
+	return nil
+}
+
+// Pause запрещает новые запуски задачи, текущий запуск продолжается
+func (r *TaskRunner) Pause(taskName string) error {
+	entry, ok := r.handlers[taskName]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	entry.paused.Store(true)
+	r.logger.Info("Task paused", zap.String("task", taskName))
+	return nil
+}
+
+// Resume снимает задачу с паузы
+func (r *TaskRunner) Resume(taskName string) error {
+	entry, ok := r.handlers[taskName]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	entry.paused.Store(false)
+	r.logger.Info("Task resumed", zap.String("task", taskName))
+	return nil
+}
+
+// Cancel отменяет контекст выполняющегося запуска задачи
+func (r *TaskRunner) Cancel(taskName string) error {
+	entry, ok := r.handlers[taskName]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	entry.mu.Lock()
+	cancel := entry.cancel
+	entry.mu.Unlock()
+	if cancel == nil {
+		return ErrTaskNotRunning
+	}
+	cancel()
+	r.logger.Info("Task cancelled", zap.String("task", taskName))
+	return nil
+}
+
+// Status возвращает состояние задачи по имени
+func (r *TaskRunner) Status(taskName string) (TaskStatus, error) {
+	entry, ok := r.handlers[taskName]
+	if !ok {
+		return TaskStatus{}, ErrTaskNotFound
+	}
+	return entry.status(taskName), nil
+}
+
+// Workers возвращает число работающих воркеров и сколько их должно быть
+func (r *TaskRunner) Workers() (alive, total int) {
+	return int(r.alive.Load()), r.workerCount
+}
+
+// LastProgress время последнего успешного шага задачи, если задача его сообщает
+func (r *TaskRunner) LastProgress(taskName string) (time.Time, error) {
+	entry, ok := r.handlers[taskName]
+	if !ok {
+		return time.Time{}, ErrTaskNotFound
+	}
+	p, ok := entry.handler.(ProgressReporter)
+	if !ok {
+		return time.Time{}, ErrNoProgress
+	}
+	return p.LastProgress(), nil
+}
+
+// Statuses возвращает состояния всех зарегистрированных задач, отсортированные по имени
+func (r *TaskRunner) Statuses() []TaskStatus {
+	res := make([]TaskStatus, 0, len(r.handlers))
+	for name, entry := range r.handlers {
+		res = append(res, entry.status(name))
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+// scheduler ставит задачу в очередь по ее расписанию до остановки раннера
+func (r *TaskRunner) scheduler(name string, entry *taskEntry) {
+	defer r.schedWg.Done()
+	if entry.runOnStart {
+		r.Enqueue(name)
+	}
+	if entry.schedule == nil {
+		return
+	}
+	for {
+		wait := time.Until(entry.schedule.Next(time.Now()))
+		if entry.jitter > 0 {
+			wait += time.Duration(rand.Int63n(int64(entry.jitter)))
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-r.ctx.Done():
+			timer.Stop()
+			return
+		case <-r.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+			r.logger.Debug("Scheduled task fired", zap.String("task", name))
+			r.Enqueue(name)
+		}
+	}
+}
+
+// run выполняет задачу в собственном отменяемом контексте и обновляет статус
+func (r *TaskRunner) run(entry *taskEntry) error {
+	taskCtx, cancel := context.WithCancel(r.ctx)
+	defer cancel()
+
+	start := time.Now()
+	entry.mu.Lock()
+	entry.cancel = cancel
+	entry.lastStartedAt = start
+	entry.runs++
+	entry.mu.Unlock()
+	entry.state.Store(taskRunning)
+
+	err := entry.handler.Process(taskCtx, r.logger)
+	result := "ok"
+	if err != nil {
+		result = "error"
+		if taskCtx.Err() != nil {
+			result = "cancelled"
+		}
+	}
+	metrics.TaskDuration.WithLabelValues(entry.name, result).Observe(time.Since(start).Seconds())
+
+	entry.mu.Lock()
+	entry.cancel = nil
+	entry.lastFinishedAt = time.Now()
+	entry.lastErr = err
+	if err != nil {
+		entry.failures++
+		if taskCtx.Err() != nil && r.ctx.Err() == nil {
+			entry.cancels++
+		}
 	}
+	entry.mu.Unlock()
+	entry.state.Store(taskIdle)
+	return err
 }
 
 func (r *TaskRunner) worker(id int) {
 	defer r.wg.Done()
+	r.alive.Add(1)
+	defer r.alive.Add(-1)
 	r.logger.Info("Worker started", zap.Int("worker_id", id))
 
 	for {
@@ -75,14 +479,14 @@ func (r *TaskRunner) worker(id int) {
 				return
 			}
 
-			handler, ok := r.handlers[taskName]
+			entry, ok := r.handlers[taskName]
 			if !ok {
 				r.logger.Warn("No handler found for task", zap.Int("worker_id", id), zap.String("task", taskName))
 				continue
 			}
 
 			r.logger.Debug("Processing task", zap.Int("worker_id", id), zap.String("task", taskName))
-			err := handler.Process(r.ctx, r.logger)
+			err := r.run(entry)
 			if err != nil {
 				r.logger.Error("Error processing task", zap.Int("worker_id", id), zap.String("task", taskName), zap.Error(err))
 			}
@@ -3,9 +3,11 @@ package pkg_test
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/tim8842/tender-data-loader/pkg"
 	"go.uber.org/zap"
@@ -124,3 +126,251 @@ func TestTaskRunner(t *testing.T) {
 		})
 	}
 }
+
+// slowTaskHandler считает запуски и максимальное число одновременных выполнений
+type slowTaskHandler struct {
+	duration time.Duration
+	calls    atomic.Int32
+	active   atomic.Int32
+	maxSeen  atomic.Int32
+}
+
+func (h *slowTaskHandler) Process(ctx context.Context, logger *zap.Logger) error {
+	h.calls.Add(1)
+	n := h.active.Add(1)
+	if n > h.maxSeen.Load() {
+		h.maxSeen.Store(n)
+	}
+	time.Sleep(h.duration)
+	h.active.Add(-1)
+	return nil
+}
+
+func TestTaskRunner_Schedule(t *testing.T) {
+	logger := zap.NewNop()
+	tests := []struct {
+		name       string
+		duration   time.Duration
+		opts       []pkg.TaskOption
+		wait       time.Duration
+		minCalls   int32
+		maxCalls   int32
+		maxOverlap int32
+	}{
+		{
+			name:       "fixed interval",
+			opts:       []pkg.TaskOption{pkg.WithSchedule(pkg.Every(20 * time.Millisecond))},
+			wait:       110 * time.Millisecond,
+			minCalls:   3,
+			maxCalls:   6,
+			maxOverlap: 1,
+		},
+		{
+			name:       "run on start without schedule",
+			opts:       []pkg.TaskOption{pkg.WithRunOnStart()},
+			wait:       50 * time.Millisecond,
+			minCalls:   1,
+			maxCalls:   1,
+			maxOverlap: 1,
+		},
+		{
+			name:       "no overlapping runs",
+			duration:   60 * time.Millisecond,
+			opts:       []pkg.TaskOption{pkg.WithSchedule(pkg.Every(5 * time.Millisecond)), pkg.WithRunOnStart()},
+			wait:       150 * time.Millisecond,
+			minCalls:   2,
+			maxCalls:   4,
+			maxOverlap: 1,
+		},
+		{
+			name:       "jitter delays run",
+			opts:       []pkg.TaskOption{pkg.WithSchedule(pkg.Every(10 * time.Millisecond)), pkg.WithJitter(time.Second)},
+			wait:       50 * time.Millisecond,
+			minCalls:   0,
+			maxCalls:   1,
+			maxOverlap: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			h := &slowTaskHandler{duration: tt.duration}
+			runner := pkg.NewTaskRunner(ctx, logger, 3)
+			runner.RegisterTask("task", h, tt.opts...)
+			runner.Start()
+			time.Sleep(tt.wait)
+			runner.Stop()
+
+			calls := h.calls.Load()
+			assert.GreaterOrEqual(t, calls, tt.minCalls)
+			assert.LessOrEqual(t, calls, tt.maxCalls)
+			assert.LessOrEqual(t, h.maxSeen.Load(), tt.maxOverlap)
+		})
+	}
+}
+
+// blockingTaskHandler выполняется до отмены контекста
+type blockingTaskHandler struct {
+	started chan struct{}
+}
+
+func (h *blockingTaskHandler) Process(ctx context.Context, logger *zap.Logger) error {
+	h.started <- struct{}{}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestTaskRunner_Control(t *testing.T) {
+	logger := zap.NewNop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := &blockingTaskHandler{started: make(chan struct{}, 1)}
+	runner := pkg.NewTaskRunner(ctx, logger, 1)
+	runner.RegisterTask("blocking", h)
+	runner.Start()
+	defer runner.Stop()
+
+	assert.ErrorIs(t, runner.Trigger("unknown"), pkg.ErrTaskNotFound)
+	assert.ErrorIs(t, runner.Cancel("blocking"), pkg.ErrTaskNotRunning)
+
+	// Пауза запрещает запуск
+	assert.NoError(t, runner.Pause("blocking"))
+	assert.ErrorIs(t, runner.Trigger("blocking"), pkg.ErrTaskPaused)
+	st, err := runner.Status("blocking")
+	assert.NoError(t, err)
+	assert.Equal(t, pkg.TaskStatePaused, st.State)
+	assert.NoError(t, runner.Resume("blocking"))
+
+	// Запуск и повторный запуск во время выполнения
+	assert.NoError(t, runner.Trigger("blocking"))
+	<-h.started
+	assert.ErrorIs(t, runner.Trigger("blocking"), pkg.ErrTaskBusy)
+	st, _ = runner.Status("blocking")
+	assert.Equal(t, pkg.TaskStateRunning, st.State)
+	assert.NotNil(t, st.LastStartedAt)
+
+	// Отмена выполняющейся задачи
+	assert.NoError(t, runner.Cancel("blocking"))
+	assert.Eventually(t, func() bool {
+		st, _ := runner.Status("blocking")
+		return st.State == pkg.TaskStateFailed
+	}, time.Second, 5*time.Millisecond)
+
+	statuses := runner.Statuses()
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, int64(1), statuses[0].Runs)
+	assert.Equal(t, int64(1), statuses[0].Failures)
+	assert.Equal(t, int64(1), statuses[0].Cancels)
+	assert.Equal(t, context.Canceled.Error(), statuses[0].LastError)
+}
+
+type progressTask struct {
+	MockTaskHandler
+	last time.Time
+}
+
+func (p *progressTask) LastProgress() time.Time { return p.last }
+
+func TestTaskRunner_Health(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runner := pkg.NewTaskRunner(ctx, zap.NewNop(), 3)
+	last := time.Date(2025, 6, 20, 12, 0, 0, 0, time.UTC)
+	runner.RegisterTask("progress", &progressTask{last: last})
+	runner.RegisterTask("plain", &MockTaskHandler{})
+
+	alive, total := runner.Workers()
+	assert.Equal(t, 0, alive)
+	assert.Equal(t, 3, total)
+
+	runner.Start()
+	assert.Eventually(t, func() bool {
+		alive, _ := runner.Workers()
+		return alive == 3
+	}, time.Second, 5*time.Millisecond)
+
+	got, err := runner.LastProgress("progress")
+	assert.NoError(t, err)
+	assert.Equal(t, last, got)
+	_, err = runner.LastProgress("plain")
+	assert.ErrorIs(t, err, pkg.ErrNoProgress)
+	_, err = runner.LastProgress("unknown")
+	assert.ErrorIs(t, err, pkg.ErrTaskNotFound)
+
+	runner.Stop()
+	alive, _ = runner.Workers()
+	assert.Equal(t, 0, alive)
+}
+
+// loopTask имитирует задачу, которая качает пачки до сигнала остановки
+type loopTask struct {
+	batches   atomic.Int32
+	cancelled atomic.Bool
+	ignore    bool // не смотреть на Stopping, выйти только по отмене контекста
+}
+
+func (l *loopTask) Process(ctx context.Context, logger *zap.Logger) error {
+	for {
+		if !l.ignore && pkg.Stopping(ctx) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			l.cancelled.Store(true)
+			return ctx.Err()
+		case <-time.After(5 * time.Millisecond):
+			l.batches.Add(1)
+		}
+	}
+}
+
+func TestTaskRunner_Shutdown(t *testing.T) {
+	tests := []struct {
+		name            string
+		task            *loopTask
+		expectErr       error
+		expectCancelled bool
+	}{
+		{name: "task stops after current batch", task: &loopTask{}},
+		{name: "task ignoring stop is cancelled", task: &loopTask{ignore: true}, expectErr: pkg.ErrShutdownTimeout, expectCancelled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := pkg.NewTaskRunner(context.Background(), zap.NewNop(), 1)
+			runner.RegisterTask("loop", tt.task, pkg.WithRunOnStart())
+			assert.False(t, pkg.Stopping(context.Background()))
+			runner.Start()
+			assert.Eventually(t, func() bool { return tt.task.batches.Load() > 0 }, time.Second, time.Millisecond)
+
+			err := runner.Shutdown(100 * time.Millisecond)
+			assert.ErrorIs(t, err, tt.expectErr)
+			assert.Equal(t, tt.expectCancelled, tt.task.cancelled.Load())
+			// Повторная остановка ничего не ломает
+			runner.Stop()
+		})
+	}
+}
+
+// Запуск вручную во время остановки не должен писать в закрытый канал задач
+func TestTaskRunner_TriggerDuringStop(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		// Без воркеров отправка из Trigger висит, пока раннер не остановится
+		runner := pkg.NewTaskRunner(context.Background(), zap.NewNop(), 0)
+		runner.RegisterTask("task", new(MockTaskHandler))
+		runner.Start()
+		assert.NoError(t, runner.Trigger("task"))
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			runner.Stop()
+		}()
+		err := runner.Trigger("task")
+		if err != nil {
+			assert.True(t, errors.Is(err, pkg.ErrTaskBusy) || errors.Is(err, pkg.ErrRunnerStopped), err)
+		}
+		<-done
+		assert.ErrorIs(t, runner.Trigger("task"), pkg.ErrRunnerStopped)
+	}
+}
@@ -2,11 +2,12 @@ package pkg
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"math/rand"
 	"time"
 
 	"github.com/tim8842/tender-data-loader/pkg/cutter"
+	"github.com/tim8842/tender-data-loader/pkg/metrics"
 	"go.uber.org/zap"
 )
 
@@ -14,36 +15,97 @@ type FuncInWrapp interface {
 	Process(ctx context.Context, logger *zap.Logger) (any, error)
 }
 
-func FuncWrapper(ctx context.Context, logger *zap.Logger, maxRetries int, delay time.Duration, fn FuncInWrapp) (any, error) {
-	// Функция обертка для того, чтобы функция пыталась выполнится еще несколько раз, если не получилось
-	var result any = nil
-	retries := 0
-	for retries <= maxRetries {
-		res, err := fn.Process(ctx, logger)
-
-		if err == nil {
-			result = res
-			logger.Debug(cutter.TruncateRunes(fmt.Sprintf("Fuction complete, res: %v\n", result), 79))
-
-			break // Если функция выполнена успешно, переходим к следующей
-		} else {
+// RetryPolicy описывает, сколько раз и с какой задержкой повторять функцию
+type RetryPolicy struct {
+	MaxRetries int              // количество повторов после первой попытки
+	BaseDelay  time.Duration    // задержка перед первым повтором
+	MaxDelay   time.Duration    // верхняя граница задержки, 0 — без ограничения
+	Multiplier float64          // во сколько раз растет задержка, <= 1 — постоянная задержка
+	Jitter     float64          // доля случайного разброса задержки, от 0 до 1
+	Retryable  func(error) bool // какие ошибки повторять, nil — любые
+}
 
-			retries++
+// ConstantPolicy повторяет любую ошибку maxRetries раз с постоянной задержкой
+func ConstantPolicy(maxRetries int, delay time.Duration) RetryPolicy {
+	return RetryPolicy{MaxRetries: maxRetries, BaseDelay: delay}
+}
 
-			if retries <= maxRetries {
-				logger.Debug(fmt.Sprintf("Retry function (%d/%d) for %v...\n", retries, maxRetries, delay))
-				time.Sleep(delay)
-			} else {
-				logger.Debug("The maximum number of attempts has been exceeded.")
-				return nil, fmt.Errorf("failed to execute function after %d retries: %w", maxRetries, err)
+// Delay возвращает задержку перед повтором с номером retry (начиная с 1)
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	d := float64(p.BaseDelay)
+	if p.Multiplier > 1 {
+		for i := 1; i < retry; i++ {
+			d *= p.Multiplier
+			if p.MaxDelay > 0 && d >= float64(p.MaxDelay) {
+				break
 			}
 		}
 	}
-	if retries > maxRetries {
-		// Этот блок выполняется, если цикл завершился из-за превышения retries
-		logger.Debug("The maximum number of attempts has been exceeded.")
-		return nil, errors.New("failed to execute function pipeline") // Возвращаем ошибку
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
 	}
+	if d < 0 {
+		return 0
+	}
+	return time.Duration(d)
+}
+
+// Wait ждет задержку перед повтором с номером retry. Возвращает ошибку контекста,
+// если его отменили раньше
+func (p RetryPolicy) Wait(ctx context.Context, retry int) error {
+	return sleep(ctx, p.Delay(retry))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
 
-	return result, nil // Возвращаем результат последней успешно выполненной функции и nil для ошибки
+func (p RetryPolicy) shouldRetry(err error) bool {
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// FuncWrapper повторяет функцию maxRetries раз с постоянной задержкой
+func FuncWrapper(ctx context.Context, logger *zap.Logger, maxRetries int, delay time.Duration, fn FuncInWrapp) (any, error) {
+	return RetryWithPolicy(ctx, logger, ConstantPolicy(maxRetries, delay), fn)
+}
+
+// RetryWithPolicy выполняет функцию и повторяет ее по политике.
+// Ожидание между попытками прерывается отменой контекста, неповторяемые ошибки возвращаются сразу
+func RetryWithPolicy(ctx context.Context, logger *zap.Logger, policy RetryPolicy, fn FuncInWrapp) (any, error) {
+	for retry := 0; ; retry++ {
+		res, err := fn.Process(ctx, logger)
+		if err == nil {
+			logger.Debug(cutter.TruncateRunes(fmt.Sprintf("Fuction complete, res: %v\n", res), 79))
+			return res, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("function aborted: %w: %w", ctxErr, err)
+		}
+		if !policy.shouldRetry(err) {
+			logger.Debug("Error is not retryable", zap.Error(err))
+			return nil, fmt.Errorf("non-retryable error: %w", err)
+		}
+		if retry >= policy.MaxRetries {
+			metrics.RetriesExhausted.WithLabelValues(metrics.TypeName(fn)).Inc()
+			logger.Debug("The maximum number of attempts has been exceeded.")
+			return nil, fmt.Errorf("failed to execute function after %d retries: %w", policy.MaxRetries, err)
+		}
+
+		metrics.Retries.WithLabelValues(metrics.TypeName(fn)).Inc()
+		delay := policy.Delay(retry + 1)
+		logger.Debug(fmt.Sprintf("Retry function (%d/%d) for %v...\n", retry+1, policy.MaxRetries, delay))
+		if ctxErr := sleep(ctx, delay); ctxErr != nil {
+			return nil, fmt.Errorf("function aborted: %w: %w", ctxErr, err)
+		}
+	}
 }
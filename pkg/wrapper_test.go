@@ -6,9 +6,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/tim8842/tender-data-loader/pkg"
+	"github.com/tim8842/tender-data-loader/pkg/metrics"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
 )
@@ -69,3 +71,89 @@ func TestFuncWrapper_ExceedMaxRetries(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to execute function after 3 retries")
 	m.AssertNumberOfCalls(t, "Process", 4)
 }
+
+func TestRetryWithPolicy_Metrics(t *testing.T) {
+	ctx := context.Background()
+	retries := metrics.Retries.WithLabelValues("pkg_test.mockFunc")
+	exhausted := metrics.RetriesExhausted.WithLabelValues("pkg_test.mockFunc")
+	retriesBefore, exhaustedBefore := testutil.ToFloat64(retries), testutil.ToFloat64(exhausted)
+
+	m := new(mockFunc)
+	m.On("Process", ctx, mock.Anything).Return(nil, errors.New("fail"))
+	_, err := pkg.FuncWrapper(ctx, zap.NewNop(), 2, time.Millisecond, m)
+
+	assert.Error(t, err)
+	assert.Equal(t, 2.0, testutil.ToFloat64(retries)-retriesBefore)
+	assert.Equal(t, 1.0, testutil.ToFloat64(exhausted)-exhaustedBefore)
+}
+
+func TestRetryWithPolicy_NotRetryable(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	errNotFound := errors.New("not found")
+
+	m := new(mockFunc)
+	m.On("Process", ctx, logger).Return(nil, errNotFound).Once()
+
+	policy := pkg.RetryPolicy{
+		MaxRetries: 3,
+		BaseDelay:  10 * time.Millisecond,
+		Retryable:  func(err error) bool { return !errors.Is(err, errNotFound) },
+	}
+	res, err := pkg.RetryWithPolicy(ctx, logger, policy, m)
+
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, errNotFound)
+	m.AssertNumberOfCalls(t, "Process", 1)
+}
+
+func TestRetryWithPolicy_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	logger := zaptest.NewLogger(t)
+
+	m := new(mockFunc)
+	m.On("Process", ctx, logger).Return(nil, errors.New("fail")).Run(func(mock.Arguments) {
+		time.AfterFunc(20*time.Millisecond, cancel)
+	})
+
+	start := time.Now()
+	res, err := pkg.RetryWithPolicy(ctx, logger, pkg.ConstantPolicy(3, time.Minute), m)
+
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+	m.AssertNumberOfCalls(t, "Process", 1)
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := pkg.RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second, Multiplier: 2}
+	tests := []struct {
+		retry    int
+		expected time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 5 * time.Second},
+		{10, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, policy.Delay(tt.retry))
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := policy.Delay(2)
+		assert.GreaterOrEqual(t, d, time.Second)
+		assert.LessOrEqual(t, d, 3*time.Second)
+	}
+	assert.Equal(t, time.Second, pkg.ConstantPolicy(3, time.Second).Delay(5))
+}
+
+func TestRetryPolicy_Wait(t *testing.T) {
+	policy := pkg.RetryPolicy{BaseDelay: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, policy.Wait(ctx, 1), context.Canceled)
+	assert.NoError(t, pkg.RetryPolicy{BaseDelay: time.Millisecond}.Wait(context.Background(), 1))
+}